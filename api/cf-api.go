@@ -18,19 +18,48 @@ package api
 
 import (
 	"github.com/cloudfoundry-community/go-cfenv"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 	"net/http"
+	"sync"
 )
 
 // CfAPI is the implementation of API interface. It is point of access to CF CloudController API
 type CfAPI struct {
 	BaseAddress string
 	*http.Client
+	// BlobstoreTransport downloads data CC redirects to blobstore. It must not add CC credentials.
+	// http.DefaultTransport is used when nil.
+	BlobstoreTransport http.RoundTripper
+
+	infoMutex    sync.Mutex
+	info         *types.CfInfo
+	capabilities *Capabilities
+	// clientCredentials is set when CC is accessed with client_credentials grant, which has no user
+	clientCredentials bool
 }
 
 // NewCfAPI constructs and initializes access to CF by loading necessary credentials from ENVs
 func NewCfAPI() *CfAPI {
+	return newCfAPI(oauth2.NoContext)
+}
+
+// NewCfAPIWithTransport works like NewCfAPI but both UAA and CC are accessed
+// through transport built from provided configuration
+func NewCfAPIWithTransport(config TransportConfig) (*CfAPI, error) {
+	transport, err := NewHTTPTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.WithValue(oauth2.NoContext, oauth2.HTTPClient, &http.Client{Transport: transport})
+	toReturn := newCfAPI(ctx)
+	toReturn.BlobstoreTransport = transport
+	return toReturn, nil
+}
+
+func newCfAPI(ctx context.Context) *CfAPI {
 	envs := cfenv.CurrentEnv()
 	tokenConfig := &clientcredentials.Config{
 		ClientID:     envs["CLIENT_ID"],
@@ -40,6 +69,7 @@ func NewCfAPI() *CfAPI {
 	}
 	toReturn := new(CfAPI)
 	toReturn.BaseAddress = envs["CF_API"]
-	toReturn.Client = tokenConfig.Client(oauth2.NoContext)
+	toReturn.Client = tokenConfig.Client(ctx)
+	toReturn.clientCredentials = true
 	return toReturn
 }
@@ -0,0 +1,143 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+)
+
+// Fixtures in testdata are responses of CloudController API 2.65
+var _ = Describe("Cf app entity", func() {
+
+	// settings which shall survive decoding and sending the app back to CC
+	settings := []string{"name", "space_guid", "stack_guid", "buildpack", "environment_json", "memory", "instances",
+		"disk_quota", "state", "health_check_type", "health_check_timeout", "health_check_http_endpoint",
+		"diego", "enable_ssh", "ports"}
+	readOnly := []string{"package_state", "detected_buildpack", "detected_start_command", "version",
+		"package_updated_at", "staging_task_id", "staging_failed_reason"}
+
+	var sut CfAPI
+
+	fixture := func(name string) []byte {
+		content, err := ioutil.ReadFile("testdata/" + name)
+		Expect(err).NotTo(HaveOccurred())
+		return content
+	}
+
+	decodeMap := func(content []byte) map[string]interface{} {
+		decoded := map[string]interface{}{}
+		Expect(json.Unmarshal(content, &decoded)).To(Succeed())
+		return decoded
+	}
+
+	capturingResponder := func(captured *[]byte, code int, v interface{}) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			*captured, _ = ioutil.ReadAll(req.Body)
+			return httpmock.NewJsonResponse(code, v)
+		}
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should decode full v2 app entity", func() {
+		httpmock.RegisterResponder("GET", "/v2/apps/guid", httpmock.NewBytesResponder(200, fixture("app.json")))
+
+		app, err := sut.GetApp("guid")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(app.Entity.HealthCheckType).To(Equal("http"))
+		Expect(app.Entity.HealthCheckTimeout).To(Equal(180))
+		Expect(app.Entity.HealthCheckHTTPEndpoint).To(Equal("/health"))
+		Expect(app.Entity.StackGUID).To(Equal("f6c960cc-98ba-4fd1-b197-ecbf39108aa2"))
+		Expect(app.Entity.DockerImage).To(BeEmpty())
+		Expect(*app.Entity.Diego).To(BeTrue())
+		Expect(*app.Entity.EnableSSH).To(BeFalse())
+		Expect(app.Entity.Ports).To(Equal([]int{8080, 8081}))
+		Expect(app.Entity.DetectedBuildpack).To(Equal("java-buildpack=v3.7.1"))
+	})
+
+	It("should send settings back unchanged on update", func() {
+		httpmock.RegisterResponder("GET", "/v2/apps/guid", httpmock.NewBytesResponder(200, fixture("app.json")))
+		var sent []byte
+		httpmock.RegisterResponder("PUT", "/v2/apps/6064d98a-95e6-400b-bc03-be65e6d59622",
+			capturingResponder(&sent, 201, nil))
+		app, err := sut.GetApp("guid")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sut.UpdateApp(app)).To(Succeed())
+
+		original := decodeMap(fixture("app.json"))["entity"].(map[string]interface{})
+		updated := decodeMap(sent)
+		for _, key := range settings {
+			Expect(updated).To(HaveKeyWithValue(key, original[key]), key)
+		}
+		for _, key := range readOnly {
+			Expect(updated).NotTo(HaveKey(key))
+		}
+	})
+
+	It("should not reset unknown settings when app is sent without them", func() {
+		var sent []byte
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid", capturingResponder(&sent, 201, nil))
+
+		Expect(sut.UpdateApp(&types.CfAppResource{Meta: types.CfMeta{GUID: "guid"}, Entity: types.CfApp{Name: "app"}})).To(Succeed())
+
+		updated := decodeMap(sent)
+		for _, key := range []string{"diego", "enable_ssh", "ports", "stack_guid", "docker_image", "health_check_type"} {
+			Expect(updated).NotTo(HaveKey(key))
+		}
+	})
+
+	It("should carry settings over to application clone", func() {
+		var created []byte
+		httpmock.RegisterResponder("GET", "/v2/apps/guid/summary", httpmock.NewBytesResponder(200, fixture("app-summary.json")))
+		httpmock.RegisterResponder("POST", "/v2/apps", capturingResponder(&created, 201,
+			types.CfAppResource{Meta: types.CfMeta{GUID: "clone_guid"}}))
+		httpmock.RegisterResponder("POST", "/v2/routes", responderGenerator(201,
+			types.CfRouteResource{Meta: types.CfMeta{GUID: "route_guid"}, Entity: types.CfRoute{Host: "clone"}}))
+		httpmock.RegisterResponder("PUT", "/v2/apps/clone_guid/routes/route_guid", responderGenerator(201, nil))
+
+		_, err := sut.CreateApplicationClone("guid", "other_space", map[string]string{"name": "clone"})
+
+		Expect(err).NotTo(HaveOccurred())
+		source := decodeMap(fixture("app-summary.json"))
+		clone := decodeMap(created)
+		Expect(clone).To(HaveKeyWithValue("name", "clone"))
+		Expect(clone).To(HaveKeyWithValue("space_guid", "other_space"))
+		Expect(clone).To(HaveKeyWithValue("state", types.AppStopped))
+		for _, key := range []string{"stack_guid", "buildpack", "environment_json", "memory", "instances", "disk_quota",
+			"health_check_type", "health_check_timeout", "health_check_http_endpoint", "diego", "enable_ssh", "ports"} {
+			Expect(clone).To(HaveKeyWithValue(key, source[key]), key)
+		}
+		for _, key := range readOnly {
+			Expect(clone).NotTo(HaveKey(key))
+		}
+	})
+})
@@ -0,0 +1,112 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"reflect"
+)
+
+const (
+	// EnvApplyNone leaves running instances with the old environment until next start
+	EnvApplyNone = ""
+	// EnvApplyRestart restarts started app, which is enough for runtime variables
+	EnvApplyRestart = "restart"
+	// EnvApplyRestage restages started app, needed when variables affect buildpacks
+	EnvApplyRestage = "restage"
+)
+
+// EnvApply tells how patched environment is applied to the app. Stopped apps are never started.
+type EnvApply struct {
+	Action string
+	Wait   WaitOptions
+}
+
+// GetAppEnv returns full environment of the app: user provided variables, environment
+// variable groups, VCAP_SERVICES and VCAP_APPLICATION. It requires space developer role.
+func (c *CfAPI) GetAppEnv(appGUID string) (*types.CfAppEnv, error) {
+	address := fmt.Sprintf("%v/v2/apps/%v/env", c.BaseAddress, appGUID)
+	toReturn := new(types.CfAppEnv)
+	if err := c.getAndDecode(address, "app environment", toReturn); err != nil {
+		return nil, err
+	}
+	log.Debugf("Environment of app %v retrieved, %d services bound", appGUID, len(toReturn.SystemEnv.VcapServices))
+	return toReturn, nil
+}
+
+// PatchAppEnv sets and removes user provided variables of the app, keeping the other ones.
+// Only environment_json is sent, so concurrent changes of other app fields are not overwritten.
+// Returned app holds the patched environment.
+func (c *CfAPI) PatchAppEnv(appGUID string, set map[string]interface{}, unset []string, apply EnvApply) (*types.CfAppResource, error) {
+	for _, name := range unset {
+		if _, ok := set[name]; ok {
+			msg := fmt.Sprintf("Variable %v can not be both set and unset", name)
+			log.Error(msg)
+			return nil, errors.Annotate(types.InvalidEnvPatchError, msg)
+		}
+	}
+	switch apply.Action {
+	case EnvApplyNone, EnvApplyRestart, EnvApplyRestage:
+	default:
+		return nil, errors.Annotate(types.InvalidEnvPatchError, "Unknown apply action: "+apply.Action)
+	}
+
+	app, err := c.GetApp(appGUID)
+	if err != nil {
+		return nil, err
+	}
+	env := mergeEnv(app.Entity.Envs, set, unset)
+	if reflect.DeepEqual(env, app.Entity.Envs) || (len(env) == 0 && len(app.Entity.Envs) == 0) {
+		log.Infof("Environment of app %v already up to date", appGUID)
+		return app, nil
+	}
+	if err := c.updateAppFields(appGUID, map[string]interface{}{"environment_json": env}); err != nil {
+		return nil, err
+	}
+	app.Entity.Envs = env
+
+	if app.Entity.State != types.AppStarted {
+		return app, nil
+	}
+	switch apply.Action {
+	case EnvApplyRestart:
+		_, err = c.RestartApp(appGUID, apply.Wait)
+	case EnvApplyRestage:
+		if _, err = c.RestageAndWait(appGUID, apply.Wait); err == nil {
+			_, err = c.waitForAppRunning(appGUID, app.Entity.InstanceCount, apply.Wait)
+		}
+	}
+	return app, err
+}
+
+// mergeEnv returns copy of env with variables set and unset. Env itself is not modified.
+func mergeEnv(env map[string]interface{}, set map[string]interface{}, unset []string) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for name, value := range env {
+		merged[name] = value
+	}
+	for name, value := range set {
+		merged[name] = value
+	}
+	for _, name := range unset {
+		delete(merged, name)
+	}
+	return merged
+}
@@ -0,0 +1,180 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("Cf app env", func() {
+
+	var (
+		sut     CfAPI
+		app     types.CfAppResource
+		updates []map[string]interface{}
+		apply   EnvApply
+	)
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		app = types.CfAppResource{Meta: types.CfMeta{GUID: "guid"}, Entity: types.CfApp{Name: "app",
+			State: types.AppStopped, InstanceCount: 1, Envs: map[string]interface{}{"KEEP": "1", "OLD": "2"}}}
+		updates = []map[string]interface{}{}
+		apply = EnvApply{Wait: WaitOptions{Timeout: 200 * time.Millisecond, PollInterval: time.Millisecond}}
+
+		httpmock.RegisterResponder("GET", "/v2/apps/guid", func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(200, app)
+		})
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid", func(req *http.Request) (*http.Response, error) {
+			update := map[string]interface{}{}
+			json.NewDecoder(req.Body).Decode(&update)
+			updates = append(updates, update)
+			return httpmock.NewJsonResponse(201, app)
+		})
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("get app env", func() {
+		It("should decode all sections", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/env", httpmock.NewStringResponder(200, `{
+				"staging_env_json": {"STAGING": "yes"},
+				"running_env_json": {"RUNNING": "yes"},
+				"environment_json": {"FOO": "bar"},
+				"system_env_json": {"VCAP_SERVICES": {"postgresql": [{
+					"name": "db", "label": "postgresql", "plan": "free", "tags": ["sql"],
+					"credentials": {"uri": "postgres://db"}}]}},
+				"application_env_json": {"VCAP_APPLICATION": {
+					"application_id": "guid", "application_name": "app", "name": "app",
+					"uris": ["app.example.com"], "space_name": "space", "limits": {"mem": 512, "disk": 1024, "fds": 16384}}}
+			}`))
+
+			env, err := sut.GetAppEnv("guid")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.StagingEnv).To(HaveKeyWithValue("STAGING", "yes"))
+			Expect(env.RunningEnv).To(HaveKeyWithValue("RUNNING", "yes"))
+			Expect(env.Environment).To(HaveKeyWithValue("FOO", "bar"))
+			db := env.SystemEnv.VcapServices["postgresql"]
+			Expect(db).To(HaveLen(1))
+			Expect(db[0].Name).To(Equal("db"))
+			Expect(db[0].Credentials).To(HaveKeyWithValue("uri", "postgres://db"))
+			vcapApplication := env.ApplicationEnv.VcapApplication
+			Expect(vcapApplication.ApplicationID).To(Equal("guid"))
+			Expect(vcapApplication.Uris).To(ConsistOf("app.example.com"))
+			Expect(vcapApplication.Limits.Mem).To(Equal(int64(512)))
+		})
+
+		It("should return error when user is not allowed to read env", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/env", responderGenerator(403, nil))
+
+			_, err := sut.GetAppEnv("guid")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("patch app env", func() {
+		It("should send merged environment only", func() {
+			result, err := sut.PatchAppEnv("guid", map[string]interface{}{"NEW": "3"}, []string{"OLD"}, apply)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updates).To(Equal([]map[string]interface{}{
+				{"environment_json": map[string]interface{}{"KEEP": "1", "NEW": "3"}}}))
+			Expect(result.Entity.Envs).To(Equal(map[string]interface{}{"KEEP": "1", "NEW": "3"}))
+			Expect(app.Entity.Envs).To(HaveKey("OLD"))
+		})
+
+		It("should skip update when nothing changes", func() {
+			_, err := sut.PatchAppEnv("guid", map[string]interface{}{"KEEP": "1"}, []string{"MISSING"}, apply)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updates).To(BeEmpty())
+		})
+
+		It("should refuse variable both set and unset", func() {
+			_, err := sut.PatchAppEnv("guid", map[string]interface{}{"OLD": "3"}, []string{"OLD"}, apply)
+
+			Expect(errors.Cause(err)).To(Equal(types.InvalidEnvPatchError))
+		})
+
+		It("should refuse unknown apply action", func() {
+			apply.Action = "redeploy"
+
+			_, err := sut.PatchAppEnv("guid", map[string]interface{}{"NEW": "3"}, nil, apply)
+
+			Expect(errors.Cause(err)).To(Equal(types.InvalidEnvPatchError))
+		})
+
+		It("should not start stopped app when restart is requested", func() {
+			apply.Action = EnvApplyRestart
+
+			_, err := sut.PatchAppEnv("guid", map[string]interface{}{"NEW": "3"}, nil, apply)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updates).To(HaveLen(1))
+		})
+
+		Context("with started app", func() {
+			BeforeEach(func() {
+				app.Entity.State = types.AppStarted
+				httpmock.RegisterResponder("GET", "/v2/apps/guid/instances",
+					responderGenerator(200, map[string]types.CfAppInstance{"0": {State: "RUNNING"}}))
+			})
+
+			It("should restart the app", func() {
+				apply.Action = EnvApplyRestart
+				httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", sequenceResponder(
+					responderGenerator(400, map[string]interface{}{"code": 220001}),
+					responderGenerator(200, map[string]types.CfAppInstance{"0": {State: "RUNNING"}})))
+
+				_, err := sut.PatchAppEnv("guid", map[string]interface{}{"NEW": "3"}, nil, apply)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(updates).To(HaveLen(3))
+				Expect(updates[1]).To(Equal(map[string]interface{}{"state": "STOPPED"}))
+				Expect(updates[2]).To(Equal(map[string]interface{}{"state": "STARTED"}))
+			})
+
+			It("should restage the app and wait for it running", func() {
+				apply.Action = EnvApplyRestage
+				restaged := false
+				httpmock.RegisterResponder("POST", "/v2/apps/guid/restage", func(req *http.Request) (*http.Response, error) {
+					restaged = true
+					app.Entity.PackageState = types.PackageStaged
+					return httpmock.NewJsonResponse(201, app)
+				})
+
+				_, err := sut.PatchAppEnv("guid", map[string]interface{}{"NEW": "3"}, nil, apply)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(restaged).To(BeTrue())
+				Expect(updates).To(HaveLen(1))
+			})
+		})
+	})
+})
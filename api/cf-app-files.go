@@ -0,0 +1,196 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"archive/zip"
+	"bufio"
+	log "github.com/cihub/seelog"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const cfIgnoreFile = ".cfignore"
+
+// defaultIgnored lists files never uploaded, same as cf CLI does
+var defaultIgnored = []string{".cfignore", "_darcs", ".DS_Store", ".git", ".gitignore", ".hg", "/manifest.yml", ".svn"}
+
+// appFile is a regular file of app directory. Path is relative to the directory and slash separated.
+type appFile struct {
+	path     string
+	fullPath string
+	mode     os.FileMode
+	size     int64
+}
+
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// cfIgnore holds .cfignore patterns, which follow .gitignore syntax. The last matching pattern wins.
+type cfIgnore []ignorePattern
+
+func parseCfIgnore(content string) cfIgnore {
+	patterns := cfIgnore{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// pattern without slash matches at any depth, otherwise it is relative to app directory
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		pattern.segments = strings.Split(line, "/")
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+func (c cfIgnore) ignored(relPath string, isDir bool) bool {
+	ignored := false
+	segments := strings.Split(relPath, "/")
+	for _, pattern := range c {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(pattern.segments, segments) {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments matches path segments against pattern ones, where "**" stands for any number of segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+func loadCfIgnore(dir string) (cfIgnore, error) {
+	patterns := parseCfIgnore(strings.Join(defaultIgnored, "\n"))
+	content, err := ioutil.ReadFile(filepath.Join(dir, cfIgnoreFile))
+	if os.IsNotExist(err) {
+		return patterns, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return append(patterns, parseCfIgnore(string(content))...), nil
+}
+
+// listAppFiles returns regular files of dir which are not ignored by .cfignore.
+// Symbolic links to files are followed, links to directories are skipped.
+func listAppFiles(dir string) ([]appFile, error) {
+	ignore, err := loadCfIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []appFile{}
+	err = filepath.Walk(dir, func(fullPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, fullPath)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ignore.ignored(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(fullPath); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				log.Warnf("Skipping symbolic link to directory: %v", rel)
+				return nil
+			}
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, appFile{path: rel, fullPath: fullPath, mode: info.Mode(), size: info.Size()})
+		}
+		return nil
+	})
+	return files, err
+}
+
+// writeAppZip writes files into zip archive keeping their permissions
+func writeAppZip(w io.Writer, files []appFile) error {
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		header := &zip.FileHeader{Name: file.path, Method: zip.Deflate}
+		header.SetMode(file.mode)
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(writer, file.fullPath); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func copyFile(w io.Writer, fullPath string) error {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
@@ -0,0 +1,133 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Cf app files", func() {
+
+	Describe("cfignore", func() {
+		ignore := parseCfIgnore(`
+# comment
+*.log
+/build
+tmp/
+docs/**/*.md
+!keep.log
+`)
+
+		It("should match patterns without slash at any depth", func() {
+			Expect(ignore.ignored("app.log", false)).To(BeTrue())
+			Expect(ignore.ignored("logs/deep/app.log", false)).To(BeTrue())
+			Expect(ignore.ignored("app.go", false)).To(BeFalse())
+		})
+
+		It("should anchor patterns with slash to app directory", func() {
+			Expect(ignore.ignored("build", true)).To(BeTrue())
+			Expect(ignore.ignored("src/build", true)).To(BeFalse())
+			Expect(ignore.ignored("docs/a/b/readme.md", false)).To(BeTrue())
+			Expect(ignore.ignored("docs/readme.md", false)).To(BeTrue())
+			Expect(ignore.ignored("src/readme.md", false)).To(BeFalse())
+		})
+
+		It("should apply directory patterns to directories only", func() {
+			Expect(ignore.ignored("src/tmp", true)).To(BeTrue())
+			Expect(ignore.ignored("src/tmp", false)).To(BeFalse())
+		})
+
+		It("should let later negated pattern re-include file", func() {
+			Expect(ignore.ignored("keep.log", false)).To(BeFalse())
+			Expect(ignore.ignored("src/keep.log", false)).To(BeFalse())
+		})
+	})
+
+	Describe("listing and zipping", func() {
+		var dir string
+
+		write := func(path string, content string, mode os.FileMode) {
+			fullPath := filepath.Join(dir, filepath.FromSlash(path))
+			Expect(os.MkdirAll(filepath.Dir(fullPath), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(fullPath, []byte(content), mode)).To(Succeed())
+			Expect(os.Chmod(fullPath, mode)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "app-files-")
+			Expect(err).NotTo(HaveOccurred())
+			write("run.sh", "#!/bin/sh", 0755)
+			write("src/main.go", "package main", 0644)
+			write("src/debug.log", "log", 0644)
+			write(".git/HEAD", "ref", 0644)
+			write("manifest.yml", "---", 0644)
+			write("config/manifest.yml", "---", 0644)
+			write(".cfignore", "*.log\n", 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("should skip ignored and default ignored files", func() {
+			files, err := listAppFiles(dir)
+
+			Expect(err).NotTo(HaveOccurred())
+			paths := []string{}
+			for _, file := range files {
+				paths = append(paths, file.path)
+			}
+			Expect(paths).To(ConsistOf("run.sh", "src/main.go", "config/manifest.yml"))
+		})
+
+		It("should follow symbolic links to files", func() {
+			Expect(os.Symlink(filepath.Join(dir, "run.sh"), filepath.Join(dir, "link.sh"))).To(Succeed())
+			Expect(os.Symlink(filepath.Join(dir, "src"), filepath.Join(dir, "linked-src"))).To(Succeed())
+
+			files, err := listAppFiles(dir)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(ContainElement(appFile{path: "link.sh", fullPath: filepath.Join(dir, "link.sh"),
+				mode: 0755, size: 9}))
+			Expect(files).To(HaveLen(4))
+		})
+
+		It("should keep file permissions in zip", func() {
+			files, err := listAppFiles(dir)
+			Expect(err).NotTo(HaveOccurred())
+			buffer := new(bytes.Buffer)
+
+			Expect(writeAppZip(buffer, files)).To(Succeed())
+
+			archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+			Expect(err).NotTo(HaveOccurred())
+			modes := map[string]os.FileMode{}
+			for _, entry := range archive.File {
+				modes[entry.Name] = entry.Mode()
+			}
+			Expect(modes).To(Equal(map[string]os.FileMode{
+				"run.sh": 0755, "src/main.go": 0644, "config/manifest.yml": 0644}))
+		})
+	})
+})
@@ -0,0 +1,83 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+)
+
+// ccAppStoppedStatsErrorCode is returned by CC when asked for stats of stopped app
+const ccAppStoppedStatsErrorCode = 200003
+
+// GetAppStats returns usage of app instances by index. Stopped app has no stats.
+func (c *CfAPI) GetAppStats(appGUID string) (map[string]types.CfAppInstanceStats, error) {
+	address := fmt.Sprintf("%v/v2/apps/%v/stats", c.BaseAddress, appGUID)
+	log.Infof("Getting app stats: %v", address)
+	resp, err := c.Get(address)
+	if err != nil {
+		log.Errorf("Could not get app stats: [%v]", err)
+		return nil, errors.Wrap(types.CcGetInstancesFailedError, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, types.EntityNotFoundError
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := helpers.ReaderToString(resp.Body)
+		ccError := ccErrorResponse{}
+		json.Unmarshal([]byte(message), &ccError)
+		if resp.StatusCode == http.StatusBadRequest && ccError.Code == ccAppStoppedStatsErrorCode {
+			log.Debugf("App %v is stopped: %v", appGUID, ccError.Description)
+			return map[string]types.CfAppInstanceStats{}, nil
+		}
+		log.Errorf("GetAppStats finished with error: %v", message)
+		return nil, CreateCcError(message, types.CcGetInstancesFailedError)
+	}
+
+	stats := map[string]types.CfAppInstanceStats{}
+	if err := decodeResponse(resp, &stats); err != nil {
+		return nil, err
+	}
+	log.Debugf("Stats of %d instances retrieved", len(stats))
+	return stats, nil
+}
+
+// RestartAppInstance kills single instance of the app, which CC then starts again
+func (c *CfAPI) RestartAppInstance(appGUID string, index int) error {
+	address := fmt.Sprintf("%v/v2/apps/%v/instances/%d", c.BaseAddress, appGUID, index)
+	log.Infof("Restarting app instance: %v", address)
+	request, _ := http.NewRequest("DELETE", address, nil)
+	resp, err := c.Do(request)
+	if err != nil {
+		log.Errorf("Could not restart app instance: [%v]", err)
+		return errors.Wrap(types.CcRestartInstanceFailedError, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return types.EntityNotFoundError
+	}
+	if !IsSuccessStatus(resp.StatusCode) {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("RestartAppInstance finished with error: %v", message)
+		return CreateCcError(message, types.CcRestartInstanceFailedError)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+)
+
+var _ = Describe("Cf app instances", func() {
+
+	var sut CfAPI
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("get app stats", func() {
+		It("should decode stats of instances", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/stats", httpmock.NewStringResponder(200, `{
+				"0": {
+					"state": "RUNNING",
+					"stats": {
+						"name": "app",
+						"uris": ["app.example.com"],
+						"host": "10.0.16.4",
+						"port": 61013,
+						"uptime": 1234,
+						"mem_quota": 536870912,
+						"disk_quota": 1073741824,
+						"fds_quota": 16384,
+						"usage": {"time": "2016-03-01 12:00:00 +0000", "cpu": 0.0032, "mem": 120586240, "disk": 89128960}
+					}
+				},
+				"1": {"state": "DOWN", "details": "cell unavailable", "stats": {}}
+			}`))
+
+			stats, err := sut.GetAppStats("guid")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(HaveLen(2))
+			Expect(stats["0"].Stats.URIs).To(ConsistOf("app.example.com"))
+			Expect(stats["0"].Stats.Uptime).To(Equal(int64(1234)))
+			Expect(stats["0"].Stats.MemQuota).To(Equal(int64(536870912)))
+			Expect(stats["0"].Stats.Usage).To(Equal(types.CfInstanceUsage{
+				Time: "2016-03-01 12:00:00 +0000", CPU: 0.0032, Mem: 120586240, Disk: 89128960}))
+			Expect(stats["1"].Details).To(Equal("cell unavailable"))
+		})
+
+		It("should return no stats of stopped app", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/stats", responderGenerator(400, map[string]interface{}{
+				"code": 200003, "description": "Could not fetch stats for stopped app: app",
+				"error_code": "CF-AppStoppedStatsError"}))
+
+			stats, err := sut.GetAppStats("guid")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(BeEmpty())
+		})
+
+		It("should return not found for missing app", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/stats", responderGenerator(404, nil))
+
+			_, err := sut.GetAppStats("guid")
+
+			Expect(err).To(Equal(types.EntityNotFoundError))
+		})
+
+		It("should return malformed response error for html", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/stats", htmlResponderGenerator(200, "<html></html>"))
+
+			_, err := sut.GetAppStats("guid")
+
+			Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+		})
+	})
+
+	Describe("get app instances", func() {
+		It("should decode uptime and details", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", httpmock.NewStringResponder(200,
+				`{"0": {"state": "RUNNING", "since": 1456833600.5, "uptime": 42},
+				  "1": {"state": "CRASHED", "since": 1456833601, "details": "out of memory"}}`))
+
+			instances, err := sut.GetAppInstances("guid")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instances["0"]).To(Equal(types.CfAppInstance{State: "RUNNING", Since: 1456833600.5, Uptime: 42}))
+			Expect(instances["1"].Details).To(Equal("out of memory"))
+		})
+	})
+
+	Describe("restart app instance", func() {
+		It("should delete instance of given index", func() {
+			httpmock.RegisterResponder("DELETE", "/v2/apps/guid/instances/2", responderGenerator(204, nil))
+
+			err := sut.RestartAppInstance("guid", 2)
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return CC error for index out of range", func() {
+			httpmock.RegisterResponder("DELETE", "/v2/apps/guid/instances/7", responderGenerator(400, map[string]interface{}{
+				"code": 220001, "description": "Instances error: out of range", "error_code": "CF-InstancesError"}))
+
+			err := sut.RestartAppInstance("guid", 7)
+
+			Expect(errors.Cause(err)).To(Equal(types.CcRestartInstanceFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("out of range"))
+		})
+
+		It("should return not found for missing app", func() {
+			httpmock.RegisterResponder("DELETE", "/v2/apps/guid/instances/0", responderGenerator(404, nil))
+
+			err := sut.RestartAppInstance("guid", 0)
+
+			Expect(err).To(Equal(types.EntityNotFoundError))
+		})
+
+		It("should return error when request fails", func() {
+			httpmock.RegisterResponder("DELETE", "/v2/apps/guid/instances/0", responderFailGenerator(nil))
+
+			err := sut.RestartAppInstance("guid", 0)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,383 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultWaitTimeout  = 5 * time.Minute
+	DefaultPollInterval = 5 * time.Second
+
+	// recentCrashEventsCount limits crash events included in error of crashed app
+	recentCrashEventsCount = 3
+
+	// ccInstancesErrorCode is returned by CC when asked for instances of stopped app
+	ccInstancesErrorCode = 220001
+)
+
+// WaitOptions configures waiting until app converges to requested state.
+// Zero values are replaced with DefaultWaitTimeout and DefaultPollInterval.
+type WaitOptions struct {
+	Timeout      time.Duration
+	PollInterval time.Duration
+	// Readiness tells when started app is running. Zero value requires all instances.
+	Readiness ReadinessPolicy
+}
+
+// ReadinessPolicy decides how many instances have to be running
+type ReadinessPolicy struct {
+	// MinRunning is the number of running instances required. Zero means all instances.
+	MinRunning int
+}
+
+// AllInstancesRunning requires every instance of the app to be running
+var AllInstancesRunning = ReadinessPolicy{}
+
+// AtLeastRunning requires n instances running, or all of them when the app has fewer
+func AtLeastRunning(n int) ReadinessPolicy {
+	return ReadinessPolicy{MinRunning: n}
+}
+
+func (p ReadinessPolicy) required(instances int) int {
+	if p.MinRunning > 0 && p.MinRunning < instances {
+		return p.MinRunning
+	}
+	return instances
+}
+
+// AppInstancesReport describes app instances as seen when waiting finished
+type AppInstancesReport struct {
+	AppGUID   string
+	Instances map[string]types.CfAppInstance
+}
+
+func (r *AppInstancesReport) String() string {
+	indexes := sortedIndexes(r.Instances)
+	states := make([]string, len(indexes))
+	for i, index := range indexes {
+		states[i] = index + ":" + r.Instances[index].State
+	}
+	return fmt.Sprintf("app %v instances [%v]", r.AppGUID, strings.Join(states, " "))
+}
+
+type ccErrorResponse struct {
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+	ErrorCode   string `json:"error_code"`
+}
+
+// GetAppInstances returns states of app instances by index. Stopped app has no instances.
+func (c *CfAPI) GetAppInstances(appGUID string) (map[string]types.CfAppInstance, error) {
+	instances, pending, err := c.getAppInstances(appGUID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != "" {
+		return nil, CreateCcError(pending, types.CcGetInstancesFailedError)
+	}
+	return instances, nil
+}
+
+// StopApp stops the app and waits until none of its instances is running or starting.
+// CC v2 stops reporting instances as soon as the app is stopped, so on foundations with
+// v3 API stats of the app process are polled, which include instances still shutting down.
+func (c *CfAPI) StopApp(appGUID string, options WaitOptions) (*AppInstancesReport, error) {
+	if err := c.setAppState(appGUID, types.AppStopped); err != nil {
+		return nil, err
+	}
+	getInstances := c.getAppInstances
+	if c.v3Available() {
+		getInstances = c.getProcessInstances
+	}
+	return c.waitForInstances(appGUID, options, "stopped", getInstances, func(instances map[string]types.CfAppInstance) (bool, error) {
+		for _, instance := range instances {
+			if instance.State == types.InstanceRunning || instance.State == types.InstanceStarting {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// RestartApp stops the app and starts it again, waiting for all instances running.
+// Timeout of options applies to stopping and starting separately.
+func (c *CfAPI) RestartApp(appGUID string, options WaitOptions) (*AppInstancesReport, error) {
+	if report, err := c.StopApp(appGUID, options); err != nil {
+		return report, err
+	}
+	if err := c.setAppState(appGUID, types.AppStarted); err != nil {
+		return nil, err
+	}
+	return c.waitForAppRunning(appGUID, -1, options)
+}
+
+// RestageAndWait restages the app and waits until its package is staged.
+// Failed staging results in CcStagingFailedError.
+func (c *CfAPI) RestageAndWait(appGUID string, options WaitOptions) (*types.CfAppResource, error) {
+	if err := c.RestageApp(appGUID); err != nil {
+		return nil, err
+	}
+
+	var app *types.CfAppResource
+	err := pollUntil(options, func() string {
+		return fmt.Sprintf("app %v staging, package state %v", appGUID, app.Entity.PackageState)
+	}, func() (bool, error) {
+		var err error
+		if app, err = c.GetApp(appGUID); err != nil {
+			return false, err
+		}
+		log.Debugf("App %v package state: %v", appGUID, app.Entity.PackageState)
+		switch app.Entity.PackageState {
+		case types.PackageStaged:
+			return true, nil
+		case types.PackageFailed:
+			msg := fmt.Sprintf("Staging of app %v failed: %v %v", appGUID,
+				app.Entity.StagingFailedReason, app.Entity.StagingFailedDescription)
+			log.Error(msg)
+			return false, errors.Annotate(types.CcStagingFailedError, msg)
+		}
+		return false, nil
+	})
+	return app, err
+}
+
+func (c *CfAPI) setAppState(appGUID, state string) error {
+	return c.updateAppFields(appGUID, map[string]interface{}{"state": state})
+}
+
+// updateAppFields sends only given fields of app entity, leaving the others untouched
+func (c *CfAPI) updateAppFields(appGUID string, fields map[string]interface{}) error {
+	address := fmt.Sprintf("%v/v2/apps/%v", c.BaseAddress, appGUID)
+	log.Infof("Updating app fields %v: %v", fields, address)
+	raw, _ := json.Marshal(fields)
+	request, _ := http.NewRequest("PUT", address, bytes.NewReader(raw))
+	resp, err := c.Do(request)
+	if err != nil {
+		log.Errorf("Could not update app: [%v]", err)
+		return errors.Wrap(types.CcUpdateFailedError, err)
+	} else if !IsSuccessStatus(resp.StatusCode) {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("Updating app fields finished with error: %v", message)
+		return CreateCcError(message, types.CcUpdateFailedError)
+	}
+	return nil
+}
+
+// getAppInstances returns CC response body as pending when instances are not known yet,
+// e.g. while staging is in progress
+func (c *CfAPI) getAppInstances(appGUID string) (map[string]types.CfAppInstance, string, error) {
+	address := fmt.Sprintf("%v/v2/apps/%v/instances", c.BaseAddress, appGUID)
+	resp, err := c.Get(address)
+	if err != nil {
+		log.Errorf("Could not get app instances: [%v]", err)
+		return nil, "", errors.Wrap(types.CcGetInstancesFailedError, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := helpers.ReaderToString(resp.Body)
+		ccError := ccErrorResponse{}
+		json.Unmarshal([]byte(message), &ccError)
+		if resp.StatusCode == http.StatusBadRequest && ccError.Code == ccInstancesErrorCode {
+			log.Debugf("App %v is stopped: %v", appGUID, ccError.Description)
+			return map[string]types.CfAppInstance{}, "", nil
+		}
+		log.Debugf("Getting instances of app %v finished with error: %v", appGUID, message)
+		return nil, message, nil
+	}
+
+	instances := map[string]types.CfAppInstance{}
+	if err := decodeResponse(resp, &instances); err != nil {
+		return nil, "", err
+	}
+	return instances, "", nil
+}
+
+// v3Available falls back to v2 API when capabilities are not known
+func (c *CfAPI) v3Available() bool {
+	capabilities, err := c.GetCapabilities()
+	if err != nil {
+		log.Warnf("Could not determine foundation capabilities, using v2 API: %v", err)
+		return false
+	}
+	return capabilities.V3
+}
+
+// getProcessInstances returns instances of the web process of the app, which has GUID of the app
+func (c *CfAPI) getProcessInstances(appGUID string) (map[string]types.CfAppInstance, string, error) {
+	address := fmt.Sprintf("%v/v3/processes/%v/stats", c.BaseAddress, appGUID)
+	stats := new(types.CfV3ProcessStatsResponse)
+	if err := c.getAndDecode(address, "process stats", stats, "resources"); err != nil {
+		return nil, "", err
+	}
+	instances := map[string]types.CfAppInstance{}
+	for _, instance := range stats.Resources {
+		instances[strconv.Itoa(instance.Index)] = types.CfAppInstance{
+			State: instance.State, Uptime: instance.Uptime, Details: instance.Details}
+	}
+	return instances, "", nil
+}
+
+// waitForAppRunning waits until instances required by options.Readiness are running.
+// Only instances of indexes below expected count are taken into account, negative
+// expected count means all reported instances. Flapping app fails the wait, so do
+// crashed instances once too few instances are left to reach readiness.
+func (c *CfAPI) waitForAppRunning(appGUID string, expected int, options WaitOptions) (*AppInstancesReport, error) {
+	return c.waitForInstances(appGUID, options, "running", c.getAppInstances, func(instances map[string]types.CfAppInstance) (bool, error) {
+		total := expected
+		if total < 0 {
+			if len(instances) == 0 {
+				return false, nil
+			}
+			total = len(instances)
+		}
+		required := options.Readiness.required(total)
+
+		running := 0
+		crashed := []string{}
+		for _, index := range sortedIndexes(instances) {
+			if i, err := strconv.Atoi(index); err == nil && i >= total {
+				continue
+			}
+			switch instances[index].State {
+			case types.InstanceRunning:
+				running++
+			case types.InstanceCrashed:
+				crashed = append(crashed, index)
+			case types.InstanceFlapping:
+				log.Errorf("Application %v flapping, instance %v", appGUID, index)
+				return false, errors.Annotate(types.CcGetInstancesFailedError, "Application flapping")
+			}
+		}
+		if running >= required {
+			return true, nil
+		}
+		if len(crashed) > 0 && total-len(crashed) < required {
+			return false, c.crashError(appGUID, crashed[0], instances[crashed[0]])
+		}
+		return false, nil
+	})
+}
+
+// crashError describes crashed instance, together with recent crash events of the app when available
+func (c *CfAPI) crashError(appGUID, index string, instance types.CfAppInstance) error {
+	msg := fmt.Sprintf("Instance %v of app %v crashed", index, appGUID)
+	if instance.Details != "" {
+		msg += ": " + instance.Details
+	}
+	events, err := c.recentCrashEvents(appGUID)
+	if err != nil {
+		log.Warnf("Could not get crash events of app %v: %v", appGUID, err)
+	}
+	for _, crash := range SummarizeCrashes(events) {
+		msg += "; " + crash.String()
+	}
+	log.Error(msg)
+	return errors.Annotate(types.AppCrashedError, msg)
+}
+
+func (c *CfAPI) recentCrashEvents(appGUID string) ([]types.CfEventResource, error) {
+	address := fmt.Sprintf("%v/v2/events?%v&order-direction=desc&results-per-page=%d", c.BaseAddress,
+		filtersQuery(map[string]string{"actee": appGUID, "type": types.EventAppCrash}), recentCrashEventsCount)
+	events := new(types.CfEventsResponse)
+	if err := c.getAndDecode(address, "app crash events", events, "resources"); err != nil {
+		return nil, err
+	}
+	return events.Resources, nil
+}
+
+// sortedIndexes returns instance indexes in numerical order
+func sortedIndexes(instances map[string]types.CfAppInstance) []string {
+	indexes := make(instanceIndexes, 0, len(instances))
+	for index := range instances {
+		indexes = append(indexes, index)
+	}
+	sort.Sort(indexes)
+	return indexes
+}
+
+type instanceIndexes []string
+
+func (s instanceIndexes) Len() int      { return len(s) }
+func (s instanceIndexes) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s instanceIndexes) Less(i, j int) bool {
+	left, leftErr := strconv.Atoi(s[i])
+	right, rightErr := strconv.Atoi(s[j])
+	if leftErr != nil || rightErr != nil {
+		return s[i] < s[j]
+	}
+	return left < right
+}
+
+func (c *CfAPI) waitForInstances(appGUID string, options WaitOptions, state string,
+	getInstances func(string) (map[string]types.CfAppInstance, string, error),
+	converged func(map[string]types.CfAppInstance) (bool, error)) (*AppInstancesReport, error) {
+
+	log.Infof("Waiting for app %v %v", appGUID, state)
+	report := &AppInstancesReport{AppGUID: appGUID}
+	err := pollUntil(options, func() string {
+		return fmt.Sprintf("%v %v", report, state)
+	}, func() (bool, error) {
+		instances, pending, err := getInstances(appGUID)
+		if err != nil || pending != "" {
+			return false, err
+		}
+		report.Instances = instances
+		log.Debugf("Waiting for %v", report.String())
+		return converged(instances)
+	})
+	return report, err
+}
+
+// pollUntil calls check until it reports done or fails. TimeoutOccurredError
+// annotated with describe() is returned when options.Timeout passes.
+func pollUntil(options WaitOptions, describe func() string, check func() (bool, error)) error {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultWaitTimeout
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+	deadline := time.Now().Add(options.Timeout)
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			msg := "Timeout while waiting for " + describe()
+			log.Error(msg)
+			return errors.Annotate(types.TimeoutOccurredError, msg)
+		}
+		if remaining > options.PollInterval {
+			remaining = options.PollInterval
+		}
+		time.Sleep(remaining)
+	}
+}
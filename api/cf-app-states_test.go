@@ -0,0 +1,368 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("Cf app states", func() {
+
+	var (
+		sut     CfAPI
+		options WaitOptions
+	)
+
+	stoppedResponder := responderGenerator(400, map[string]interface{}{
+		"code":        220001,
+		"description": "Instances error: Request failed for app: app as the app is in stopped state.",
+		"error_code":  "CF-InstancesError",
+	})
+	notStagedResponder := responderGenerator(400, map[string]interface{}{
+		"code":        170002,
+		"description": "App has not finished staging",
+		"error_code":  "CF-NotStaged",
+	})
+	instancesResponder := func(states ...string) httpmock.Responder {
+		instances := map[string]types.CfAppInstance{}
+		for i, state := range states {
+			instances[string('0'+rune(i))] = types.CfAppInstance{State: state}
+		}
+		return responderGenerator(200, instances)
+	}
+	appResponder := func(packageState string) httpmock.Responder {
+		app := types.CfAppResource{Meta: types.CfMeta{GUID: "guid"}}
+		app.Entity.PackageState = packageState
+		app.Entity.StagingFailedReason = "StagingError"
+		return responderGenerator(200, app)
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		options = WaitOptions{Timeout: 200 * time.Millisecond, PollInterval: time.Millisecond}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("get app instances", func() {
+		It("should return instances of running app", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", instancesResponder("RUNNING", "STARTING"))
+
+			instances, err := sut.GetAppInstances("guid")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instances).To(HaveLen(2))
+			Expect(instances["1"].State).To(Equal(types.InstanceStarting))
+		})
+
+		It("should return no instances of stopped app", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", stoppedResponder)
+
+			instances, err := sut.GetAppInstances("guid")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instances).To(BeEmpty())
+		})
+
+		It("should return error when app is not staged", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", notStagedResponder)
+
+			_, err := sut.GetAppInstances("guid")
+
+			Expect(errors.Cause(err)).To(Equal(types.CcGetInstancesFailedError))
+		})
+	})
+
+	Describe("stop app", func() {
+		It("should send only the state and wait until instances are gone", func() {
+			var body string
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", func(req *http.Request) (*http.Response, error) {
+				body = helpers.ReaderToString(req.Body)
+				return httpmock.NewJsonResponse(201, nil)
+			})
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", sequenceResponder(
+				instancesResponder("RUNNING", "RUNNING"),
+				instancesResponder("DOWN", "RUNNING"),
+				stoppedResponder))
+
+			report, err := sut.StopApp("guid", options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(MatchJSON(`{"state": "STOPPED"}`))
+			Expect(report.AppGUID).To(Equal("guid"))
+			Expect(report.Instances).To(BeEmpty())
+		})
+
+		It("should wait until v3 process stats report no running instance", func() {
+			root := types.CfRootLinks{Links: map[string]types.CfRootLink{}}
+			link := types.CfRootLink{Href: "https://api.example.com/v3"}
+			link.Meta.Version = "3.10.0"
+			root.Links["cloud_controller_v3"] = link
+			httpmock.RegisterResponder("GET", "/v2/info", responderGenerator(200, types.CfInfo{APIVersion: "2.65.0"}))
+			httpmock.RegisterResponder("GET", "/", responderGenerator(200, root))
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", responderGenerator(201, nil))
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", stoppedResponder)
+			processStats := func(states ...string) httpmock.Responder {
+				stats := types.CfV3ProcessStatsResponse{Resources: []types.CfV3ProcessStats{}}
+				for i, state := range states {
+					stats.Resources = append(stats.Resources, types.CfV3ProcessStats{Type: "web", Index: i, State: state})
+				}
+				return responderGenerator(200, stats)
+			}
+			polls := 0
+			stats := sequenceResponder(processStats("RUNNING", "DOWN"), processStats("DOWN", "DOWN"))
+			httpmock.RegisterResponder("GET", "/v3/processes/guid/stats", func(req *http.Request) (*http.Response, error) {
+				polls++
+				return stats(req)
+			})
+
+			report, err := sut.StopApp("guid", options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(polls).To(Equal(2))
+			Expect(report.String()).To(Equal("app guid instances [0:DOWN 1:DOWN]"))
+		})
+
+		It("should report instances when timeout occurs", func() {
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", responderGenerator(201, nil))
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", instancesResponder("RUNNING", "DOWN"))
+
+			report, err := sut.StopApp("guid", options)
+
+			Expect(errors.Cause(err)).To(Equal(types.TimeoutOccurredError))
+			Expect(errors.Details(err)).To(ContainSubstring("0:RUNNING 1:DOWN"))
+			Expect(report.Instances).To(HaveLen(2))
+		})
+
+		It("should return error when state cannot be changed", func() {
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", responderGenerator(400, nil))
+
+			_, err := sut.StopApp("guid", options)
+
+			Expect(errors.Cause(err)).To(Equal(types.CcUpdateFailedError))
+		})
+
+		It("should return error when instances cannot be fetched", func() {
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", responderGenerator(201, nil))
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", responderFailGenerator(nil))
+
+			_, err := sut.StopApp("guid", options)
+
+			Expect(errors.Message(err)).To(Equal(types.CcGetInstancesFailedError.Error()))
+		})
+	})
+
+	Describe("restart app", func() {
+		It("should stop the app and wait until started instances are running", func() {
+			states := []string{}
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", func(req *http.Request) (*http.Response, error) {
+				states = append(states, helpers.ReaderToString(req.Body))
+				return httpmock.NewJsonResponse(201, nil)
+			})
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", sequenceResponder(
+				stoppedResponder,
+				notStagedResponder,
+				instancesResponder("STARTING", "RUNNING"),
+				instancesResponder("RUNNING", "RUNNING")))
+
+			report, err := sut.RestartApp("guid", options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(states).To(HaveLen(2))
+			Expect(states[0]).To(MatchJSON(`{"state": "STOPPED"}`))
+			Expect(states[1]).To(MatchJSON(`{"state": "STARTED"}`))
+			Expect(report.Instances).To(HaveLen(2))
+		})
+
+		It("should fail when app is flapping", func() {
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", responderGenerator(201, nil))
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", sequenceResponder(
+				stoppedResponder,
+				instancesResponder("FLAPPING")))
+
+			_, err := sut.RestartApp("guid", options)
+
+			Expect(errors.Cause(err)).To(Equal(types.CcGetInstancesFailedError))
+		})
+	})
+
+	Describe("start app and wait", func() {
+		var app types.CfAppResource
+
+		crashEventsURL := "/v2/events?q=actee%3Aguid&q=type%3Aapp.crash&order-direction=desc&results-per-page=3"
+		crashEvents := types.CfEventsResponse{Count: 1, Resources: []types.CfEventResource{{
+			Meta: types.CfMeta{GUID: "event"},
+			Entity: types.CfEvent{Type: types.EventAppCrash, Actee: "guid",
+				Timestamp: time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC),
+				Metadata: map[string]interface{}{"index": 1, "exit_status": 137,
+					"exit_description": "out of memory"}}}}}
+
+		BeforeEach(func() {
+			app = types.CfAppResource{Meta: types.CfMeta{GUID: "guid"},
+				Entity: types.CfApp{State: types.AppStopped, InstanceCount: 3}}
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", responderGenerator(201, app))
+		})
+
+		It("should wait for all instances by default", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", sequenceResponder(
+				notStagedResponder,
+				instancesResponder("RUNNING", "RUNNING", "STARTING"),
+				instancesResponder("RUNNING", "RUNNING", "RUNNING")))
+
+			report, err := sut.StartAppAndWait(&app, options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Instances).To(HaveLen(3))
+			Expect(report.Instances["2"].State).To(Equal(types.InstanceRunning))
+		})
+
+		It("should be ready once requested number of instances is running", func() {
+			options.Readiness = AtLeastRunning(2)
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances",
+				instancesResponder("RUNNING", "CRASHED", "RUNNING"))
+
+			report, err := sut.StartAppAndWait(&app, options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Instances["1"].State).To(Equal(types.InstanceCrashed))
+		})
+
+		It("should keep waiting while crashed instances do not prevent readiness", func() {
+			options.Readiness = AtLeastRunning(2)
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", sequenceResponder(
+				instancesResponder("STARTING", "CRASHED", "STARTING"),
+				instancesResponder("RUNNING", "CRASHED", "RUNNING")))
+
+			_, err := sut.StartAppAndWait(&app, options)
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should ignore instances above expected count", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances",
+				instancesResponder("RUNNING", "RUNNING", "RUNNING", "CRASHED"))
+
+			_, err := sut.StartAppAndWait(&app, options)
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should fail fast on crashed instance with details and recent crashes", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", responderGenerator(200,
+				map[string]types.CfAppInstance{"0": {State: "RUNNING"}, "1": {State: "CRASHED", Details: "oom"},
+					"2": {State: "STARTING"}}))
+			httpmock.RegisterResponder("GET", crashEventsURL, responderGenerator(200, crashEvents))
+			options.Timeout = time.Hour
+
+			report, err := sut.StartAppAndWait(&app, options)
+
+			Expect(errors.Cause(err)).To(Equal(types.AppCrashedError))
+			details := errors.Details(err)
+			Expect(details).To(ContainSubstring("Instance 1 of app guid crashed: oom"))
+			Expect(details).To(ContainSubstring("2016-03-01T12:00:00Z crash of instance 1: exit status 137, out of memory"))
+			Expect(report.Instances).To(HaveLen(3))
+		})
+
+		It("should report crash even when events cannot be fetched", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances",
+				instancesResponder("RUNNING", "CRASHED", "RUNNING"))
+			httpmock.RegisterResponder("GET", crashEventsURL, responderGenerator(500, nil))
+
+			_, err := sut.StartAppAndWait(&app, options)
+
+			Expect(errors.Cause(err)).To(Equal(types.AppCrashedError))
+		})
+
+		It("should wait for every reported instance when instance count is unknown", func() {
+			app.Entity.InstanceCount = 0
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", sequenceResponder(
+				instancesResponder(),
+				instancesResponder("RUNNING", "STARTING"),
+				instancesResponder("RUNNING", "RUNNING")))
+
+			report, err := sut.StartAppAndWait(&app, options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Instances).To(HaveLen(2))
+		})
+
+		It("should order instances numerically in report", func() {
+			report := AppInstancesReport{AppGUID: "guid", Instances: map[string]types.CfAppInstance{
+				"10": {State: "RUNNING"}, "2": {State: "DOWN"}, "1": {State: "CRASHED"}}}
+
+			Expect(report.String()).To(Equal("app guid instances [1:CRASHED 2:DOWN 10:RUNNING]"))
+		})
+	})
+
+	Describe("restage and wait", func() {
+		BeforeEach(func() {
+			httpmock.RegisterResponder("POST", "/v2/apps/guid/restage", appResponder(""))
+		})
+
+		It("should wait until package is staged", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid", sequenceResponder(
+				appResponder(types.PackagePending),
+				appResponder(types.PackageStaged)))
+
+			app, err := sut.RestageAndWait("guid", options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(app.Entity.PackageState).To(Equal(types.PackageStaged))
+		})
+
+		It("should return staging error when package failed", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid", appResponder(types.PackageFailed))
+
+			app, err := sut.RestageAndWait("guid", options)
+
+			Expect(errors.Cause(err)).To(Equal(types.CcStagingFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("StagingError"))
+			Expect(app.Entity.PackageState).To(Equal(types.PackageFailed))
+		})
+
+		It("should time out when package stays pending", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid", appResponder(types.PackagePending))
+
+			_, err := sut.RestageAndWait("guid", options)
+
+			Expect(errors.Cause(err)).To(Equal(types.TimeoutOccurredError))
+		})
+	})
+})
+
+// sequenceResponder serves responses of given responders in order, repeating the last one
+func sequenceResponder(responders ...httpmock.Responder) httpmock.Responder {
+	calls := 0
+	return func(req *http.Request) (*http.Response, error) {
+		responder := responders[len(responders)-1]
+		if calls < len(responders) {
+			responder = responders[calls]
+		}
+		calls++
+		return responder(req)
+	}
+}
@@ -46,7 +46,9 @@ func (c *CfAPI) CreateApp(app types.CfApp) (*types.CfAppResource, error) {
 	}
 
 	toReturn := new(types.CfAppResource)
-	json.NewDecoder(resp.Body).Decode(toReturn)
+	if err := decodeResponse(resp, toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
 	log.Debugf("CreateApp status code: [%v]", resp.StatusCode)
 	log.Debugf("App created. GUID: [%v]", toReturn.Meta.GUID)
 	return toReturn, nil
@@ -67,7 +69,7 @@ func (c *CfAPI) GetAppSummary(id string) (*types.CfAppSummary, error) {
 	}
 
 	toReturn := new(types.CfAppSummary)
-	if err := json.NewDecoder(resp.Body).Decode(toReturn); err != nil {
+	if err := decodeResponse(resp, toReturn, "guid"); err != nil {
 		log.Errorf("Error decoding AppSummary response: [%v]", err.Error())
 		return nil, err
 	}
@@ -76,6 +78,63 @@ func (c *CfAPI) GetAppSummary(id string) (*types.CfAppSummary, error) {
 	return toReturn, nil
 }
 
+// GetApp returns app of given GUID. types.EntityNotFoundError is returned when it does not exist.
+func (c *CfAPI) GetApp(guid string) (*types.CfAppResource, error) {
+	address := fmt.Sprintf("%v/v2/apps/%v", c.BaseAddress, guid)
+	response, err := c.getEntity(address, "application")
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := new(types.CfAppResource)
+	if err := decodeResponse(response, toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
+	log.Debugf("App retrieved: [%v]", toReturn.Entity.Name)
+	return toReturn, nil
+}
+
+// ListApps returns all apps matching filters, e.g. {"name": "app", "space_guid": "guid"}.
+// All pages are requested, so Resources holds every matching app.
+func (c *CfAPI) ListApps(filters map[string]string) (*types.CfAppsResponse, error) {
+	address := fmt.Sprintf("%v/v2/apps?%v", c.BaseAddress, filtersQuery(filters))
+	return c.listApps(address)
+}
+
+// GetAppByName returns app of given name from space. types.EntityNotFoundError is returned when there is no such app.
+func (c *CfAPI) GetAppByName(spaceGUID, name string) (*types.CfAppResource, error) {
+	address := fmt.Sprintf("%v/v2/spaces/%v/apps?%v", c.BaseAddress, spaceGUID,
+		filtersQuery(map[string]string{"name": name}))
+	apps, err := c.listApps(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(apps.Resources) == 0 {
+		log.Infof("Application %v not found in space %v", name, spaceGUID)
+		return nil, types.EntityNotFoundError
+	}
+	return &apps.Resources[0], nil
+}
+
+func (c *CfAPI) listApps(address string) (*types.CfAppsResponse, error) {
+	toReturn := new(types.CfAppsResponse)
+	err := c.forEachPage(address, "apps", func(response *http.Response) (string, error) {
+		page := new(types.CfAppsResponse)
+		if err := decodeResponse(response, page, "resources"); err != nil {
+			return "", err
+		}
+		toReturn.Count = page.Count
+		toReturn.Pages = page.Pages
+		toReturn.Resources = append(toReturn.Resources, page.Resources...)
+		return page.NextURL, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Retrieved %v app(s)", len(toReturn.Resources))
+	return toReturn, nil
+}
+
 func (c *CfAPI) AssertAppHasRoutes(appSummary *types.CfAppSummary) error {
 	if len(appSummary.Routes) == 0 {
 		return errors.Annotate(types.InternalServerError, "Reference app has no route associated")
@@ -96,7 +155,9 @@ func (c *CfAPI) GetAppBindings(id string) (*types.CfBindingsResources, error) {
 	}
 
 	toReturn := new(types.CfBindingsResources)
-	json.NewDecoder(response.Body).Decode(toReturn)
+	if err := decodeResponse(response, toReturn, "resources"); err != nil {
+		return nil, err
+	}
 	log.Debugf("Get bindings status code: [%v]", response.StatusCode)
 	log.Debugf("Bindings retrieved. Got %d of %d results", len(toReturn.Resources), toReturn.TotalResults)
 	return toReturn, nil
@@ -132,13 +193,19 @@ func (c *CfAPI) CopyBits(sourceID string, destID string, asyncError chan error)
 	}
 
 	jobResponse := new(types.CfJobResponse)
-	json.NewDecoder(resp.Body).Decode(jobResponse)
+	if err := decodeResponse(resp, jobResponse, "metadata.url", "entity.status"); err != nil {
+		asyncError <- err
+		return
+	}
 	for jobResponse.Entity.Status != "finished" {
 		if resp, err = c.Get(c.BaseAddress + jobResponse.Meta.URL); err != nil {
 			asyncError <- errors.Wrap(types.CcJobFailedError, err)
 			return
 		}
-		json.NewDecoder(resp.Body).Decode(jobResponse)
+		if err := decodeResponse(resp, jobResponse, "entity.status"); err != nil {
+			asyncError <- err
+			return
+		}
 		log.Debugf("Copy_bits job check: [%v]", jobResponse.Entity.Status)
 		if jobResponse.Entity.Status == "failed" {
 			asyncError <- errors.Annotate(types.CcJobFailedError, jobResponse.Entity.Error)
@@ -170,16 +237,21 @@ func (c *CfAPI) RestageApp(appGUID string) error {
 	}
 
 	restagedApp := new(types.CfAppResource)
-	json.NewDecoder(resp.Body).Decode(restagedApp)
+	if err := decodeResponse(resp, restagedApp, "metadata.guid"); err != nil {
+		return err
+	}
 	log.Debugf("RestageApp status code: [%v]", resp.StatusCode)
 	log.Debugf("App status after restage: [%v]", restagedApp.Entity.State)
 	return nil
 }
 
+// UpdateApp sends app entity to CC. Fields set by CC, like package_state, are not sent.
 func (c *CfAPI) UpdateApp(app *types.CfAppResource) error {
 	address := fmt.Sprintf("%v/v2/apps/%v", c.BaseAddress, app.Meta.GUID)
 	log.Infof("Updating an app: %v", address)
-	raw, _ := json.Marshal(app.Entity)
+	entity := app.Entity
+	entity.ClearReadOnlyFields()
+	raw, _ := json.Marshal(entity)
 	request, _ := http.NewRequest("PUT", address, bytes.NewReader(raw))
 	resp, err := c.Do(request)
 	if err != nil {
@@ -193,67 +265,23 @@ func (c *CfAPI) UpdateApp(app *types.CfAppResource) error {
 	return nil
 }
 
+// StartApp starts the app and waits, with default WaitOptions, until all its instances are running
 func (c *CfAPI) StartApp(app *types.CfAppResource) error {
+	_, err := c.StartAppAndWait(app, WaitOptions{})
+	return err
+}
+
+// StartAppAndWait starts the app and waits until instances required by options.Readiness are running.
+// Crashed instances fail the wait with types.AppCrashedError describing recent crashes.
+func (c *CfAPI) StartAppAndWait(app *types.CfAppResource, options WaitOptions) (*AppInstancesReport, error) {
 	app.Entity.State = types.AppStarted
 	if err := c.UpdateApp(app); err != nil {
-		return err
-	}
-
-	var err error
-	asyncErr := make(chan error)
-	go c.waitForAppRunning(app.Meta.GUID, asyncErr)
-	select {
-	case err = <-asyncErr:
-		if err != nil {
-			return err
-		}
-	case <-time.After(5 * time.Minute):
-		return types.TimeoutOccurredError
+		return nil, err
 	}
-	return nil
-}
-
-func (c *CfAPI) waitForAppRunning(appGUID string, asyncErr chan error) {
-	address := fmt.Sprintf("%v/v2/apps/%v/instances", c.BaseAddress, appGUID)
-	log.Infof("Waiting for app running, checking instances: %v", address)
-
-	timeout := time.Second * 5
-	for {
-		resp, err := c.Get(address)
-		if err != nil {
-			log.Errorf("Could not get app instances: [%v]", err)
-			asyncErr <- errors.Wrap(types.CcGetInstancesFailedError, err)
-			return
-		} else if resp.StatusCode != http.StatusOK {
-			log.Debugf("waitForAppRunning finished with error: %v", helpers.ReaderToString(resp.Body))
-			time.Sleep(timeout)
-			continue
-		}
-
-		decodedInstances := map[string]types.CfAppInstance{}
-		if err := json.NewDecoder(resp.Body).Decode(&decodedInstances); err != nil {
-			asyncErr <- errors.Wrap(types.CcGetInstancesFailedError, err)
-			return
-		}
-
-		running := true
-		for key, value := range decodedInstances {
-			log.Infof("Instance %v, status: %v", key, value)
-			if value.State == "FLAPPING" {
-				log.Errorf("Application flapping. Stopping spawn.")
-				asyncErr <- errors.Annotate(types.CcGetInstancesFailedError, "Application flapping")
-				return
-			}
-			if value.State != "RUNNING" {
-				running = false
-				time.Sleep(timeout)
-				break
-			}
-		}
-		if running {
-			break
-		}
+	expected := app.Entity.InstanceCount
+	if expected <= 0 {
+		// instance count was not given, so every reported instance is waited for
+		expected = -1
 	}
-	asyncErr <- nil
-	return
+	return c.waitForAppRunning(app.Meta.GUID, expected, options)
 }
@@ -83,6 +83,27 @@ var _ = Describe("Cf apps", func() {
 				Expect(result).To(BeNil())
 			})
 		})
+		Context("when router responds with HTML page", func() {
+			It("should return malformed response error with body snippet", func() {
+				httpmock.RegisterResponder("POST", "/v2/apps", htmlResponderGenerator(201, "<html>502 Bad Gateway</html>"))
+
+				result, err := sut.CreateApp(types.CfApp{Name: "appName"})
+
+				Expect(result).To(BeNil())
+				Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+				Expect(err.(*types.ErrMalformedResponse).BodySnippet).To(ContainSubstring("502 Bad Gateway"))
+			})
+		})
+		Context("when response has no GUID", func() {
+			It("should return malformed response error", func() {
+				httpmock.RegisterResponder("POST", "/v2/apps", responderGenerator(201, map[string]string{}))
+
+				result, err := sut.CreateApp(types.CfApp{Name: "appName"})
+
+				Expect(result).To(BeNil())
+				Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+			})
+		})
 	})
 
 	Describe("get app summary method", func() {
@@ -134,6 +155,114 @@ var _ = Describe("Cf apps", func() {
 		})
 	})
 
+	Describe("get app method", func() {
+		Context("when app exists", func() {
+			It("should return app resource", func() {
+				app := types.CfAppResource{Meta: types.CfMeta{GUID: "guid"}, Entity: types.CfApp{Name: "app"}}
+				httpmock.RegisterResponder("GET", "/v2/apps/guid", responderGenerator(200, app))
+
+				result, err := sut.GetApp("guid")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Entity.Name).To(Equal("app"))
+			})
+		})
+		Context("when app does not exist", func() {
+			It("should return entity not found error", func() {
+				httpmock.RegisterResponder("GET", "/v2/apps/guid", responderGenerator(404, nil))
+
+				result, err := sut.GetApp("guid")
+
+				Expect(err).To(Equal(types.EntityNotFoundError))
+				Expect(result).To(BeNil())
+			})
+		})
+		Context("when http request fail", func() {
+			It("should return error", func() {
+				httpmock.RegisterResponder("GET", "/v2/apps/guid", requestFail)
+
+				result, err := sut.GetApp("guid")
+
+				Expect(err).To(HaveOccurred())
+				Expect(result).To(BeNil())
+			})
+		})
+	})
+
+	Describe("list apps method", func() {
+		firstPage := types.CfAppsResponse{
+			Count:     2,
+			Pages:     2,
+			NextURL:   "/v2/apps?page=2",
+			Resources: []types.CfAppResource{{Meta: types.CfMeta{GUID: "first"}}},
+		}
+		secondPage := types.CfAppsResponse{
+			Count:     2,
+			Pages:     2,
+			Resources: []types.CfAppResource{{Meta: types.CfMeta{GUID: "second"}}},
+		}
+
+		Context("when results span many pages", func() {
+			It("should return apps from all pages", func() {
+				httpmock.RegisterResponder("GET", "/v2/apps?q=name%3Aapp&q=space_guid%3Aspace",
+					responderGenerator(200, firstPage))
+				httpmock.RegisterResponder("GET", "/v2/apps?page=2", responderGenerator(200, secondPage))
+
+				result, err := sut.ListApps(map[string]string{"space_guid": "space", "name": "app"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Count).To(Equal(2))
+				Expect(result.Resources).To(HaveLen(2))
+				Expect(result.Resources[1].Meta.GUID).To(Equal("second"))
+			})
+		})
+		Context("when CF responds with different status code", func() {
+			It("should return error", func() {
+				httpmock.RegisterResponder("GET", "/v2/apps", negativeResponder)
+
+				result, err := sut.ListApps(nil)
+
+				Expect(err).To(HaveOccurred())
+				Expect(result).To(BeNil())
+			})
+		})
+	})
+
+	Describe("get app by name method", func() {
+		Context("when app exists", func() {
+			It("should return app resource", func() {
+				apps := types.CfAppsResponse{Count: 1, Resources: []types.CfAppResource{{Meta: types.CfMeta{GUID: "guid"}}}}
+				httpmock.RegisterResponder("GET", "/v2/spaces/space/apps?q=name%3Aapp", responderGenerator(200, apps))
+
+				result, err := sut.GetAppByName("space", "app")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Meta.GUID).To(Equal("guid"))
+			})
+		})
+		Context("when app does not exist", func() {
+			It("should return entity not found error", func() {
+				apps := types.CfAppsResponse{Resources: []types.CfAppResource{}}
+				httpmock.RegisterResponder("GET", "/v2/spaces/space/apps?q=name%3Aapp", responderGenerator(200, apps))
+
+				result, err := sut.GetAppByName("space", "app")
+
+				Expect(err).To(Equal(types.EntityNotFoundError))
+				Expect(result).To(BeNil())
+			})
+		})
+		Context("when space does not exist", func() {
+			It("should return entity not found error", func() {
+				httpmock.RegisterResponder("GET", "/v2/spaces/space/apps", responderGenerator(404, nil))
+
+				result, err := sut.GetAppByName("space", "app")
+
+				Expect(err).To(Equal(types.EntityNotFoundError))
+				Expect(result).To(BeNil())
+			})
+		})
+	})
+
 	Describe("assert app has routes method", func() {
 		Context("when app summary does not have routes", func() {
 			appSummaryWithoutRoutes := types.CfAppSummary{GUID: "guid", Routes: []types.CfAppSummaryRoute{}}
@@ -269,6 +398,28 @@ var _ = Describe("Cf apps", func() {
 				Expect(bindings).To(BeNil())
 			})
 		})
+		Context("when response body is not JSON", func() {
+			resp := responderGenerator(200, nil)
+
+			It("should return malformed response error", func() {
+				httpmock.RegisterResponder("GET", "/v2/apps/guid/service_bindings",
+					htmlResponderGenerator(200, "<html>Not Found</html>"))
+
+				bindings, err := sut.GetAppBindings("guid")
+
+				Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+				Expect(bindings).To(BeNil())
+			})
+
+			It("should reject null body", func() {
+				httpmock.RegisterResponder("GET", "/v2/apps/guid/service_bindings", resp)
+
+				bindings, err := sut.GetAppBindings("guid")
+
+				Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+				Expect(bindings).To(BeNil())
+			})
+		})
 	})
 
 	Describe("delete binding", func() {
@@ -465,7 +616,7 @@ var _ = Describe("Cf apps", func() {
 
 	Describe("restage app", func() {
 		Context("when successfully deleted", func() {
-			resp := responderGenerator(201, nil)
+			resp := responderGenerator(201, types.CfAppResource{Meta: types.CfMeta{GUID: "guid"}})
 
 			It("should not return error", func() {
 				httpmock.RegisterResponder("POST", "/v2/apps/guid/restage", resp)
@@ -591,6 +742,14 @@ func responderGenerator(code int, v interface{}) httpmock.Responder {
 	}
 }
 
+func htmlResponderGenerator(code int, body string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(code, body)
+		resp.Header.Set("Content-Type", "text/html")
+		return resp, nil
+	}
+}
+
 func responderFailGenerator(err error) httpmock.Responder {
 	return func(req *http.Request) (*http.Response, error) {
 		if err == nil {
@@ -0,0 +1,210 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+)
+
+// BitsSource provides application bits for UploadBits
+type BitsSource struct {
+	dir     string
+	zipPath string
+	reader  io.Reader
+}
+
+// BitsFromPath uses directory or zip file as application bits.
+// Directory is zipped honouring its .cfignore and file permissions.
+func BitsFromPath(path string) (BitsSource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Errorf("Could not access application bits: %v", err)
+		return BitsSource{}, errors.Wrap(types.InvalidBitsSourceError, err)
+	}
+	if info.IsDir() {
+		return BitsSource{dir: path}, nil
+	}
+	return BitsSource{zipPath: path}, nil
+}
+
+// BitsFromReader uses zip archive read from reader as application bits
+func BitsFromReader(reader io.Reader) BitsSource {
+	return BitsSource{reader: reader}
+}
+
+type UploadOptions struct {
+	// Wait configures waiting for the upload job
+	Wait WaitOptions
+	// MatchResources makes files already present in CC resource pool to be sent by
+	// reference instead of content. It applies to directory sources only.
+	MatchResources bool
+}
+
+// UploadReport describes finished upload. Files is unknown, thus zero, for bits read from io.Reader.
+// MatchedFiles were not uploaded as CC already had them, which saved BytesSaved of their size.
+type UploadReport struct {
+	Files         int
+	UploadedBytes int64
+	MatchedFiles  int
+	BytesSaved    int64
+}
+
+// UploadBits uploads application bits and waits until CC processes them
+func (c *CfAPI) UploadBits(appGUID string, source BitsSource, options UploadOptions) (*UploadReport, error) {
+	report := &UploadReport{}
+	resources := []types.CfResource{}
+	var bits io.Reader
+	size := int64(-1)
+
+	switch {
+	case source.dir != "":
+		files, err := listAppFiles(source.dir)
+		if err != nil {
+			log.Errorf("Could not list application files: %v", err)
+			return nil, errors.Wrap(types.InvalidBitsSourceError, err)
+		}
+		report.Files = len(files)
+		if options.MatchResources {
+			if files, resources, err = c.matchAppFiles(files); err != nil {
+				return nil, err
+			}
+			report.MatchedFiles = len(resources)
+			for _, resource := range resources {
+				report.BytesSaved += resource.Size
+			}
+		}
+		zipFile, err := ioutil.TempFile("", "app-bits-")
+		if err != nil {
+			return nil, errors.Wrap(types.InternalServerError, err)
+		}
+		defer os.Remove(zipFile.Name())
+		defer zipFile.Close()
+		if err := writeAppZip(zipFile, files); err != nil {
+			log.Errorf("Could not zip application files: %v", err)
+			return nil, errors.Wrap(types.InvalidBitsSourceError, err)
+		}
+		if size, err = zipFile.Seek(0, io.SeekCurrent); err != nil {
+			return nil, errors.Wrap(types.InternalServerError, err)
+		}
+		if _, err := zipFile.Seek(0, io.SeekStart); err != nil {
+			return nil, errors.Wrap(types.InternalServerError, err)
+		}
+		bits = zipFile
+	case source.zipPath != "":
+		archive, err := zip.OpenReader(source.zipPath)
+		if err != nil {
+			log.Errorf("Could not read application zip: %v", err)
+			return nil, errors.Wrap(types.InvalidBitsSourceError, err)
+		}
+		report.Files = len(archive.File)
+		archive.Close()
+		zipFile, err := os.Open(source.zipPath)
+		if err != nil {
+			return nil, errors.Wrap(types.InvalidBitsSourceError, err)
+		}
+		defer zipFile.Close()
+		info, err := zipFile.Stat()
+		if err != nil {
+			return nil, errors.Wrap(types.InvalidBitsSourceError, err)
+		}
+		size = info.Size()
+		bits = zipFile
+	case source.reader != nil:
+		bits = source.reader
+	default:
+		return nil, errors.Annotate(types.InvalidBitsSourceError, "No application bits given")
+	}
+
+	counter := &countingReader{reader: bits}
+	job, err := c.putBits(appGUID, resources, counter, size)
+	if err != nil {
+		return nil, err
+	}
+	report.UploadedBytes = counter.count
+	if err := c.WaitForJob(job, options.Wait); err != nil {
+		return nil, err
+	}
+	log.Infof("Bits of app %v uploaded: %d files, %d bytes, %d files matched saving %d bytes", appGUID,
+		report.Files, report.UploadedBytes, report.MatchedFiles, report.BytesSaved)
+	return report, nil
+}
+
+// putBits sends multipart bits request with resources already known to CC and zip
+// of the remaining files. Size of negative value means unknown size, sent chunked.
+func (c *CfAPI) putBits(appGUID string, resources []types.CfResource, bits io.Reader, size int64) (*types.CfJobResponse, error) {
+	address := fmt.Sprintf("%v/v2/apps/%v/bits?async=true", c.BaseAddress, appGUID)
+	log.Infof("Uploading bits: %v", address)
+
+	rawResources, _ := json.Marshal(resources)
+	buffer := new(bytes.Buffer)
+	form := multipart.NewWriter(buffer)
+	form.WriteField("resources", string(rawResources))
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="application"; filename="application.zip"`)
+	header.Set("Content-Type", "application/zip")
+	form.CreatePart(header)
+	prefix := append([]byte{}, buffer.Bytes()...)
+	buffer.Reset()
+	form.Close()
+	suffix := buffer.Bytes()
+
+	request, _ := http.NewRequest("PUT", address, io.MultiReader(bytes.NewReader(prefix), bits, bytes.NewReader(suffix)))
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	if size >= 0 {
+		request.ContentLength = int64(len(prefix)) + size + int64(len(suffix))
+	}
+	resp, err := c.Do(request)
+	if err != nil {
+		log.Errorf("Could not upload bits: [%v]", err)
+		return nil, errors.Wrap(types.CcUploadBitsFailedError, err)
+	} else if !IsSuccessStatus(resp.StatusCode) {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("UploadBits finished with error: %v", message)
+		return nil, CreateCcError(message, types.CcUploadBitsFailedError)
+	}
+
+	job := new(types.CfJobResponse)
+	if err := decodeResponse(resp, job, "metadata.guid", "entity.status"); err != nil {
+		return nil, err
+	}
+	log.Debugf("Upload job %v status: %v", job.Meta.GUID, job.Entity.Status)
+	return job, nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
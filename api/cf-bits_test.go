@@ -0,0 +1,215 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type uploadedBits struct {
+	resources     []types.CfResource
+	files         map[string]string
+	contentLength int64
+}
+
+var _ = Describe("Cf bits", func() {
+
+	var (
+		sut      CfAPI
+		dir      string
+		uploaded *uploadedBits
+		options  UploadOptions
+	)
+
+	finishedJob := types.CfJobResponse{Meta: types.CfMeta{GUID: "job", URL: "/v2/jobs/job"},
+		Entity: types.CfJob{GUID: "job", Status: types.JobFinished}}
+	queuedJob := finishedJob
+	queuedJob.Entity.Status = types.JobQueued
+
+	bitsResponder := func(job types.CfJobResponse) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			uploaded = &uploadedBits{files: map[string]string{}, contentLength: req.ContentLength}
+			if err := req.ParseMultipartForm(1 << 20); err != nil {
+				return httpmock.NewStringResponse(400, err.Error()), nil
+			}
+			json.Unmarshal([]byte(req.FormValue("resources")), &uploaded.resources)
+			if file, header, err := req.FormFile("application"); err == nil {
+				archive, _ := zip.NewReader(file, header.Size)
+				for _, entry := range archive.File {
+					reader, _ := entry.Open()
+					content, _ := ioutil.ReadAll(reader)
+					uploaded.files[entry.Name] = string(content)
+				}
+			}
+			return httpmock.NewJsonResponse(201, job)
+		}
+	}
+
+	zipBytes := func(files map[string]string) []byte {
+		buffer := new(bytes.Buffer)
+		archive := zip.NewWriter(buffer)
+		for name, content := range files {
+			writer, _ := archive.Create(name)
+			writer.Write([]byte(content))
+		}
+		archive.Close()
+		return buffer.Bytes()
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		uploaded = nil
+		options = UploadOptions{Wait: WaitOptions{Timeout: 200 * time.Millisecond, PollInterval: time.Millisecond}}
+		var err error
+		dir, err = ioutil.TempDir("", "bits-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "index.js"), []byte("main"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "debug.log"), []byte("log"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, ".cfignore"), []byte("*.log"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+		os.RemoveAll(dir)
+	})
+
+	It("should upload zipped directory and wait for job", func() {
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid/bits?async=true", bitsResponder(queuedJob))
+		httpmock.RegisterResponder("GET", "/v2/jobs/job", sequenceResponder(
+			responderGenerator(200, queuedJob), responderGenerator(200, finishedJob)))
+		source, err := BitsFromPath(dir)
+		Expect(err).NotTo(HaveOccurred())
+
+		report, err := sut.UploadBits("guid", source, options)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploaded.resources).To(BeEmpty())
+		Expect(uploaded.files).To(Equal(map[string]string{"index.js": "main"}))
+		Expect(uploaded.contentLength).To(BeNumerically(">", report.UploadedBytes))
+		Expect(report.Files).To(Equal(1))
+	})
+
+	It("should upload only files not matched by CC", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "lib.jar"), []byte("library"), 0600)).To(Succeed())
+		httpmock.RegisterResponder("PUT", "/v2/resource_match", func(req *http.Request) (*http.Response, error) {
+			resources := []types.CfResource{}
+			json.NewDecoder(req.Body).Decode(&resources)
+			matched := []types.CfResource{}
+			for _, resource := range resources {
+				if resource.Size == int64(len("library")) {
+					matched = append(matched, resource)
+				}
+			}
+			return httpmock.NewJsonResponse(200, matched)
+		})
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid/bits?async=true", bitsResponder(finishedJob))
+		source, _ := BitsFromPath(dir)
+		options.MatchResources = true
+
+		report, err := sut.UploadBits("guid", source, options)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploaded.files).To(Equal(map[string]string{"index.js": "main"}))
+		Expect(uploaded.resources).To(Equal([]types.CfResource{{
+			SHA1: "00299a408dc3498a3cd7bae6db588f3324654d76", Size: 7, Path: "lib.jar", Mode: "600"}}))
+		Expect(report.Files).To(Equal(2))
+		Expect(report.MatchedFiles).To(Equal(1))
+		Expect(report.BytesSaved).To(Equal(int64(7)))
+	})
+
+	It("should upload zip file as is", func() {
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid/bits?async=true", bitsResponder(finishedJob))
+		zipPath := filepath.Join(dir, "app.zip")
+		content := zipBytes(map[string]string{"a.txt": "a", "b.txt": "b"})
+		Expect(ioutil.WriteFile(zipPath, content, 0644)).To(Succeed())
+		source, err := BitsFromPath(zipPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		report, err := sut.UploadBits("guid", source, options)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploaded.files).To(Equal(map[string]string{"a.txt": "a", "b.txt": "b"}))
+		Expect(report).To(Equal(&UploadReport{Files: 2, UploadedBytes: int64(len(content))}))
+	})
+
+	It("should stream zip from reader", func() {
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid/bits?async=true", bitsResponder(finishedJob))
+
+		report, err := sut.UploadBits("guid", BitsFromReader(bytes.NewReader(zipBytes(map[string]string{"c": "c"}))), options)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploaded.files).To(HaveKeyWithValue("c", "c"))
+		Expect(report.UploadedBytes).To(BeNumerically(">", 0))
+	})
+
+	It("should return job error when processing fails", func() {
+		failedJob := finishedJob
+		failedJob.Entity.Status = types.JobFailed
+		failedJob.Entity.Error = "Use error_details instead"
+		failedJob.Entity.ErrorDetails = &types.CfErrorDetails{Code: 160001, ErrorCode: "CF-AppBitsUploadInvalid",
+			Description: "The app upload is invalid: zip is corrupted"}
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid/bits?async=true", bitsResponder(queuedJob))
+		httpmock.RegisterResponder("GET", "/v2/jobs/job", responderGenerator(200, failedJob))
+
+		_, err := sut.UploadBits("guid", BitsFromReader(bytes.NewReader(zipBytes(nil))), options)
+
+		Expect(errors.Cause(err)).To(Equal(types.CcJobFailedError))
+		Expect(errors.Details(err)).To(ContainSubstring("zip is corrupted"))
+	})
+
+	It("should return CC error when upload is rejected", func() {
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid/bits?async=true", responderGenerator(400, map[string]interface{}{
+			"code": 160001, "description": "The app upload is invalid", "error_code": "CF-AppBitsUploadInvalid"}))
+
+		_, err := sut.UploadBits("guid", BitsFromReader(bytes.NewReader(zipBytes(nil))), options)
+
+		Expect(errors.Cause(err)).To(Equal(types.CcUploadBitsFailedError))
+	})
+
+	It("should refuse missing path", func() {
+		_, err := BitsFromPath(filepath.Join(dir, "missing"))
+
+		Expect(errors.Message(err)).To(Equal(types.InvalidBitsSourceError.Error()))
+	})
+
+	It("should refuse file which is not a zip", func() {
+		source, _ := BitsFromPath(filepath.Join(dir, "index.js"))
+
+		_, err := sut.UploadBits("guid", source, options)
+
+		Expect(errors.Message(err)).To(Equal(types.InvalidBitsSourceError.Error()))
+	})
+
+	It("should refuse empty source", func() {
+		_, err := sut.UploadBits("guid", BitsSource{}, options)
+
+		Expect(errors.Cause(err)).To(Equal(types.InvalidBitsSourceError))
+	})
+})
@@ -0,0 +1,194 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"time"
+)
+
+var _ = Describe("Cf blue-green with fake CC", func() {
+
+	var (
+		fake       *cctest.FakeCC
+		sut        *api.CfAPI
+		sourceGUID string
+		options    api.WaitOptions
+	)
+
+	BeforeEach(func() {
+		fake, sut = newFakeCCAPI()
+		domainGUID := fake.AddDomain("example.com")
+		sourceGUID = fake.AddApp(types.CfApp{Name: "source", SpaceGUID: "space", State: types.AppStarted,
+			Envs: map[string]interface{}{"FOO": "bar"}})
+		routeGUID := fake.AddRoute(types.CfCreateRouteRequest{Host: "source", DomainGUID: domainGUID, SpaceGUID: "space"})
+		Expect(sut.AssociateRoute(sourceGUID, routeGUID)).To(Succeed())
+		options = api.WaitOptions{PollInterval: time.Millisecond}
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("should move routes to new version and delete the old one", func() {
+		report, err := sut.BlueGreenDeploy(api.BlueGreenRequest{
+			AppGUID:   sourceGUID,
+			Env:       map[string]interface{}{"VERSION": "2"},
+			Probe:     func(url string) error { return nil },
+			DeleteOld: true,
+			Wait:      options,
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.RolledBack).To(BeFalse())
+		for _, step := range report.Steps {
+			Expect(step.Status).To(Equal(api.StepSucceeded), step.Name)
+		}
+		Expect(report.Steps[len(report.Steps)-1].Name).To(Equal("delete old app"))
+		_, exists := fake.App(sourceGUID)
+		Expect(exists).To(BeFalse())
+		app, err := sut.GetAppByName("space", "source")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(app.Meta.GUID).To(Equal(report.NewAppGUID))
+		Expect(app.Entity.State).To(Equal(types.AppStarted))
+		Expect(app.Entity.Envs).To(Equal(map[string]interface{}{"FOO": "bar", "VERSION": "2"}))
+		Expect(routeHosts(fake, report.NewAppGUID)).To(ConsistOf("source"))
+		temporary, _ := sut.GetSpaceRoutesForHostname("space", "source-temp")
+		Expect(temporary.Resources).To(BeEmpty())
+	})
+
+	It("should keep the old version stopped under venerable name", func() {
+		report, err := sut.BlueGreenDeploy(api.BlueGreenRequest{AppGUID: sourceGUID, Wait: options})
+
+		Expect(err).NotTo(HaveOccurred())
+		old, _ := fake.App(sourceGUID)
+		Expect(old.Name).To(Equal("source" + api.VenerableSuffix))
+		Expect(old.State).To(Equal(types.AppStopped))
+		Expect(routeHosts(fake, sourceGUID)).To(BeEmpty())
+		Expect(routeHosts(fake, report.NewAppGUID)).To(ConsistOf("source"))
+	})
+
+	It("should roll back when health probe fails", func() {
+		probed := ""
+		report, err := sut.BlueGreenDeploy(api.BlueGreenRequest{
+			AppGUID: sourceGUID,
+			Probe: func(url string) error {
+				probed = url
+				return errors.New("unhealthy")
+			},
+			Wait: options,
+		})
+
+		Expect(errors.Cause(err)).To(Equal(types.HealthProbeFailedError))
+		Expect(probed).To(Equal("source-temp.example.com"))
+		Expect(report.RolledBack).To(BeTrue())
+		rollbacks := []string{}
+		for _, step := range report.Steps {
+			if step.Rollback {
+				Expect(step.Status).To(Equal(api.StepSucceeded), step.Name)
+				rollbacks = append(rollbacks, step.Name)
+			}
+		}
+		Expect(rollbacks).To(Equal([]string{"map temporary route source-temp.example.com",
+			"create temporary route source-temp.example.com", "create new app", "rename old app"}))
+		_, exists := fake.App(report.NewAppGUID)
+		Expect(exists).To(BeFalse())
+		old, _ := fake.App(sourceGUID)
+		Expect(old.Name).To(Equal("source"))
+		Expect(old.State).To(Equal(types.AppStarted))
+		Expect(routeHosts(fake, sourceGUID)).To(ConsistOf("source"))
+		temporary, _ := sut.GetSpaceRoutesForHostname("space", "source-temp")
+		Expect(temporary.Resources).To(BeEmpty())
+	})
+
+	Describe("with bound service", func() {
+		var instanceGUID string
+
+		BeforeEach(func() {
+			_, plans := fake.AddService("postgresql", "free")
+			instanceGUID = fake.AddServiceInstance(types.CfServiceInstanceCreateRequest{
+				Name: "db", SpaceGUID: "space", PlanGUID: plans[0]})
+			fake.AddBinding(sourceGUID, instanceGUID)
+		})
+
+		boundApps := func() []string {
+			apps := []string{}
+			for _, binding := range fake.Bindings() {
+				Expect(binding.ServiceInstanceGUID).To(Equal(instanceGUID))
+				apps = append(apps, binding.AppGUID)
+			}
+			return apps
+		}
+
+		It("should bind the service to new version before starting it", func() {
+			report, err := sut.BlueGreenDeploy(api.BlueGreenRequest{AppGUID: sourceGUID, DeleteOld: true, Wait: options})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(boundApps()).To(ConsistOf(report.NewAppGUID))
+			env, err := sut.GetAppEnv(report.NewAppGUID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.SystemEnv.VcapServices).To(HaveKey("postgresql"))
+		})
+
+		It("should unbind the service from new version on rollback", func() {
+			report, err := sut.BlueGreenDeploy(api.BlueGreenRequest{AppGUID: sourceGUID, Wait: options,
+				Probe: func(url string) error { return errors.New("unhealthy") }})
+
+			Expect(err).To(HaveOccurred())
+			Expect(report.RolledBack).To(BeTrue())
+			rollbacks := []string{}
+			for _, step := range report.Steps {
+				if step.Rollback {
+					Expect(step.Status).To(Equal(api.StepSucceeded), step.Name)
+					rollbacks = append(rollbacks, step.Name)
+				}
+			}
+			Expect(rollbacks).To(Equal([]string{"bind services", "map temporary route source-temp.example.com",
+				"create temporary route source-temp.example.com", "create new app", "rename old app"}))
+			Expect(boundApps()).To(ConsistOf(sourceGUID))
+		})
+	})
+
+	It("should delete temporary route when it cannot be mapped", func() {
+		fake.InjectFailure("PUT", "/v2/apps/:guid/routes/:route", cctest.Failure{StatusCode: 500, Body: "{}", Times: 1})
+
+		report, err := sut.BlueGreenDeploy(api.BlueGreenRequest{AppGUID: sourceGUID, Wait: options})
+
+		Expect(err).To(HaveOccurred())
+		Expect(report.RolledBack).To(BeTrue())
+		temporary, _ := sut.GetSpaceRoutesForHostname("space", "source-temp")
+		Expect(temporary.Resources).To(BeEmpty())
+		Expect(routeHosts(fake, sourceGUID)).To(ConsistOf("source"))
+	})
+
+	It("should restore routes of the old version when remapping fails", func() {
+		fake.InjectFailure("DELETE", "/v2/apps/:guid/routes/:route", cctest.Failure{StatusCode: 500, Body: "{}", Times: 1})
+
+		report, err := sut.BlueGreenDeploy(api.BlueGreenRequest{AppGUID: sourceGUID, Wait: options})
+
+		Expect(err).To(HaveOccurred())
+		Expect(report.RolledBack).To(BeTrue())
+		Expect(routeHosts(fake, sourceGUID)).To(ConsistOf("source"))
+		_, exists := fake.App(report.NewAppGUID)
+		Expect(exists).To(BeFalse())
+	})
+})
@@ -0,0 +1,357 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+
+	// VenerableSuffix is appended to name of the old version while the new one is deployed
+	VenerableSuffix = "-venerable"
+	// TemporaryHostSuffix is appended to app name to build host of the temporary route
+	TemporaryHostSuffix = "-temp"
+)
+
+// HealthProbe checks the new version of the app under given URL before production routes are remapped
+type HealthProbe func(url string) error
+
+// BlueGreenRequest describes deployment of new version of the app. The new version takes
+// name, configuration, service bindings and routes of the old one, which is renamed with VenerableSuffix.
+type BlueGreenRequest struct {
+	AppGUID string
+	// Bits of the new version. When nil, bits of the old version are copied, e.g. to apply Env.
+	// Docker apps run image of the old version and need no bits.
+	Bits *BitsSource
+	// Env is merged into environment of the old version
+	Env map[string]interface{}
+	// DockerCredentials of private registry, as CC does not return those of the old version
+	DockerCredentials *types.CfDockerCredentials
+	// TemporaryHost of route used before remapping, app name with TemporaryHostSuffix by default
+	TemporaryHost string
+	Probe         HealthProbe
+	// DeleteOld deletes the old version instead of only stopping it
+	DeleteOld bool
+	Wait      WaitOptions
+}
+
+// DeployStep describes single step of the deployment or of its rollback
+type DeployStep struct {
+	Name     string
+	Rollback bool
+	Status   string
+	Error    string
+	Duration time.Duration
+}
+
+// DeployReport lists steps of the deployment in order of execution
+type DeployReport struct {
+	OldAppGUID string
+	NewAppGUID string
+	Steps      []DeployStep
+	RolledBack bool
+}
+
+func (r *DeployReport) String() string {
+	var buffer bytes.Buffer
+	for i, step := range r.Steps {
+		name := step.Name
+		if step.Rollback {
+			name = "rollback: " + name
+		}
+		fmt.Fprintf(&buffer, "%d. %v: %v (%v)", i+1, name, step.Status, step.Duration)
+		if step.Error != "" {
+			fmt.Fprintf(&buffer, ": %v", step.Error)
+		}
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
+
+type deployStep struct {
+	name     string
+	run      func() error
+	rollback func() error
+}
+
+// BlueGreenDeploy deploys new version of the app without downtime. The new version is started
+// under a temporary route and probed, then production routes are moved to it and the old version
+// is stopped or deleted. When any step fails, completed steps are rolled back in reverse order
+// and the error of the failed step is returned together with the report.
+func (c *CfAPI) BlueGreenDeploy(request BlueGreenRequest) (*DeployReport, error) {
+	report := &DeployReport{OldAppGUID: request.AppGUID}
+	old, err := c.GetAppSummary(request.AppGUID)
+	if err != nil {
+		return report, err
+	}
+	if err := c.AssertAppHasRoutes(old); err != nil {
+		return report, err
+	}
+	temporaryHost := request.TemporaryHost
+	if temporaryHost == "" {
+		temporaryHost = old.Name + TemporaryHostSuffix
+	}
+	domain := old.Routes[0].Domain
+	temporaryURL := fmt.Sprintf("%v.%v", temporaryHost, domain.Name)
+
+	var newApp *types.CfAppResource
+	var temporaryRouteGUID string
+	steps := []deployStep{
+		{
+			name: "rename old app",
+			run: func() error {
+				return c.updateAppFields(old.GUID, map[string]interface{}{"name": old.Name + VenerableSuffix})
+			},
+			rollback: func() error {
+				return c.updateAppFields(old.GUID, map[string]interface{}{"name": old.Name})
+			},
+		},
+		{
+			name: "create new app",
+			run: func() error {
+				entity := types.NewCfAppResource(*old, old.Name, old.SpaceGUID).Entity
+				entity.DockerCredentials = request.DockerCredentials
+				if len(request.Env) > 0 {
+					entity.Envs = mergeEnv(entity.Envs, request.Env, nil)
+				}
+				created, err := c.CreateApp(entity)
+				if err != nil {
+					return err
+				}
+				newApp = created
+				report.NewAppGUID = created.Meta.GUID
+				return nil
+			},
+			rollback: func() error {
+				return c.DeleteApp(newApp.Meta.GUID)
+			},
+		},
+		{
+			name: "upload bits",
+			run: func() error {
+				if request.Bits != nil {
+					_, err := c.UploadBits(newApp.Meta.GUID, *request.Bits, UploadOptions{Wait: request.Wait, MatchResources: true})
+					return err
+				}
+				if newApp.Entity.IsDocker() {
+					return nil
+				}
+				asyncError := make(chan error, 1)
+				c.CopyBits(old.GUID, newApp.Meta.GUID, asyncError)
+				return <-asyncError
+			},
+		},
+		{
+			name: "create temporary route " + temporaryURL,
+			run: func() error {
+				route, err := c.CreateRoute(&types.CfCreateRouteRequest{
+					Host: temporaryHost, DomainGUID: domain.GUID, SpaceGUID: old.SpaceGUID})
+				if err != nil {
+					return err
+				}
+				temporaryRouteGUID = route.Meta.GUID
+				return nil
+			},
+			rollback: func() error {
+				return c.DeleteRoute(temporaryRouteGUID)
+			},
+		},
+		{
+			name: "map temporary route " + temporaryURL,
+			run: func() error {
+				return c.AssociateRoute(newApp.Meta.GUID, temporaryRouteGUID)
+			},
+			rollback: func() error {
+				return c.UnassociateRoute(newApp.Meta.GUID, temporaryRouteGUID)
+			},
+		},
+	}
+	if len(old.Services) > 0 {
+		steps = append(steps, deployStep{
+			name: "bind services",
+			run: func() error {
+				return c.bindServices(newApp.Meta.GUID, old.Services)
+			},
+			rollback: func() error {
+				errorsCh := make(chan error, 1)
+				wg := &sync.WaitGroup{}
+				wg.Add(1)
+				c.UnbindAppServices(newApp.Meta.GUID, errorsCh, wg)
+				return <-errorsCh
+			},
+		})
+	}
+	steps = append(steps, deployStep{
+		name: "start new app",
+		run: func() error {
+			_, err := c.StartAppAndWait(newApp, request.Wait)
+			return err
+		},
+	})
+	if request.Probe != nil {
+		steps = append(steps, deployStep{
+			name: "probe " + temporaryURL,
+			run: func() error {
+				if err := request.Probe(temporaryURL); err != nil {
+					return errors.Annotate(types.HealthProbeFailedError, err.Error())
+				}
+				return nil
+			},
+		})
+	}
+	for _, route := range old.Routes {
+		route := route
+		steps = append(steps, deployStep{
+			name: "map route " + summaryRouteURL(route) + " to new app",
+			run: func() error {
+				return c.AssociateRoute(newApp.Meta.GUID, route.GUID)
+			},
+			rollback: func() error {
+				return c.UnassociateRoute(newApp.Meta.GUID, route.GUID)
+			},
+		})
+	}
+	for _, route := range old.Routes {
+		route := route
+		steps = append(steps, deployStep{
+			name: "unmap route " + summaryRouteURL(route) + " from old app",
+			run: func() error {
+				return c.UnassociateRoute(old.GUID, route.GUID)
+			},
+			rollback: func() error {
+				return c.AssociateRoute(old.GUID, route.GUID)
+			},
+		})
+	}
+	steps = append(steps, deployStep{
+		name: "delete temporary route " + temporaryURL,
+		run: func() error {
+			return c.DeleteRoute(temporaryRouteGUID)
+		},
+	}, deployStep{
+		name: "stop old app",
+		run: func() error {
+			_, err := c.StopApp(old.GUID, request.Wait)
+			return err
+		},
+		rollback: func() error {
+			if err := c.setAppState(old.GUID, types.AppStarted); err != nil {
+				return err
+			}
+			expected := old.InstanceCount
+			if expected <= 0 {
+				expected = -1
+			}
+			_, err := c.waitForAppRunning(old.GUID, expected, request.Wait)
+			return err
+		},
+	})
+	if request.DeleteOld {
+		steps = append(steps, deployStep{
+			name: "delete old app",
+			run: func() error {
+				return c.DeleteApp(old.GUID)
+			},
+		})
+	}
+	return report, c.runDeploySteps(report, steps)
+}
+
+// HTTPHealthProbe returns probe polling the app over HTTP until path responds with success status.
+// The probe does not use CfAPI client, so CC token is never sent to the app.
+func HTTPHealthProbe(scheme, path string, options WaitOptions) HealthProbe {
+	return func(url string) error {
+		address := fmt.Sprintf("%v://%v%v", scheme, url, path)
+		client := &http.Client{Timeout: 10 * time.Second}
+		lastStatus := "no response"
+		return pollUntil(options, func() string {
+			return fmt.Sprintf("health probe %v, last result: %v", address, lastStatus)
+		}, func() (bool, error) {
+			resp, err := client.Get(address)
+			if err != nil {
+				lastStatus = err.Error()
+				return false, nil
+			}
+			lastStatus = fmt.Sprintf("(%d) %v", resp.StatusCode, helpers.ReaderToString(resp.Body))
+			return IsSuccessStatus(resp.StatusCode), nil
+		})
+	}
+}
+
+func (c *CfAPI) runDeploySteps(report *DeployReport, steps []deployStep) error {
+	for i, step := range steps {
+		log.Infof("Deployment of app %v: %v", report.OldAppGUID, step.name)
+		err := runDeployStep(report, step.name, false, step.run)
+		if err == nil {
+			continue
+		}
+		log.Errorf("Deployment of app %v failed at step %v: %v, rolling back", report.OldAppGUID, step.name, err)
+		for j := i - 1; j >= 0; j-- {
+			if steps[j].rollback != nil {
+				runDeployStep(report, steps[j].name, true, steps[j].rollback)
+			}
+		}
+		report.RolledBack = true
+		return errors.Annotate(err, fmt.Sprintf("Deployment failed at step %v and was rolled back", step.name))
+	}
+	return nil
+}
+
+func runDeployStep(report *DeployReport, name string, rollback bool, run func() error) error {
+	started := time.Now()
+	err := run()
+	step := DeployStep{Name: name, Rollback: rollback, Status: StepSucceeded, Duration: time.Since(started)}
+	if err != nil {
+		step.Status = StepFailed
+		step.Error = err.Error()
+		if rollback {
+			log.Errorf("Rollback of step %v failed: %v", name, err)
+		}
+	}
+	report.Steps = append(report.Steps, step)
+	return err
+}
+
+// bindServices binds the app to service instances bound to the old version
+func (c *CfAPI) bindServices(appGUID string, services []types.CfAppSummaryService) error {
+	errorsCh := make(chan error, len(services))
+	wg := &sync.WaitGroup{}
+	wg.Add(len(services))
+	for _, service := range services {
+		go c.BindService(appGUID, service.GUID, errorsCh, wg)
+	}
+	wg.Wait()
+	return helpers.FirstNonEmpty(errorsCh, len(services))
+}
+
+func summaryRouteURL(route types.CfAppSummaryRoute) string {
+	if route.Host == "" {
+		return route.Domain.Name
+	}
+	return route.Host + "." + route.Domain.Name
+}
@@ -0,0 +1,73 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"time"
+)
+
+var _ = Describe("Cf blue-green", func() {
+
+	BeforeEach(func() {
+		httpmock.Activate()
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("http health probe", func() {
+		options := WaitOptions{Timeout: 50 * time.Millisecond, PollInterval: time.Millisecond}
+
+		It("should succeed once app responds with success", func() {
+			httpmock.RegisterResponder("GET", "https://app-temp.example.com/health", sequenceResponder(
+				httpmock.NewStringResponder(502, "no app"),
+				httpmock.NewStringResponder(200, "ok")))
+
+			err := HTTPHealthProbe("https", "/health", options)("app-temp.example.com")
+
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should time out with last response", func() {
+			httpmock.RegisterResponder("GET", "https://app-temp.example.com/health",
+				httpmock.NewStringResponder(503, "starting"))
+
+			err := HTTPHealthProbe("https", "/health", options)("app-temp.example.com")
+
+			Expect(errors.Cause(err)).To(Equal(types.TimeoutOccurredError))
+			Expect(errors.Details(err)).To(ContainSubstring("(503) starting"))
+		})
+	})
+
+	It("should describe deployment steps", func() {
+		report := DeployReport{Steps: []DeployStep{
+			{Name: "create new app", Status: StepSucceeded, Duration: time.Second},
+			{Name: "upload bits", Status: StepFailed, Error: "no bits", Duration: 2 * time.Second},
+			{Name: "create new app", Rollback: true, Status: StepSucceeded, Duration: time.Second},
+		}}
+
+		Expect(report.String()).To(Equal("1. create new app: succeeded (1s)\n" +
+			"2. upload bits: failed (2s): no bits\n" +
+			"3. rollback: create new app: succeeded (1s)\n"))
+	})
+})
@@ -107,10 +107,8 @@ func (c *CfAPI) GetBrokers(brokerName string) (*types.CfServiceBrokerResources,
 	}
 
 	brokers := new(types.CfServiceBrokerResources)
-	if err := json.NewDecoder(response.Body).Decode(brokers); err != nil {
-		msg := fmt.Sprintf("Failed to parse broker list response: %v", err.Error())
-		log.Error(msg)
-		return nil, errors.Annotate(types.InternalServerError, msg)
+	if err := decodeResponse(response, brokers, "resources"); err != nil {
+		return nil, err
 	}
 	return brokers, nil
 }
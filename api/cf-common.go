@@ -17,14 +17,21 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	log "github.com/cihub/seelog"
 	"github.com/signalfx/golib/errors"
 	"github.com/trustedanalytics/go-cf-lib/helpers"
 	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 )
 
+const bodySnippetLength = 512
+
 func (c *CfAPI) deleteEntity(url string, entityName string) error {
 	log.Infof("Deleting %s: %v", entityName, url)
 
@@ -78,3 +85,100 @@ func (c *CfAPI) getEntity(url string, entityName string) (*http.Response, error)
 
 	return response, nil
 }
+
+// getAndDecode gets entity and decodes it into v, checking required fields
+func (c *CfAPI) getAndDecode(url string, entityName string, v interface{}, requiredFields ...string) error {
+	response, err := c.getEntity(url, entityName)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(response, v, requiredFields...)
+}
+
+// forEachPage requests address and every page following it. Function decoding
+// the page returns next_url of the decoded page, which is empty for the last one.
+func (c *CfAPI) forEachPage(address string, entityName string, decodePage func(*http.Response) (string, error)) error {
+	for address != "" {
+		response, err := c.getEntity(address, entityName)
+		if err != nil {
+			return err
+		}
+		nextURL, err := decodePage(response)
+		if err != nil {
+			return err
+		}
+		address = ""
+		if nextURL != "" {
+			address = c.BaseAddress + nextURL
+		}
+	}
+	return nil
+}
+
+// filtersQuery builds CC v2 filter query, e.g. q=name:app&q=space_guid:guid
+func filtersQuery(filters map[string]string) string {
+	keys := []string{}
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	query := url.Values{}
+	for _, key := range keys {
+		query.Add("q", key+":"+filters[key])
+	}
+	return query.Encode()
+}
+
+// decodeResponse decodes JSON body of CC response into toReturn. Required fields are given
+// as dot separated JSON paths, e.g. "metadata.guid", and must be present and non-empty.
+func decodeResponse(response *http.Response, toReturn interface{}, requiredFields ...string) error {
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return malformedResponse(response, body, fmt.Sprintf("could not read body: %v", err))
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "json") {
+		return malformedResponse(response, body, "unexpected content type")
+	}
+	if err := json.Unmarshal(body, toReturn); err != nil {
+		return malformedResponse(response, body, fmt.Sprintf("could not decode body: %v", err))
+	}
+
+	if len(requiredFields) > 0 {
+		var generic interface{}
+		json.Unmarshal(body, &generic)
+		for _, field := range requiredFields {
+			if !hasField(generic, field) {
+				return malformedResponse(response, body, "missing required field "+field)
+			}
+		}
+	}
+	return nil
+}
+
+func hasField(decoded interface{}, path string) bool {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := decoded.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		decoded = object[key]
+	}
+	return decoded != nil && decoded != ""
+}
+
+func malformedResponse(response *http.Response, body []byte, reason string) error {
+	snippet := string(body)
+	if len(snippet) > bodySnippetLength {
+		snippet = snippet[:bodySnippetLength] + "..."
+	}
+	err := &types.ErrMalformedResponse{
+		StatusCode:  response.StatusCode,
+		ContentType: response.Header.Get("Content-Type"),
+		Reason:      reason,
+		BodySnippet: snippet,
+	}
+	log.Error(err.Error())
+	return err
+}
@@ -0,0 +1,63 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+)
+
+var _ = Describe("Cf platform configuration with fake CC", func() {
+
+	var (
+		fake *cctest.FakeCC
+		sut  *api.CfAPI
+	)
+
+	BeforeEach(func() {
+		fake, sut = newFakeCCAPI()
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("should converge environment variable group", func() {
+		_, err := sut.ConvergeEnvVarGroup(api.EnvGroupRunning, map[string]interface{}{"HTTP_PROXY": "proxy:3128"})
+		Expect(err).NotTo(HaveOccurred())
+
+		changes, err := sut.ConvergeEnvVarGroup(api.EnvGroupRunning, map[string]interface{}{"NO_PROXY": "localhost"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(HaveLen(2))
+		Expect(fake.EnvVarGroup(api.EnvGroupRunning)).To(Equal(map[string]interface{}{"NO_PROXY": "localhost"}))
+		Expect(fake.EnvVarGroup(api.EnvGroupStaging)).To(BeEmpty())
+	})
+
+	It("should converge feature flags", func() {
+		changes, err := sut.ConvergeFeatureFlags(map[string]bool{"diego_docker": true, "app_scaling": true})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(HaveLen(1))
+		Expect(fake.FeatureFlag("diego_docker")).To(BeTrue())
+		flag, err := sut.GetFeatureFlag("diego_docker")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flag.Overridden).To(BeTrue())
+	})
+})
@@ -0,0 +1,236 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// Environment variable groups applied to every app of the foundation
+const (
+	EnvGroupRunning = "running"
+	EnvGroupStaging = "staging"
+)
+
+// Kinds of configuration change
+const (
+	ConfigAdded   = "added"
+	ConfigChanged = "changed"
+	ConfigRemoved = "removed"
+)
+
+// ConfigChange describes one variable or feature flag changed while converging platform configuration
+type ConfigChange struct {
+	Kind string
+	Name string
+	Old  interface{}
+	New  interface{}
+}
+
+func (c ConfigChange) String() string {
+	switch c.Kind {
+	case ConfigAdded:
+		return fmt.Sprintf("+ %v: %v", c.Name, c.New)
+	case ConfigRemoved:
+		return fmt.Sprintf("- %v: %v", c.Name, c.Old)
+	}
+	return fmt.Sprintf("~ %v: %v -> %v", c.Name, c.Old, c.New)
+}
+
+// GetEnvVarGroup returns variables of running or staging environment variable group.
+// It requires admin role.
+func (c *CfAPI) GetEnvVarGroup(group string) (map[string]interface{}, error) {
+	if err := validateEnvGroup(group); err != nil {
+		return nil, err
+	}
+	address := fmt.Sprintf("%v/v2/config/environment_variable_group/%v", c.BaseAddress, group)
+	toReturn := map[string]interface{}{}
+	if err := c.getAndDecode(address, group+" environment variable group", &toReturn); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+// SetEnvVarGroup replaces all variables of running or staging environment variable group.
+// Apps see the new variables after restart or restage respectively.
+func (c *CfAPI) SetEnvVarGroup(group string, vars map[string]interface{}) error {
+	if err := validateEnvGroup(group); err != nil {
+		return err
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	address := fmt.Sprintf("%v/v2/config/environment_variable_group/%v", c.BaseAddress, group)
+	return c.putConfig(address, group+" environment variable group", vars)
+}
+
+// GetFeatureFlags returns all feature flags of the foundation
+func (c *CfAPI) GetFeatureFlags() ([]types.CfFeatureFlag, error) {
+	toReturn := []types.CfFeatureFlag{}
+	if err := c.getAndDecode(c.BaseAddress+"/v2/config/feature_flags", "feature flags", &toReturn); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+// GetFeatureFlag returns feature flag of given name. Unknown flag results in EntityNotFoundError.
+func (c *CfAPI) GetFeatureFlag(name string) (*types.CfFeatureFlag, error) {
+	address := fmt.Sprintf("%v/v2/config/feature_flags/%v", c.BaseAddress, name)
+	toReturn := new(types.CfFeatureFlag)
+	if err := c.getAndDecode(address, "feature flag", toReturn, "name"); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+// SetFeatureFlag enables or disables feature flag. It requires admin role.
+func (c *CfAPI) SetFeatureFlag(name string, enabled bool) error {
+	address := fmt.Sprintf("%v/v2/config/feature_flags/%v", c.BaseAddress, name)
+	return c.putConfig(address, "feature flag "+name, map[string]bool{"enabled": enabled})
+}
+
+// ConvergeEnvVarGroup makes variable group equal to desired, removing variables not listed there.
+// Group is updated only when it differs. Returned changes are sorted by variable name.
+func (c *CfAPI) ConvergeEnvVarGroup(group string, desired map[string]interface{}) ([]ConfigChange, error) {
+	current, err := c.GetEnvVarGroup(group)
+	if err != nil {
+		return nil, err
+	}
+	changes := DiffEnvVars(current, desired)
+	if len(changes) == 0 {
+		log.Infof("The %v environment variable group is up to date", group)
+		return changes, nil
+	}
+	if err := c.SetEnvVarGroup(group, desired); err != nil {
+		return nil, err
+	}
+	log.Infof("The %v environment variable group converged, %d changes", group, len(changes))
+	return changes, nil
+}
+
+// ConvergeFeatureFlags sets flags listed in desired, leaving the other ones untouched.
+// Unknown flag names are rejected before any flag is changed. Returned changes are sorted by flag name.
+func (c *CfAPI) ConvergeFeatureFlags(desired map[string]bool) ([]ConfigChange, error) {
+	current, err := c.GetFeatureFlags()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := DiffFeatureFlags(current, desired)
+	if err != nil {
+		return nil, err
+	}
+	for i, change := range changes {
+		if err := c.SetFeatureFlag(change.Name, change.New.(bool)); err != nil {
+			log.Errorf("Feature flags converged partially, %d of %d changes applied", i, len(changes))
+			return changes[:i], err
+		}
+	}
+	return changes, nil
+}
+
+// DiffEnvVars returns changes turning current variables into desired ones, sorted by name.
+// Values are compared as their JSON representations, so e.g. int 1 equals float64 1.
+func DiffEnvVars(current, desired map[string]interface{}) []ConfigChange {
+	normalized := map[string]interface{}{}
+	raw, _ := json.Marshal(desired)
+	json.Unmarshal(raw, &normalized)
+
+	changes := []ConfigChange{}
+	for _, name := range sortedKeys(current, normalized) {
+		old, inCurrent := current[name]
+		value, inDesired := normalized[name]
+		switch {
+		case !inCurrent:
+			changes = append(changes, ConfigChange{Kind: ConfigAdded, Name: name, New: desired[name]})
+		case !inDesired:
+			changes = append(changes, ConfigChange{Kind: ConfigRemoved, Name: name, Old: old})
+		case !reflect.DeepEqual(old, value):
+			changes = append(changes, ConfigChange{Kind: ConfigChanged, Name: name, Old: old, New: desired[name]})
+		}
+	}
+	return changes
+}
+
+// DiffFeatureFlags returns changes of flags listed in desired, sorted by name.
+// Flags unknown to the foundation result in EntityNotFoundError.
+func DiffFeatureFlags(current []types.CfFeatureFlag, desired map[string]bool) ([]ConfigChange, error) {
+	flags := map[string]interface{}{}
+	for _, flag := range current {
+		flags[flag.Name] = flag.Enabled
+	}
+	changes := []ConfigChange{}
+	for _, name := range sortedKeys(desired) {
+		enabled, ok := flags[name]
+		if !ok {
+			msg := fmt.Sprintf("Unknown feature flag %v", name)
+			log.Error(msg)
+			return nil, errors.Annotate(types.EntityNotFoundError, msg)
+		}
+		if enabled != desired[name] {
+			changes = append(changes, ConfigChange{Kind: ConfigChanged, Name: name, Old: enabled, New: desired[name]})
+		}
+	}
+	return changes, nil
+}
+
+func validateEnvGroup(group string) error {
+	if group != EnvGroupRunning && group != EnvGroupStaging {
+		return errors.Annotate(types.InvalidInputError, "Unknown environment variable group: "+group)
+	}
+	return nil
+}
+
+func (c *CfAPI) putConfig(address, entityName string, v interface{}) error {
+	log.Infof("Updating %v: %v", entityName, address)
+	raw, _ := json.Marshal(v)
+	request, _ := http.NewRequest("PUT", address, bytes.NewReader(raw))
+	resp, err := c.Do(request)
+	if err != nil {
+		log.Errorf("Could not update %v: [%v]", entityName, err)
+		return errors.Wrap(types.CcUpdateConfigFailedError, err)
+	} else if !IsSuccessStatus(resp.StatusCode) {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("Updating %v finished with error: %v", entityName, message)
+		return CreateCcError(message, types.CcUpdateConfigFailedError)
+	}
+	return nil
+}
+
+// sortedKeys returns keys of all given maps, each once, in order
+func sortedKeys(maps ...interface{}) []string {
+	seen := map[string]bool{}
+	for _, m := range maps {
+		for _, key := range reflect.ValueOf(m).MapKeys() {
+			seen[key.String()] = true
+		}
+	}
+	toReturn := []string{}
+	for key := range seen {
+		toReturn = append(toReturn, key)
+	}
+	sort.Strings(toReturn)
+	return toReturn
+}
@@ -0,0 +1,162 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+)
+
+var _ = Describe("Cf config", func() {
+
+	var (
+		sut  CfAPI
+		sent []string
+	)
+
+	recordingResponder := func(code int) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			sent = append(sent, req.URL.Path+" "+string(body))
+			return httpmock.NewStringResponse(code, "{}"), nil
+		}
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		sent = []string{}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("environment variable groups", func() {
+		It("should get group", func() {
+			httpmock.RegisterResponder("GET", "/v2/config/environment_variable_group/running",
+				responderGenerator(200, map[string]interface{}{"HTTP_PROXY": "proxy:3128"}))
+
+			result, err := sut.GetEnvVarGroup(EnvGroupRunning)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(map[string]interface{}{"HTTP_PROXY": "proxy:3128"}))
+		})
+
+		It("should reject unknown group", func() {
+			_, err := sut.GetEnvVarGroup("other")
+
+			Expect(errors.Cause(err)).To(Equal(types.InvalidInputError))
+		})
+
+		It("should converge group and report changes", func() {
+			httpmock.RegisterResponder("GET", "/v2/config/environment_variable_group/staging",
+				responderGenerator(200, map[string]interface{}{"KEEP": "same", "OLD": "x", "PORT": 8080}))
+			httpmock.RegisterResponder("PUT", "/v2/config/environment_variable_group/staging", recordingResponder(200))
+
+			changes, err := sut.ConvergeEnvVarGroup(EnvGroupStaging, map[string]interface{}{"KEEP": "same", "NEW": "y", "PORT": 9090})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]ConfigChange{
+				{Kind: ConfigAdded, Name: "NEW", New: "y"},
+				{Kind: ConfigRemoved, Name: "OLD", Old: "x"},
+				{Kind: ConfigChanged, Name: "PORT", Old: 8080.0, New: 9090},
+			}))
+			Expect(changes[2].String()).To(Equal("~ PORT: 8080 -> 9090"))
+			Expect(sent).To(HaveLen(1))
+			body := map[string]interface{}{}
+			json.Unmarshal([]byte(sent[0][len("/v2/config/environment_variable_group/staging "):]), &body)
+			Expect(body).To(Equal(map[string]interface{}{"KEEP": "same", "NEW": "y", "PORT": 9090.0}))
+		})
+
+		It("should not update group already converged", func() {
+			httpmock.RegisterResponder("GET", "/v2/config/environment_variable_group/running",
+				responderGenerator(200, map[string]interface{}{"PORT": 8080}))
+
+			changes, err := sut.ConvergeEnvVarGroup(EnvGroupRunning, map[string]interface{}{"PORT": 8080})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(BeEmpty())
+		})
+
+		It("should return CC error when update is rejected", func() {
+			httpmock.RegisterResponder("PUT", "/v2/config/environment_variable_group/running",
+				responderGenerator(403, map[string]interface{}{"description": "You are not authorized"}))
+
+			err := sut.SetEnvVarGroup(EnvGroupRunning, nil)
+
+			Expect(errors.Cause(err)).To(Equal(types.CcUpdateConfigFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("You are not authorized"))
+		})
+	})
+
+	Describe("feature flags", func() {
+		flags := []types.CfFeatureFlag{
+			{Name: "diego_docker", Enabled: false},
+			{Name: "user_org_creation", Enabled: false},
+			{Name: "app_scaling", Enabled: true},
+		}
+
+		BeforeEach(func() {
+			httpmock.RegisterResponder("GET", "/v2/config/feature_flags", responderGenerator(200, flags))
+		})
+
+		It("should get single flag", func() {
+			httpmock.RegisterResponder("GET", "/v2/config/feature_flags/app_scaling", responderGenerator(200, flags[2]))
+
+			result, err := sut.GetFeatureFlag("app_scaling")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Enabled).To(BeTrue())
+		})
+
+		It("should set only flags which differ", func() {
+			httpmock.RegisterResponder("PUT", "/v2/config/feature_flags/diego_docker", recordingResponder(200))
+
+			changes, err := sut.ConvergeFeatureFlags(map[string]bool{"diego_docker": true, "app_scaling": true})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]ConfigChange{{Kind: ConfigChanged, Name: "diego_docker", Old: false, New: true}}))
+			Expect(sent).To(Equal([]string{`/v2/config/feature_flags/diego_docker {"enabled":true}`}))
+		})
+
+		It("should reject unknown flag before changing anything", func() {
+			changes, err := sut.ConvergeFeatureFlags(map[string]bool{"diego_docker": true, "no_such_flag": true})
+
+			Expect(errors.Cause(err)).To(Equal(types.EntityNotFoundError))
+			Expect(changes).To(BeNil())
+			Expect(sent).To(BeEmpty())
+		})
+
+		It("should report changes applied before failure", func() {
+			httpmock.RegisterResponder("PUT", "/v2/config/feature_flags/diego_docker", recordingResponder(200))
+			httpmock.RegisterResponder("PUT", "/v2/config/feature_flags/user_org_creation", recordingResponder(500))
+
+			changes, err := sut.ConvergeFeatureFlags(map[string]bool{"diego_docker": true, "user_org_creation": true})
+
+			Expect(errors.Cause(err)).To(Equal(types.CcUpdateConfigFailedError))
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Name).To(Equal("diego_docker"))
+		})
+	})
+})
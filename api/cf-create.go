@@ -88,15 +88,24 @@ func (c *CfAPI) CreateApplicationClone(sourceAppGUID, spaceGUID string, paramete
 
 	//Newly spawned app instance shall have almost identical config as reference app
 	destApp := types.NewCfAppResource(*sourceAppSummary, requestedName, spaceGUID)
-	if destApp.Entity.Envs == nil && len(parameters) > 0 {
-		destApp.Entity.Envs = map[string]interface{}{}
+	if destApp.Entity.IsDocker() {
+		// Docker clone gets the image reference here, but CopyBits fails for Docker apps, so callers
+		// have to use CloneAppBits instead. Registry credentials are not returned by CC and have
+		// to be set with UpdateDockerImage for private images.
+		log.Infof("Cloning Docker app %v with image %v", sourceAppGUID, destApp.Entity.DockerImage)
+		diego := true
+		destApp.Entity.Diego = &diego
 	}
-	for k, v := range parameters {
-		log.Debugf("Setting additional env: %v:%v", k, v)
-		if _, ok := destApp.Entity.Envs[k]; ok {
-			log.Warnf("Env %v already exists (overriding)", k)
+	if len(parameters) > 0 {
+		additionalEnvs := map[string]interface{}{}
+		for k, v := range parameters {
+			log.Debugf("Setting additional env: %v:%v", k, v)
+			if _, ok := destApp.Entity.Envs[k]; ok {
+				log.Warnf("Env %v already exists (overriding)", k)
+			}
+			additionalEnvs[k] = v
 		}
-		destApp.Entity.Envs[k] = v
+		destApp.Entity.Envs = mergeEnv(destApp.Entity.Envs, additionalEnvs, nil)
 	}
 	destApp, err = c.CreateApp(destApp.Entity)
 	if err != nil {
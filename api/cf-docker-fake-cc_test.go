@@ -0,0 +1,85 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"time"
+)
+
+var _ = Describe("Cf docker apps with fake CC", func() {
+
+	var (
+		fake       *cctest.FakeCC
+		sut        *api.CfAPI
+		dockerGUID string
+	)
+
+	BeforeEach(func() {
+		fake, sut = newFakeCCAPI()
+		domainGUID := fake.AddDomain("example.com")
+		app, err := sut.CreateDockerApp(types.CfApp{Name: "web", SpaceGUID: "space"}, "web:1.0",
+			&types.CfDockerCredentials{Username: "deployer", Password: "s3cret"})
+		Expect(err).NotTo(HaveOccurred())
+		dockerGUID = app.Meta.GUID
+		routeGUID := fake.AddRoute(types.CfCreateRouteRequest{Host: "web", DomainGUID: domainGUID, SpaceGUID: "space"})
+		Expect(sut.AssociateRoute(dockerGUID, routeGUID)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("should start without bits and never return credentials", func() {
+		app, err := sut.GetApp(dockerGUID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(app.Entity.DockerCredentials).To(BeNil())
+		stored, _ := fake.App(dockerGUID)
+		Expect(stored.DockerCredentials.Password).To(Equal("s3cret"))
+
+		Expect(sut.StartApp(app)).To(Succeed())
+	})
+
+	It("should clone image reference instead of bits", func() {
+		clone, err := sut.CreateApplicationClone(dockerGUID, "space", map[string]string{"name": "web-clone"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sut.CloneAppBits(dockerGUID, clone.Meta.GUID)).To(Succeed())
+
+		app, _ := fake.App(clone.Meta.GUID)
+		Expect(app.DockerImage).To(Equal("web:1.0"))
+		Expect(*app.Diego).To(BeTrue())
+		Expect(sut.StartApp(clone)).To(Succeed())
+	})
+
+	It("should deploy new version of docker app", func() {
+		credentials := &types.CfDockerCredentials{Username: "deployer", Password: "s3cret"}
+
+		report, err := sut.BlueGreenDeploy(api.BlueGreenRequest{AppGUID: dockerGUID, DockerCredentials: credentials,
+			Wait: api.WaitOptions{PollInterval: time.Millisecond}})
+
+		Expect(err).NotTo(HaveOccurred())
+		app, _ := fake.App(report.NewAppGUID)
+		Expect(app.DockerImage).To(Equal("web:1.0"))
+		Expect(app.DockerCredentials).To(Equal(credentials))
+		Expect(app.State).To(Equal(types.AppStarted))
+	})
+})
@@ -0,0 +1,77 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+)
+
+// CreateDockerApp creates app running Docker image. Credentials are needed only for private registries.
+// The app is created stopped, it needs no bits, so it may be started right away.
+func (c *CfAPI) CreateDockerApp(app types.CfApp, image string, credentials *types.CfDockerCredentials) (*types.CfAppResource, error) {
+	if image == "" {
+		return nil, errors.Annotate(types.InvalidInputError, "Docker image is required")
+	}
+	if app.BuildpackUrl != "" {
+		return nil, errors.Annotate(types.InvalidInputError, "Docker app cannot have buildpack")
+	}
+	diego := true
+	app.DockerImage = image
+	app.DockerCredentials = credentials
+	app.Diego = &diego
+	if app.State == "" {
+		app.State = types.AppStopped
+	}
+	log.Infof("Creating Docker app %v from image %v", app.Name, image)
+	return c.CreateApp(app)
+}
+
+// UpdateDockerImage changes image of Docker app and, when given, registry credentials.
+// Running instances use the old image until the app is restarted.
+func (c *CfAPI) UpdateDockerImage(appGUID, image string, credentials *types.CfDockerCredentials) error {
+	if image == "" {
+		return errors.Annotate(types.InvalidInputError, "Docker image is required")
+	}
+	fields := map[string]interface{}{"docker_image": image}
+	if credentials != nil {
+		fields["docker_credentials"] = credentials
+	}
+	return c.updateAppFields(appGUID, fields)
+}
+
+// CloneAppBits makes destination app run the same code as source one. Bits are copied
+// for buildpack apps, while Docker apps get the image reference of the source.
+// Registry credentials are never returned by CC, so they have to be set again with UpdateDockerImage.
+func (c *CfAPI) CloneAppBits(sourceGUID, destGUID string) error {
+	source, err := c.GetApp(sourceGUID)
+	if err != nil {
+		return err
+	}
+	if source.Entity.IsDocker() {
+		log.Infof("App %v runs Docker image, copying image reference to %v", sourceGUID, destGUID)
+		return c.updateAppFields(destGUID, map[string]interface{}{"docker_image": source.Entity.DockerImage, "diego": true})
+	}
+	asyncError := make(chan error, 1)
+	c.CopyBits(sourceGUID, destGUID, asyncError)
+	if err := <-asyncError; err != nil {
+		return errors.Annotate(err, fmt.Sprintf("Could not copy bits of app %v to %v", sourceGUID, destGUID))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+)
+
+var _ = Describe("Cf docker", func() {
+
+	var sut CfAPI
+	var sent map[string]interface{}
+	credentials := &types.CfDockerCredentials{Username: "deployer", Password: "s3cret"}
+
+	capture := func(code int, v interface{}) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			sent = map[string]interface{}{}
+			json.Unmarshal(body, &sent)
+			return httpmock.NewJsonResponse(code, v)
+		}
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		sent = nil
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("create docker app", func() {
+		It("should create stopped diego app with image and credentials", func() {
+			httpmock.RegisterResponder("POST", "/v2/apps", capture(201, types.CfAppResource{Meta: types.CfMeta{GUID: "guid"}}))
+
+			app, err := sut.CreateDockerApp(types.CfApp{Name: "web", SpaceGUID: "space"}, "registry.example.com/web:1.0", credentials)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(app.Meta.GUID).To(Equal("guid"))
+			Expect(sent).To(HaveKeyWithValue("docker_image", "registry.example.com/web:1.0"))
+			Expect(sent).To(HaveKeyWithValue("docker_credentials",
+				map[string]interface{}{"username": "deployer", "password": "s3cret"}))
+			Expect(sent).To(HaveKeyWithValue("diego", true))
+			Expect(sent).To(HaveKeyWithValue("state", types.AppStopped))
+		})
+
+		It("should reject app without image or with buildpack", func() {
+			_, err := sut.CreateDockerApp(types.CfApp{Name: "web"}, "", nil)
+			Expect(errors.Cause(err)).To(Equal(types.InvalidInputError))
+
+			_, err = sut.CreateDockerApp(types.CfApp{Name: "web", BuildpackUrl: "go_buildpack"}, "web:1.0", nil)
+			Expect(errors.Cause(err)).To(Equal(types.InvalidInputError))
+		})
+	})
+
+	Describe("update docker image", func() {
+		It("should send only image and credentials", func() {
+			httpmock.RegisterResponder("PUT", "/v2/apps/guid", capture(201, nil))
+
+			err := sut.UpdateDockerImage("guid", "web:2.0", credentials)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sent).To(Equal(map[string]interface{}{
+				"docker_image":       "web:2.0",
+				"docker_credentials": map[string]interface{}{"username": "deployer", "password": "s3cret"},
+			}))
+		})
+	})
+
+	It("should redact password when credentials are formatted", func() {
+		app := types.CfApp{Name: "web", DockerImage: "web:1.0", DockerCredentials: credentials}
+
+		for _, format := range []string{"%v", "%+v", "%#v"} {
+			Expect(fmt.Sprintf(format, app)).NotTo(ContainSubstring("s3cret"), format)
+			Expect(fmt.Sprintf(format, map[string]interface{}{"docker_credentials": credentials})).NotTo(ContainSubstring("s3cret"), format)
+		}
+		Expect(fmt.Sprintf("%+v", app)).To(ContainSubstring("deployer"))
+	})
+
+	Describe("clone app bits", func() {
+		It("should copy image reference of docker app", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/source", responderGenerator(200, types.CfAppResource{
+				Meta: types.CfMeta{GUID: "source"}, Entity: types.CfApp{DockerImage: "web:1.0"}}))
+			httpmock.RegisterResponder("PUT", "/v2/apps/dest", capture(201, nil))
+
+			err := sut.CloneAppBits("source", "dest")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sent).To(Equal(map[string]interface{}{"docker_image": "web:1.0", "diego": true}))
+		})
+
+		It("should copy bits of buildpack app", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/source", responderGenerator(200, types.CfAppResource{
+				Meta: types.CfMeta{GUID: "source"}, Entity: types.CfApp{BuildpackUrl: "go_buildpack"}}))
+			job := types.CfJobResponse{Meta: types.CfMeta{URL: "/v2/jobs/job"}, Entity: types.CfJob{Status: "finished"}}
+			httpmock.RegisterResponder("POST", "/v2/apps/dest/copy_bits", capture(201, job))
+
+			err := sut.CloneAppBits("source", "dest")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sent).To(HaveKeyWithValue("source_app_guid", "source"))
+		})
+	})
+})
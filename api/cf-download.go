@@ -0,0 +1,201 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// DownloadReport describes downloaded data. SHA256 is hex encoded checksum of the data.
+// Verified lists what the data was checked against, e.g. "size", "Content-MD5", "sha256".
+type DownloadReport struct {
+	Bytes    int64
+	SHA256   string
+	Verified []string
+}
+
+// DownloadBits streams source package of the app into w. Package checksum is verified when CC exposes v3 API
+// and the newest package of the app is the package of its current droplet, so it is known which one was served.
+// On error w may hold partial data, which shall be discarded.
+func (c *CfAPI) DownloadBits(appGUID string, w io.Writer) (*DownloadReport, error) {
+	address := fmt.Sprintf("%v/v2/apps/%v/download", c.BaseAddress, appGUID)
+	return c.download(address, "app package", w, func() *types.CfChecksum {
+		droplet := new(types.CfV3Droplet)
+		address := fmt.Sprintf("%v/v3/apps/%v/droplets/current", c.BaseAddress, appGUID)
+		if err := c.getV3Checksum(address, "current droplet", droplet); err != nil {
+			return nil
+		}
+		packages := new(types.CfV3PackagesResponse)
+		address = fmt.Sprintf("%v/v3/apps/%v/packages?order_by=-created_at&per_page=1", c.BaseAddress, appGUID)
+		if err := c.getV3Checksum(address, "app packages", packages); err != nil || len(packages.Resources) == 0 {
+			return nil
+		}
+		newest := packages.Resources[0]
+		if link, err := url.Parse(droplet.Links["package"].Href); err != nil || path.Base(link.Path) != newest.GUID {
+			log.Warnf("Newest package %v of app %v is not the package of its current droplet, checksum will not be verified",
+				newest.GUID, appGUID)
+			return nil
+		}
+		return newest.Data.Checksum
+	})
+}
+
+// DownloadDroplet streams staged droplet of the app into w. Droplet checksum is verified when CC exposes v3 API.
+// On error w may hold partial data, which shall be discarded.
+func (c *CfAPI) DownloadDroplet(appGUID string, w io.Writer) (*DownloadReport, error) {
+	address := fmt.Sprintf("%v/v2/apps/%v/droplet/download", c.BaseAddress, appGUID)
+	return c.download(address, "app droplet", w, func() *types.CfChecksum {
+		droplet := new(types.CfV3Droplet)
+		address := fmt.Sprintf("%v/v3/apps/%v/droplets/current", c.BaseAddress, appGUID)
+		if err := c.getV3Checksum(address, "current droplet", droplet); err != nil {
+			return nil
+		}
+		return droplet.Checksum
+	})
+}
+
+func (c *CfAPI) getV3Checksum(address, entityName string, v interface{}) error {
+	capabilities, err := c.GetCapabilities()
+	if err != nil || !capabilities.V3 {
+		return types.EntityNotFoundError
+	}
+	if err := c.getAndDecode(address, entityName, v); err != nil {
+		log.Warnf("Could not get checksum of %v, it will not be verified: %v", entityName, err)
+		return err
+	}
+	return nil
+}
+
+// download requests address and follows redirect to blobstore itself, so the CC token
+// is not sent to the blobstore. Data is streamed into w and verified against
+// Content-Length, Content-MD5 and checksum returned by expected, when available.
+func (c *CfAPI) download(address, entityName string, w io.Writer, expected func() *types.CfChecksum) (*DownloadReport, error) {
+	log.Infof("Downloading %v: %v", entityName, address)
+	resp, err := c.noRedirectClient().Get(address)
+	if err != nil {
+		log.Errorf("Could not download %v: [%v]", entityName, err)
+		return nil, errors.Wrap(types.CcDownloadFailedError, err)
+	}
+	if isRedirect(resp.StatusCode) {
+		location, err := resp.Location()
+		resp.Body.Close()
+		if err != nil {
+			log.Errorf("Invalid redirect while downloading %v: [%v]", entityName, err)
+			return nil, errors.Wrap(types.CcDownloadFailedError, err)
+		}
+		log.Debugf("Downloading %v from blobstore: %v://%v%v", entityName, location.Scheme, location.Host, location.Path)
+		blobstore := &http.Client{Transport: c.blobstoreTransport(), Timeout: c.Timeout}
+		if resp, err = blobstore.Get(location.String()); err != nil {
+			log.Errorf("Could not download %v from blobstore: [%v]", entityName, err)
+			return nil, errors.Wrap(types.CcDownloadFailedError, err)
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, types.EntityNotFoundError
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("Download of %v finished with error: (%d) %v", entityName, resp.StatusCode, message)
+		return nil, CreateCcError(message, types.CcDownloadFailedError)
+	}
+
+	hashes := map[string]hash.Hash{"md5": md5.New(), "sha1": sha1.New(), "sha256": sha256.New()}
+	writers := []io.Writer{w}
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+	n, err := io.Copy(io.MultiWriter(writers...), resp.Body)
+	if err != nil {
+		log.Errorf("Could not download %v: [%v]", entityName, err)
+		return nil, errors.Wrap(types.CcDownloadFailedError, err)
+	}
+	report := &DownloadReport{Bytes: n, SHA256: hex.EncodeToString(hashes["sha256"].Sum(nil)), Verified: []string{}}
+
+	if resp.ContentLength >= 0 {
+		if n != resp.ContentLength {
+			return report, verificationFailed(entityName, fmt.Sprintf("expected %d bytes, got %d", resp.ContentLength, n))
+		}
+		report.Verified = append(report.Verified, "size")
+	}
+	if contentMD5 := resp.Header.Get("Content-MD5"); contentMD5 != "" {
+		if actual := base64.StdEncoding.EncodeToString(hashes["md5"].Sum(nil)); actual != contentMD5 {
+			return report, verificationFailed(entityName, fmt.Sprintf("expected Content-MD5 %v, got %v", contentMD5, actual))
+		}
+		report.Verified = append(report.Verified, "Content-MD5")
+	}
+	if checksum := expected(); checksum != nil && checksum.Value != "" {
+		h, ok := hashes[checksum.Type]
+		if !ok {
+			log.Warnf("Unknown checksum type of %v: %v", entityName, checksum.Type)
+		} else if actual := hex.EncodeToString(h.Sum(nil)); actual != checksum.Value {
+			return report, verificationFailed(entityName, fmt.Sprintf("expected %v %v, got %v", checksum.Type, checksum.Value, actual))
+		} else {
+			report.Verified = append(report.Verified, checksum.Type)
+		}
+	}
+	log.Infof("Downloaded %v: %d bytes, verified %v", entityName, n, report.Verified)
+	return report, nil
+}
+
+// blobstoreTransport never returns transport of the client, as any of its layers may add CC credentials
+func (c *CfAPI) blobstoreTransport() http.RoundTripper {
+	if c.BlobstoreTransport != nil {
+		return c.BlobstoreTransport
+	}
+	return http.DefaultTransport
+}
+
+// noRedirectClient sends requests with CC credentials, but returns redirects instead of following them
+func (c *CfAPI) noRedirectClient() *http.Client {
+	return &http.Client{
+		Transport: c.Transport,
+		Timeout:   c.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+func verificationFailed(entityName, msg string) error {
+	msg = fmt.Sprintf("Verification of %v failed: %v", entityName, msg)
+	log.Error(msg)
+	return errors.Annotate(types.DownloadVerificationFailedError, msg)
+}
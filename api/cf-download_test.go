@@ -0,0 +1,214 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"golang.org/x/oauth2"
+	"net/http"
+)
+
+type authorizingTransport string
+
+func (t authorizingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", string(t))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var _ = Describe("Cf download", func() {
+
+	const (
+		content = "application bits"
+		// checksums of content
+		contentSHA256 = "ea39d0c25972a643d791877936e78839c094dc69a668d75d5ca7c61ec5e817be"
+		contentMD5    = "5E90FgkrzioxmkLF48lXUg=="
+	)
+
+	var sut CfAPI
+	var blobstoreAuthorization []string
+
+	blobstoreResponder := func(header http.Header) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			blobstoreAuthorization = append(blobstoreAuthorization, req.Header.Get("Authorization"))
+			resp := httpmock.NewStringResponse(200, content)
+			resp.ContentLength = int64(len(content))
+			for name, values := range header {
+				resp.Header[name] = values
+			}
+			return resp, nil
+		}
+	}
+
+	redirectResponder := func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(302, "")
+		resp.Header.Set("Location", "https://blobstore.example.com/bits/guid")
+		return resp, nil
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		token := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token", TokenType: "bearer"})
+		sut = CfAPI{Client: &http.Client{Transport: &oauth2.Transport{Source: token}}}
+		sut.capabilities = &Capabilities{}
+		blobstoreAuthorization = nil
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("download bits", func() {
+		It("should follow redirect to blobstore without CC token", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/download", redirectResponder)
+			httpmock.RegisterResponder("GET", "https://blobstore.example.com/bits/guid",
+				blobstoreResponder(http.Header{"Content-Md5": {contentMD5}}))
+			var buffer bytes.Buffer
+
+			report, err := sut.DownloadBits("guid", &buffer)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buffer.String()).To(Equal(content))
+			Expect(blobstoreAuthorization).To(Equal([]string{""}))
+			Expect(report.Bytes).To(Equal(int64(len(content))))
+			Expect(report.SHA256).To(Equal(contentSHA256))
+			Expect(report.Verified).To(Equal([]string{"size", "Content-MD5"}))
+		})
+
+		It("should not send credentials added by custom transport to blobstore", func() {
+			sut.Client = &http.Client{Transport: authorizingTransport("Basic secret")}
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/download", redirectResponder)
+			httpmock.RegisterResponder("GET", "https://blobstore.example.com/bits/guid", blobstoreResponder(nil))
+
+			_, err := sut.DownloadBits("guid", &bytes.Buffer{})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobstoreAuthorization).To(Equal([]string{""}))
+		})
+
+		It("should download from blobstore with blobstore transport", func() {
+			sut.BlobstoreTransport = authorizingTransport("Basic blobstore")
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/download", redirectResponder)
+			httpmock.RegisterResponder("GET", "https://blobstore.example.com/bits/guid", blobstoreResponder(nil))
+
+			_, err := sut.DownloadBits("guid", &bytes.Buffer{})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobstoreAuthorization).To(Equal([]string{"Basic blobstore"}))
+		})
+
+		Context("when v3 API is available", func() {
+			packagesResponder := func(guid string) httpmock.Responder {
+				return responderGenerator(200, map[string]interface{}{"resources": []interface{}{
+					map[string]interface{}{"guid": guid, "type": "bits", "state": "READY",
+						"data": map[string]interface{}{"checksum": map[string]string{"type": "sha256", "value": contentSHA256}}},
+				}})
+			}
+
+			BeforeEach(func() {
+				sut.capabilities = &Capabilities{V3: true}
+				httpmock.RegisterResponder("GET", "/v3/apps/guid/droplets/current", responderGenerator(200,
+					map[string]interface{}{"guid": "droplet", "state": "STAGED", "links": map[string]interface{}{
+						"package": map[string]string{"href": "https://api.example.com/v3/packages/package"}}}))
+			})
+
+			It("should verify checksum of the package of current droplet", func() {
+				httpmock.RegisterResponder("GET", "/v2/apps/guid/download", blobstoreResponder(nil))
+				httpmock.RegisterResponder("GET", "/v3/apps/guid/packages?order_by=-created_at&per_page=1",
+					packagesResponder("package"))
+
+				report, err := sut.DownloadBits("guid", &bytes.Buffer{})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Verified).To(Equal([]string{"size", "sha256"}))
+			})
+
+			It("should skip checksum when newer package was uploaded", func() {
+				httpmock.RegisterResponder("GET", "/v2/apps/guid/download", blobstoreResponder(nil))
+				httpmock.RegisterResponder("GET", "/v3/apps/guid/packages?order_by=-created_at&per_page=1",
+					packagesResponder("newer-package"))
+
+				report, err := sut.DownloadBits("guid", &bytes.Buffer{})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Verified).To(Equal([]string{"size"}))
+			})
+		})
+
+		It("should fail when Content-MD5 does not match", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/download",
+				blobstoreResponder(http.Header{"Content-Md5": {"AAAAAAAAAAAAAAAAAAAAAA=="}}))
+
+			_, err := sut.DownloadBits("guid", &bytes.Buffer{})
+
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Cause(err)).To(Equal(types.DownloadVerificationFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("Content-MD5"))
+		})
+
+		It("should return not found for unknown app", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/download", responderGenerator(404, nil))
+
+			_, err := sut.DownloadBits("guid", &bytes.Buffer{})
+
+			Expect(err).To(Equal(types.EntityNotFoundError))
+		})
+
+		It("should fail when blobstore returns error", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/download", redirectResponder)
+			httpmock.RegisterResponder("GET", "https://blobstore.example.com/bits/guid",
+				httpmock.NewStringResponder(500, "blobstore failure"))
+
+			_, err := sut.DownloadBits("guid", &bytes.Buffer{})
+
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Cause(err)).To(Equal(types.CcDownloadFailedError))
+		})
+	})
+
+	Describe("download droplet", func() {
+		It("should fail when droplet checksum does not match", func() {
+			sut.capabilities = &Capabilities{V3: true}
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/droplet/download", redirectResponder)
+			httpmock.RegisterResponder("GET", "https://blobstore.example.com/bits/guid", blobstoreResponder(nil))
+			httpmock.RegisterResponder("GET", "/v3/apps/guid/droplets/current", responderGenerator(200,
+				map[string]interface{}{"guid": "droplet", "state": "STAGED",
+					"checksum": map[string]string{"type": "sha1", "value": "0000000000000000000000000000000000000000"}}))
+
+			report, err := sut.DownloadDroplet("guid", &bytes.Buffer{})
+
+			Expect(errors.Cause(err)).To(Equal(types.DownloadVerificationFailedError))
+			Expect(report.Verified).To(Equal([]string{"size"}))
+		})
+
+		It("should download droplet when v3 API is not available", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/droplet/download", redirectResponder)
+			httpmock.RegisterResponder("GET", "https://blobstore.example.com/bits/guid", blobstoreResponder(nil))
+			var buffer bytes.Buffer
+
+			_, err := sut.DownloadDroplet("guid", &buffer)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buffer.String()).To(Equal(content))
+			Expect(blobstoreAuthorization).To(Equal([]string{""}))
+		})
+	})
+})
@@ -0,0 +1,114 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const eventsPerPage = 100
+
+// CrashSummary describes single app.crash event
+type CrashSummary struct {
+	Timestamp       time.Time
+	Index           int
+	ExitStatus      int
+	ExitDescription string
+	Reason          string
+}
+
+func (s CrashSummary) String() string {
+	msg := fmt.Sprintf("%v crash of instance %d: exit status %d, %v",
+		s.Timestamp.Format(time.RFC3339), s.Index, s.ExitStatus, s.ExitDescription)
+	if s.Reason != "" {
+		msg += " (" + s.Reason + ")"
+	}
+	return msg
+}
+
+// GetAppEvents returns events of the app newer than since, oldest first. Zero since means all events.
+// When event types are given, only events of those types are returned, e.g. types.EventAppCrash.
+func (c *CfAPI) GetAppEvents(appGUID string, since time.Time, eventTypes ...string) ([]types.CfEventResource, error) {
+	query := url.Values{}
+	query.Add("q", "actee:"+appGUID)
+	if !since.IsZero() {
+		query.Add("q", "timestamp>"+since.UTC().Format(time.RFC3339))
+	}
+	if len(eventTypes) == 1 {
+		query.Add("q", "type:"+eventTypes[0])
+	} else if len(eventTypes) > 1 {
+		query.Add("q", "type IN "+strings.Join(eventTypes, ","))
+	}
+	address := fmt.Sprintf("%v/v2/events?%v&order-direction=asc&results-per-page=%d", c.BaseAddress, query.Encode(), eventsPerPage)
+
+	events := []types.CfEventResource{}
+	err := c.forEachPage(address, "app events", func(response *http.Response) (string, error) {
+		page := new(types.CfEventsResponse)
+		if err := decodeResponse(response, page, "resources"); err != nil {
+			return "", err
+		}
+		events = append(events, page.Resources...)
+		return page.NextURL, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Retrieved %d event(s) of app %v", len(events), appGUID)
+	return events, nil
+}
+
+// SummarizeCrashes describes app.crash events in given order. Events of other types are skipped.
+func SummarizeCrashes(events []types.CfEventResource) []CrashSummary {
+	summaries := []CrashSummary{}
+	for _, event := range events {
+		if event.Entity.Type != types.EventAppCrash {
+			continue
+		}
+		metadata := event.Entity.Metadata
+		summaries = append(summaries, CrashSummary{
+			Timestamp:       event.Entity.Timestamp,
+			Index:           metadataInt(metadata, "index"),
+			ExitStatus:      metadataInt(metadata, "exit_status"),
+			ExitDescription: metadataString(metadata, "exit_description"),
+			Reason:          metadataString(metadata, "reason"),
+		})
+	}
+	return summaries
+}
+
+func metadataInt(metadata map[string]interface{}, key string) int {
+	switch value := metadata[key].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	}
+	return 0
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	if value, ok := metadata[key].(string); ok {
+		return value
+	}
+	return ""
+}
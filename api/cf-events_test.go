@@ -0,0 +1,105 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("Cf events", func() {
+
+	var sut CfAPI
+	since := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	crash := func(guid string, index, exitStatus int, description string) types.CfEventResource {
+		return types.CfEventResource{Meta: types.CfMeta{GUID: guid}, Entity: types.CfEvent{
+			Type:      types.EventAppCrash,
+			Actee:     "guid",
+			Timestamp: since.Add(time.Minute),
+			Metadata: map[string]interface{}{"index": index, "exit_status": exitStatus,
+				"exit_description": description, "reason": "CRASHED"},
+		}}
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("get app events", func() {
+		It("should filter by time and types and follow pages", func() {
+			update := types.CfEventResource{Meta: types.CfMeta{GUID: "update"}, Entity: types.CfEvent{
+				Type: types.EventAppUpdate, ActorName: "admin", Timestamp: since.Add(time.Second)}}
+			httpmock.RegisterResponder("GET", "/v2/events?q=actee%3Aguid&q=timestamp%3E2016-03-01T12%3A00%3A00Z"+
+				"&q=type+IN+app.crash%2Caudit.app.update&order-direction=asc&results-per-page=100",
+				responderGenerator(200, types.CfEventsResponse{Count: 2, Pages: 2, NextURL: "/v2/events?page=2",
+					Resources: []types.CfEventResource{update}}))
+			httpmock.RegisterResponder("GET", "/v2/events?page=2", responderGenerator(200, types.CfEventsResponse{
+				Count: 2, Pages: 2, Resources: []types.CfEventResource{crash("crash", 0, 1, "failed")}}))
+
+			events, err := sut.GetAppEvents("guid", since, types.EventAppCrash, types.EventAppUpdate)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Entity.ActorName).To(Equal("admin"))
+			Expect(events[1].Meta.GUID).To(Equal("crash"))
+		})
+
+		It("should request all events of the app", func() {
+			httpmock.RegisterResponder("GET", "/v2/events?q=actee%3Aguid&order-direction=asc&results-per-page=100",
+				responderGenerator(200, types.CfEventsResponse{Resources: []types.CfEventResource{}}))
+
+			events, err := sut.GetAppEvents("guid", time.Time{})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+		})
+
+		It("should return error when events cannot be fetched", func() {
+			httpmock.RegisterResponder("GET", "/v2/events?q=actee%3Aguid&q=type%3Aapp.crash&order-direction=asc&results-per-page=100",
+				responderGenerator(500, nil))
+
+			_, err := sut.GetAppEvents("guid", time.Time{}, types.EventAppCrash)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("should summarize crash events only", func() {
+		events := []types.CfEventResource{
+			crash("first", 2, 137, "out of memory"),
+			{Entity: types.CfEvent{Type: types.EventAppUpdate}},
+			crash("second", 0, 255, "app instance exited"),
+		}
+
+		summaries := SummarizeCrashes(events)
+
+		Expect(summaries).To(HaveLen(2))
+		Expect(summaries[0]).To(Equal(CrashSummary{Timestamp: since.Add(time.Minute), Index: 2, ExitStatus: 137,
+			ExitDescription: "out of memory", Reason: "CRASHED"}))
+		Expect(summaries[1].String()).To(Equal("2016-03-01T12:01:00Z crash of instance 0: exit status 255, app instance exited (CRASHED)"))
+	})
+})
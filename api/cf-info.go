@@ -0,0 +1,191 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Minimal CC API versions introducing optional features
+const (
+	MinVersionRouteServices   = "2.51.0"
+	MinVersionAsyncBindings   = "2.98.0"
+	MinVersionTasks           = "3.0.0"  // v3 API version
+	MinVersionSharedInstances = "3.36.0" // v3 API version
+)
+
+// Capabilities describes optional CC API features available on the foundation
+type Capabilities struct {
+	APIVersion      string
+	V3Version       string
+	RouteServices   bool
+	AsyncBindings   bool
+	V3              bool
+	Tasks           bool
+	SharedInstances bool
+}
+
+// GetInfo returns /v2/info of the foundation. It is requested once and cached afterwards.
+func (c *CfAPI) GetInfo() (*types.CfInfo, error) {
+	c.infoMutex.Lock()
+	defer c.infoMutex.Unlock()
+	return c.getInfo()
+}
+
+func (c *CfAPI) getInfo() (*types.CfInfo, error) {
+	if c.info != nil {
+		return c.info, nil
+	}
+	response, err := c.getEntity(c.BaseAddress+"/v2/info", "info")
+	if err != nil {
+		return nil, err
+	}
+	info := new(types.CfInfo)
+	if err := decodeResponse(response, info, "api_version"); err != nil {
+		return nil, err
+	}
+	log.Debugf("CC API version: [%v]", info.APIVersion)
+	c.info = info
+	return info, nil
+}
+
+// GetCapabilities determines features available on the foundation from its API versions.
+// The result is cached, so CC is asked only once.
+func (c *CfAPI) GetCapabilities() (*Capabilities, error) {
+	c.infoMutex.Lock()
+	defer c.infoMutex.Unlock()
+	if c.capabilities != nil {
+		return c.capabilities, nil
+	}
+
+	info, err := c.getInfo()
+	if err != nil {
+		return nil, err
+	}
+	capabilities := &Capabilities{
+		APIVersion:    info.APIVersion,
+		RouteServices: versionAtLeast(info.APIVersion, MinVersionRouteServices),
+		AsyncBindings: versionAtLeast(info.APIVersion, MinVersionAsyncBindings),
+	}
+	capabilities.V3Version = c.getV3Version()
+	capabilities.V3 = capabilities.V3Version != ""
+	capabilities.Tasks = capabilities.V3 && versionAtLeast(capabilities.V3Version, MinVersionTasks)
+	capabilities.SharedInstances = capabilities.V3 && versionAtLeast(capabilities.V3Version, MinVersionSharedInstances)
+	log.Debugf("Foundation capabilities: [%+v]", *capabilities)
+
+	c.capabilities = capabilities
+	return capabilities, nil
+}
+
+// getV3Version reads v3 API version from root endpoint links. Foundations without v3 API return empty string.
+func (c *CfAPI) getV3Version() string {
+	response, err := c.Get(c.BaseAddress + "/")
+	if err != nil {
+		log.Warnf("Could not get CC root endpoint: [%v]", err)
+		return ""
+	}
+	if response.StatusCode != http.StatusOK {
+		log.Debugf("CC root endpoint not available (%d), assuming no v3 API", response.StatusCode)
+		return ""
+	}
+	root := new(types.CfRootLinks)
+	if err := decodeResponse(response, root); err != nil {
+		return ""
+	}
+	return root.Links["cloud_controller_v3"].Meta.Version
+}
+
+func (c *CfAPI) requireFeature(feature string, available func(*Capabilities) bool, requiredVersion string) error {
+	capabilities, err := c.GetCapabilities()
+	if err != nil {
+		return err
+	}
+	if !available(capabilities) {
+		err := &types.ErrUnsupportedByFoundation{
+			Feature:         feature,
+			APIVersion:      capabilities.APIVersion,
+			RequiredVersion: requiredVersion,
+		}
+		log.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// ShareServiceInstance shares service instance with other spaces. It requires v3 API with service sharing.
+func (c *CfAPI) ShareServiceInstance(instanceGUID string, spaceGUIDs []string) error {
+	err := c.requireFeature("Service instance sharing",
+		func(cap *Capabilities) bool { return cap.SharedInstances }, "v3 "+MinVersionSharedInstances)
+	if err != nil {
+		return err
+	}
+
+	address := fmt.Sprintf("%v/v3/service_instances/%v/relationships/shared_spaces", c.BaseAddress, instanceGUID)
+	log.Infof("Sharing service instance: %v %v", address, spaceGUIDs)
+	request := types.CfToManyRelationship{Data: []types.CfRelationship{}}
+	for _, guid := range spaceGUIDs {
+		request.Data = append(request.Data, types.CfRelationship{GUID: guid})
+	}
+	serialized, _ := json.Marshal(request)
+
+	response, err := c.Post(address, "application/json", bytes.NewReader(serialized))
+	if err != nil {
+		msg := fmt.Sprintf("Failed to share service instance: %v", err.Error())
+		log.Error(msg)
+		return errors.Annotate(types.InternalServerError, msg)
+	}
+	if response.StatusCode != http.StatusOK {
+		msg := fmt.Sprintf("Failed to share service instance: Status code %d, Error %v", response.StatusCode,
+			helpers.ReaderToString(response.Body))
+		log.Error(msg)
+		return errors.Annotate(types.InternalServerError, msg)
+	}
+	return nil
+}
+
+// versionAtLeast compares dot separated numeric versions, e.g. "2.51.0"
+func versionAtLeast(version, minimal string) bool {
+	actual, required := parseVersion(version), parseVersion(minimal)
+	for i := 0; i < len(required); i++ {
+		var part int
+		if i < len(actual) {
+			part = actual[i]
+		}
+		if part != required[i] {
+			return part > required[i]
+		}
+	}
+	return true
+}
+
+func parseVersion(version string) []int {
+	toReturn := []int{}
+	for _, part := range strings.Split(version, ".") {
+		number, _ := strconv.Atoi(part)
+		toReturn = append(toReturn, number)
+	}
+	return toReturn
+}
@@ -0,0 +1,194 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+)
+
+var _ = Describe("Cf info", func() {
+
+	var (
+		sut       *CfAPI
+		infoCalls int
+	)
+
+	infoResponder := func(apiVersion string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			infoCalls++
+			return httpmock.NewJsonResponse(200, types.CfInfo{APIVersion: apiVersion})
+		}
+	}
+
+	rootResponder := func(v3Version string) httpmock.Responder {
+		root := types.CfRootLinks{Links: map[string]types.CfRootLink{}}
+		if v3Version != "" {
+			link := types.CfRootLink{Href: "https://api.example.com/v3"}
+			link.Meta.Version = v3Version
+			root.Links["cloud_controller_v3"] = link
+		}
+		return responderGenerator(200, root)
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		infoCalls = 0
+		sut = &CfAPI{Client: http.DefaultClient}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("get info", func() {
+		It("should ask CC only once", func() {
+			httpmock.RegisterResponder("GET", "/v2/info", infoResponder("2.54.0"))
+
+			first, err := sut.GetInfo()
+			Expect(err).NotTo(HaveOccurred())
+			second, err := sut.GetInfo()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first.APIVersion).To(Equal("2.54.0"))
+			Expect(second).To(Equal(first))
+			Expect(infoCalls).To(Equal(1))
+		})
+
+		It("should return error when api version is missing", func() {
+			httpmock.RegisterResponder("GET", "/v2/info", responderGenerator(200, map[string]string{}))
+
+			info, err := sut.GetInfo()
+
+			Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+			Expect(info).To(BeNil())
+		})
+
+		It("should return error when request fails", func() {
+			httpmock.RegisterResponder("GET", "/v2/info", responderFailGenerator(nil))
+
+			info, err := sut.GetInfo()
+
+			Expect(err).To(HaveOccurred())
+			Expect(info).To(BeNil())
+		})
+	})
+
+	Describe("get capabilities", func() {
+		Context("on old foundation without v3 API", func() {
+			It("should report no optional features", func() {
+				httpmock.RegisterResponder("GET", "/v2/info", infoResponder("2.22.0"))
+				httpmock.RegisterResponder("GET", "/", responderGenerator(404, nil))
+
+				capabilities, err := sut.GetCapabilities()
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*capabilities).To(Equal(Capabilities{APIVersion: "2.22.0"}))
+			})
+		})
+		Context("on recent foundation", func() {
+			It("should report all features", func() {
+				httpmock.RegisterResponder("GET", "/v2/info", infoResponder("2.120.0"))
+				httpmock.RegisterResponder("GET", "/", rootResponder("3.55.0"))
+
+				capabilities, err := sut.GetCapabilities()
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(capabilities.RouteServices).To(BeTrue())
+				Expect(capabilities.AsyncBindings).To(BeTrue())
+				Expect(capabilities.V3).To(BeTrue())
+				Expect(capabilities.SharedInstances).To(BeTrue())
+			})
+		})
+		Context("on foundation with early v3 API", func() {
+			It("should not report service sharing", func() {
+				httpmock.RegisterResponder("GET", "/v2/info", infoResponder("2.75.0"))
+				httpmock.RegisterResponder("GET", "/", rootResponder("3.10.0"))
+
+				capabilities, err := sut.GetCapabilities()
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(capabilities.RouteServices).To(BeTrue())
+				Expect(capabilities.AsyncBindings).To(BeFalse())
+				Expect(capabilities.V3).To(BeTrue())
+				Expect(capabilities.SharedInstances).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("share service instance", func() {
+		Context("when foundation does not support sharing", func() {
+			It("should fail without calling v3 API", func() {
+				httpmock.RegisterResponder("GET", "/v2/info", infoResponder("2.75.0"))
+				httpmock.RegisterResponder("GET", "/", responderGenerator(404, nil))
+
+				err := sut.ShareServiceInstance("guid", []string{"space"})
+
+				Expect(err).To(BeAssignableToTypeOf(&types.ErrUnsupportedByFoundation{}))
+			})
+		})
+		Context("when foundation supports sharing", func() {
+			BeforeEach(func() {
+				httpmock.RegisterResponder("GET", "/v2/info", infoResponder("2.120.0"))
+				httpmock.RegisterResponder("GET", "/", rootResponder("3.55.0"))
+			})
+
+			It("should not return error", func() {
+				httpmock.RegisterResponder("POST", "/v3/service_instances/guid/relationships/shared_spaces",
+					responderGenerator(200, nil))
+
+				err := sut.ShareServiceInstance("guid", []string{"space"})
+
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should return error when CC rejects request", func() {
+				httpmock.RegisterResponder("POST", "/v3/service_instances/guid/relationships/shared_spaces",
+					responderGenerator(422, nil))
+
+				err := sut.ShareServiceInstance("guid", []string{"space"})
+
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("user provided route service", func() {
+		It("should fail early on foundation without route services", func() {
+			httpmock.RegisterResponder("GET", "/v2/info", infoResponder("2.43.0"))
+			httpmock.RegisterResponder("GET", "/", responderGenerator(404, nil))
+
+			result, err := sut.CreateUserProvidedServiceInstance(&types.CfUserProvidedService{
+				Name: "ups", RouteServiceURL: "https://route-service.example.com"})
+
+			Expect(err).To(BeAssignableToTypeOf(&types.ErrUnsupportedByFoundation{}))
+			Expect(result).To(BeNil())
+		})
+	})
+
+	Describe("version comparison", func() {
+		It("should compare numerically", func() {
+			Expect(versionAtLeast("2.100.0", "2.98.0")).To(BeTrue())
+			Expect(versionAtLeast("2.98.0", "2.98.0")).To(BeTrue())
+			Expect(versionAtLeast("2.9.0", "2.51.0")).To(BeFalse())
+			Expect(versionAtLeast("3", "2.51.0")).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,72 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+)
+
+func (c *CfAPI) GetJob(guid string) (*types.CfJobResponse, error) {
+	address := fmt.Sprintf("%v/v2/jobs/%v", c.BaseAddress, guid)
+	toReturn := new(types.CfJobResponse)
+	if err := c.getAndDecode(address, "job", toReturn, "entity.status"); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+// WaitForJob polls job until it is finished. Failed job results in CcJobFailedError
+// annotated with the error reported by CC.
+func (c *CfAPI) WaitForJob(job *types.CfJobResponse, options WaitOptions) error {
+	address := c.BaseAddress + job.Meta.URL
+	if job.Meta.URL == "" {
+		address = fmt.Sprintf("%v/v2/jobs/%v", c.BaseAddress, job.Meta.GUID)
+	}
+	current := job
+	fetched := false
+	return pollUntil(options, func() string {
+		return fmt.Sprintf("job %v, status %v", job.Meta.GUID, current.Entity.Status)
+	}, func() (bool, error) {
+		// job returned by the triggering request is checked before polling
+		if fetched {
+			next := new(types.CfJobResponse)
+			if err := c.getAndDecode(address, "job", next, "entity.status"); err != nil {
+				return false, err
+			}
+			log.Debugf("Job %v check: [%v]", job.Meta.GUID, next.Entity.Status)
+			current = next
+		}
+		fetched = true
+
+		switch current.Entity.Status {
+		case types.JobFinished:
+			log.Debugf("Job %v finished", job.Meta.GUID)
+			return true, nil
+		case types.JobFailed:
+			msg := fmt.Sprintf("Job %v failed: %v", job.Meta.GUID, current.Entity.Error)
+			if details := current.Entity.ErrorDetails; details != nil {
+				msg = fmt.Sprintf("%v (%v: %v)", msg, details.ErrorCode, details.Description)
+			}
+			log.Error(msg)
+			return false, errors.Annotate(types.CcJobFailedError, msg)
+		}
+		return false, nil
+	})
+}
@@ -0,0 +1,89 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("Cf jobs", func() {
+
+	var (
+		sut     CfAPI
+		options WaitOptions
+		job     types.CfJobResponse
+	)
+
+	jobWithStatus := func(status string) types.CfJobResponse {
+		toReturn := job
+		toReturn.Entity.Status = status
+		return toReturn
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		options = WaitOptions{Timeout: 200 * time.Millisecond, PollInterval: time.Millisecond}
+		job = types.CfJobResponse{Meta: types.CfMeta{GUID: "job"}, Entity: types.CfJob{GUID: "job", Status: types.JobQueued}}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should get job", func() {
+		httpmock.RegisterResponder("GET", "/v2/jobs/job", responderGenerator(200, jobWithStatus(types.JobRunning)))
+
+		result, err := sut.GetJob("job")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Entity.Status).To(Equal(types.JobRunning))
+	})
+
+	It("should not poll finished job", func() {
+		Expect(sut.WaitForJob(&types.CfJobResponse{Entity: types.CfJob{Status: types.JobFinished}}, options)).To(Succeed())
+	})
+
+	It("should poll by GUID until job is finished", func() {
+		httpmock.RegisterResponder("GET", "/v2/jobs/job", sequenceResponder(
+			responderGenerator(200, jobWithStatus(types.JobRunning)),
+			responderGenerator(200, jobWithStatus(types.JobFinished))))
+
+		Expect(sut.WaitForJob(&job, options)).To(Succeed())
+	})
+
+	It("should time out when job stays queued", func() {
+		httpmock.RegisterResponder("GET", "/v2/jobs/job", responderGenerator(200, job))
+
+		err := sut.WaitForJob(&job, options)
+
+		Expect(errors.Cause(err)).To(Equal(types.TimeoutOccurredError))
+		Expect(errors.Details(err)).To(ContainSubstring("job job, status queued"))
+	})
+
+	It("should return error when job cannot be fetched", func() {
+		httpmock.RegisterResponder("GET", "/v2/jobs/job", responderGenerator(404, nil))
+
+		Expect(sut.WaitForJob(&job, options)).To(Equal(types.EntityNotFoundError))
+	})
+})
@@ -0,0 +1,83 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/trustedanalytics/go-cf-lib/types"
+)
+
+func (c *CfAPI) GetSpace(guid string) (*types.CfSpaceResource, error) {
+	address := fmt.Sprintf("%v/v2/spaces/%v", c.BaseAddress, guid)
+	toReturn := new(types.CfSpaceResource)
+	if err := c.getAndDecode(address, "space", toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
+	log.Debugf("Space retrieved: [%v]", toReturn.Entity.Name)
+	return toReturn, nil
+}
+
+// GetSpaceSummary returns space with its apps, including their state, instances and memory
+func (c *CfAPI) GetSpaceSummary(guid string) (*types.CfSpaceSummary, error) {
+	address := fmt.Sprintf("%v/v2/spaces/%v/summary", c.BaseAddress, guid)
+	toReturn := new(types.CfSpaceSummary)
+	if err := c.getAndDecode(address, "space summary", toReturn, "guid"); err != nil {
+		return nil, err
+	}
+	log.Debugf("Space summary retrieved: %d apps", len(toReturn.Apps))
+	return toReturn, nil
+}
+
+func (c *CfAPI) GetOrganization(guid string) (*types.CfOrgResource, error) {
+	address := fmt.Sprintf("%v/v2/organizations/%v", c.BaseAddress, guid)
+	toReturn := new(types.CfOrgResource)
+	if err := c.getAndDecode(address, "organization", toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
+	log.Debugf("Organization retrieved: [%v]", toReturn.Entity.Name)
+	return toReturn, nil
+}
+
+// GetOrganizationMemoryUsage returns memory in MB used by started apps of organization
+func (c *CfAPI) GetOrganizationMemoryUsage(guid string) (int64, error) {
+	address := fmt.Sprintf("%v/v2/organizations/%v/memory_usage", c.BaseAddress, guid)
+	usage := new(types.CfOrgMemoryUsage)
+	if err := c.getAndDecode(address, "organization memory usage", usage, "memory_usage_in_mb"); err != nil {
+		return 0, err
+	}
+	return usage.MemoryUsageInMB, nil
+}
+
+func (c *CfAPI) GetQuotaDefinition(guid string) (*types.CfQuotaDefinitionResource, error) {
+	address := fmt.Sprintf("%v/v2/quota_definitions/%v", c.BaseAddress, guid)
+	return c.getQuotaDefinition(address, "quota definition")
+}
+
+func (c *CfAPI) GetSpaceQuotaDefinition(guid string) (*types.CfQuotaDefinitionResource, error) {
+	address := fmt.Sprintf("%v/v2/space_quota_definitions/%v", c.BaseAddress, guid)
+	return c.getQuotaDefinition(address, "space quota definition")
+}
+
+func (c *CfAPI) getQuotaDefinition(address, entityName string) (*types.CfQuotaDefinitionResource, error) {
+	toReturn := new(types.CfQuotaDefinitionResource)
+	if err := c.getAndDecode(address, entityName, toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
+	log.Debugf("%v retrieved: [%v]", entityName, toReturn.Entity.Name)
+	return toReturn, nil
+}
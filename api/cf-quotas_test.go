@@ -0,0 +1,76 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+)
+
+var _ = Describe("Cf quotas", func() {
+
+	var sut CfAPI
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should get space quota definition", func() {
+		quota := types.CfQuotaDefinitionResource{Meta: types.CfMeta{GUID: "quota"},
+			Entity: types.CfQuotaDefinition{Name: "small", MemoryLimit: 1024, InstanceMemoryLimit: types.QuotaUnlimited}}
+		httpmock.RegisterResponder("GET", "/v2/space_quota_definitions/quota", responderGenerator(200, quota))
+
+		result, err := sut.GetSpaceQuotaDefinition("quota")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Entity).To(Equal(quota.Entity))
+	})
+
+	It("should return not found for missing organization", func() {
+		httpmock.RegisterResponder("GET", "/v2/organizations/org", responderGenerator(404, nil))
+
+		_, err := sut.GetOrganization("org")
+
+		Expect(err).To(Equal(types.EntityNotFoundError))
+	})
+
+	It("should accept zero organization memory usage", func() {
+		httpmock.RegisterResponder("GET", "/v2/organizations/org/memory_usage",
+			responderGenerator(200, types.CfOrgMemoryUsage{MemoryUsageInMB: 0}))
+
+		usage, err := sut.GetOrganizationMemoryUsage("org")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(usage).To(BeZero())
+	})
+
+	It("should refuse space summary without guid", func() {
+		httpmock.RegisterResponder("GET", "/v2/spaces/space/summary", responderGenerator(200, map[string]string{}))
+
+		_, err := sut.GetSpaceSummary("space")
+
+		Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+	})
+})
@@ -0,0 +1,126 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io"
+	"net/http"
+	"os"
+)
+
+// resourceMatchBatchSize limits resources sent in single resource_match request, as cf CLI does
+const resourceMatchBatchSize = 1000
+
+// MatchResources returns those of resources which CC already has in its resource pool.
+// Only SHA1 and size are taken into account.
+func (c *CfAPI) MatchResources(resources []types.CfResource) ([]types.CfResource, error) {
+	matched := []types.CfResource{}
+	for start := 0; start < len(resources); start += resourceMatchBatchSize {
+		end := start + resourceMatchBatchSize
+		if end > len(resources) {
+			end = len(resources)
+		}
+		batch, err := c.matchResourcesBatch(resources[start:end])
+		if err != nil {
+			return nil, err
+		}
+		matched = append(matched, batch...)
+	}
+	log.Debugf("%d of %d resources matched", len(matched), len(resources))
+	return matched, nil
+}
+
+func (c *CfAPI) matchResourcesBatch(resources []types.CfResource) ([]types.CfResource, error) {
+	address := c.BaseAddress + "/v2/resource_match"
+	fingerprints := make([]types.CfResource, len(resources))
+	for i, resource := range resources {
+		fingerprints[i] = types.CfResource{SHA1: resource.SHA1, Size: resource.Size}
+	}
+	raw, _ := json.Marshal(fingerprints)
+	request, _ := http.NewRequest("PUT", address, bytes.NewReader(raw))
+	resp, err := c.Do(request)
+	if err != nil {
+		log.Errorf("Could not match resources: [%v]", err)
+		return nil, errors.Wrap(types.CcUploadBitsFailedError, err)
+	} else if resp.StatusCode != http.StatusOK {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("MatchResources finished with error: %v", message)
+		return nil, CreateCcError(message, types.CcUploadBitsFailedError)
+	}
+
+	matched := []types.CfResource{}
+	if err := decodeResponse(resp, &matched); err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// matchAppFiles splits files into those which have to be uploaded and resources
+// matched by CC, which carry path and mode so CC can place them in the package
+func (c *CfAPI) matchAppFiles(files []appFile) ([]appFile, []types.CfResource, error) {
+	resources := make([]types.CfResource, len(files))
+	for i, file := range files {
+		sum, err := fileSHA1(file.fullPath)
+		if err != nil {
+			log.Errorf("Could not compute SHA1 of %v: %v", file.path, err)
+			return nil, nil, errors.Wrap(types.InvalidBitsSourceError, err)
+		}
+		resources[i] = types.CfResource{SHA1: sum, Size: file.size, Path: file.path,
+			Mode: fmt.Sprintf("%o", file.mode.Perm())}
+	}
+	matched, err := c.MatchResources(resources)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known := map[types.CfResource]bool{}
+	for _, resource := range matched {
+		known[types.CfResource{SHA1: resource.SHA1, Size: resource.Size}] = true
+	}
+	toUpload := []appFile{}
+	matchedFiles := []types.CfResource{}
+	for i, file := range files {
+		if known[types.CfResource{SHA1: resources[i].SHA1, Size: resources[i].Size}] {
+			matchedFiles = append(matchedFiles, resources[i])
+		} else {
+			toUpload = append(toUpload, file)
+		}
+	}
+	return toUpload, matchedFiles, nil
+}
+
+func fileSHA1(fullPath string) (string, error) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha1.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
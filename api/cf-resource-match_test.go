@@ -0,0 +1,104 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+)
+
+var _ = Describe("Cf resource match", func() {
+
+	var (
+		sut     CfAPI
+		batches [][]types.CfResource
+	)
+
+	// knownResponder matches resources of even size
+	knownResponder := func(req *http.Request) (*http.Response, error) {
+		batch := []types.CfResource{}
+		json.NewDecoder(req.Body).Decode(&batch)
+		batches = append(batches, batch)
+		matched := []types.CfResource{}
+		for _, resource := range batch {
+			if resource.Size%2 == 0 {
+				matched = append(matched, resource)
+			}
+		}
+		return httpmock.NewJsonResponse(200, matched)
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		batches = nil
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should send fingerprints only and return matched resources", func() {
+		httpmock.RegisterResponder("PUT", "/v2/resource_match", knownResponder)
+
+		matched, err := sut.MatchResources([]types.CfResource{
+			{SHA1: "a", Size: 2, Path: "a.jar", Mode: "644"},
+			{SHA1: "b", Size: 3, Path: "b.jar", Mode: "644"}})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matched).To(Equal([]types.CfResource{{SHA1: "a", Size: 2}}))
+		Expect(batches).To(Equal([][]types.CfResource{{{SHA1: "a", Size: 2}, {SHA1: "b", Size: 3}}}))
+	})
+
+	It("should send resources in batches", func() {
+		httpmock.RegisterResponder("PUT", "/v2/resource_match", knownResponder)
+		resources := []types.CfResource{}
+		for i := 0; i < 2*resourceMatchBatchSize+1; i++ {
+			resources = append(resources, types.CfResource{SHA1: fmt.Sprint(i), Size: int64(i)})
+		}
+
+		matched, err := sut.MatchResources(resources)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matched).To(HaveLen(resourceMatchBatchSize + 1))
+		Expect(batches).To(HaveLen(3))
+		Expect(batches[2]).To(HaveLen(1))
+	})
+
+	It("should return CC error", func() {
+		httpmock.RegisterResponder("PUT", "/v2/resource_match", responderGenerator(400, map[string]interface{}{
+			"code": 1001, "description": "Request invalid due to parse error"}))
+
+		_, err := sut.MatchResources([]types.CfResource{{SHA1: "a", Size: 1}})
+
+		Expect(errors.Cause(err)).To(Equal(types.CcUploadBitsFailedError))
+	})
+
+	It("should return malformed response error for html", func() {
+		httpmock.RegisterResponder("PUT", "/v2/resource_match", htmlResponderGenerator(200, "<html></html>"))
+
+		_, err := sut.MatchResources([]types.CfResource{{SHA1: "a", Size: 1}})
+
+		Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+	})
+})
@@ -0,0 +1,84 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"time"
+)
+
+var _ = Describe("Cf rolling update with fake CC", func() {
+
+	var (
+		fake    *cctest.FakeCC
+		sut     *api.CfAPI
+		appGUID string
+		wait    api.WaitOptions
+	)
+
+	BeforeEach(func() {
+		fake, sut = newFakeCCAPI()
+		appGUID = fake.AddApp(types.CfApp{Name: "web", SpaceGUID: "space", State: types.AppStarted, InstanceCount: 5,
+			Envs: map[string]interface{}{"LEVEL": "info", "OLD": "x"}})
+		wait = api.WaitOptions{Timeout: time.Second, PollInterval: time.Millisecond}
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("should update env and restart instances in batches", func() {
+		report, err := sut.RollingUpdate(api.RollingUpdateRequest{AppGUID: appGUID, BatchSize: 2,
+			SetEnv: map[string]interface{}{"LEVEL": "debug"}, UnsetEnv: []string{"OLD"},
+			Fields: map[string]interface{}{"command": "./run --fast"}, Wait: wait})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Batches).To(Equal([][]int{{0, 1}, {2, 3}, {4}}))
+		Expect(report.Updated).To(BeTrue())
+		Expect(fake.AppInstanceRestarts(appGUID)).To(Equal([]int{0, 1, 2, 3, 4}))
+		app, _ := fake.App(appGUID)
+		Expect(app.Envs).To(Equal(map[string]interface{}{"LEVEL": "debug"}))
+		Expect(app.Command).To(Equal("./run --fast"))
+		Expect(report.String()).To(ContainSubstring("finished: 5 instances restarted in 3 batches"))
+	})
+
+	It("should not touch app which is not healthy", func() {
+		fake.SetAppInstances(appGUID, map[string]types.CfAppInstance{
+			"0": {State: types.InstanceRunning}, "1": {State: types.InstanceCrashed}})
+
+		report, err := sut.RollingUpdate(api.RollingUpdateRequest{AppGUID: appGUID,
+			SetEnv: map[string]interface{}{"LEVEL": "debug"}, Wait: wait})
+
+		Expect(errors.Cause(err)).To(Equal(types.RollingUpdateAbortedError))
+		Expect(report.Updated).To(BeFalse())
+		Expect(fake.AppInstanceRestarts(appGUID)).To(BeEmpty())
+	})
+
+	It("should only update stopped app", func() {
+		stopped := fake.AddApp(types.CfApp{Name: "worker", SpaceGUID: "space", State: types.AppStopped})
+
+		report, err := sut.RollingRestart(stopped, 1, wait)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Batches).To(BeEmpty())
+	})
+})
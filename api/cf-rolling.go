@@ -0,0 +1,229 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"strconv"
+	"strings"
+)
+
+// rollingRejectedFields are app fields which restarting instances does not apply, or which
+// change app version, so CC replaces all instances at once
+var rollingRejectedFields = []string{"state", "instances", "memory", "disk_quota", "buildpack", "stack_guid",
+	"docker_image", "health_check_type", "health_check_http_endpoint", "enable_ssh", "ports"}
+
+// RollingUpdateRequest describes change applied to the app by restarting its instances gradually
+type RollingUpdateRequest struct {
+	AppGUID string
+	// SetEnv and UnsetEnv patch user provided variables, like PatchAppEnv does
+	SetEnv   map[string]interface{}
+	UnsetEnv []string
+	// Fields are other app fields to update, e.g. "command" or "health_check_timeout". Fields
+	// which change app version, like health check type or ports, are rejected, as CC would
+	// replace all instances at once.
+	Fields map[string]interface{}
+	// BatchSize is the number of instances restarted at once. Zero means one by one.
+	BatchSize int
+	// Wait applies to each batch separately
+	Wait WaitOptions
+}
+
+// RollingUpdateReport describes progress of rolling update, also when it was aborted
+type RollingUpdateReport struct {
+	AppGUID string
+	Updated bool
+	// Batches are instance indexes restarted, in order. The last batch of aborted update may not be running.
+	Batches   [][]int
+	Instances *AppInstancesReport
+	Aborted   bool
+}
+
+func (r *RollingUpdateReport) String() string {
+	restarted := 0
+	for _, batch := range r.Batches {
+		restarted += len(batch)
+	}
+	status := "finished"
+	if r.Aborted {
+		status = "aborted"
+	}
+	return fmt.Sprintf("rolling update of app %v %v: %d instances restarted in %d batches",
+		r.AppGUID, status, restarted, len(r.Batches))
+}
+
+// RollingRestart restarts instances of started app in batches, without changing it
+func (c *CfAPI) RollingRestart(appGUID string, batchSize int, options WaitOptions) (*RollingUpdateReport, error) {
+	return c.RollingUpdate(RollingUpdateRequest{AppGUID: appGUID, BatchSize: batchSize, Wait: options})
+}
+
+// RollingUpdate applies env or config change to the app and restarts its instances in batches,
+// waiting for each batch to be running again before the next one. All instances have to be running
+// before the update starts. Crashed or flapping instance aborts the update with RollingUpdateAbortedError,
+// leaving the change applied to instances restarted so far. Stopped app is only updated.
+func (c *CfAPI) RollingUpdate(request RollingUpdateRequest) (*RollingUpdateReport, error) {
+	report := &RollingUpdateReport{AppGUID: request.AppGUID, Batches: [][]int{}}
+	if err := validateRollingUpdate(request); err != nil {
+		return report, err
+	}
+	app, err := c.GetApp(request.AppGUID)
+	if err != nil {
+		return report, err
+	}
+
+	var baseline map[string]types.CfAppInstance
+	if app.Entity.State == types.AppStarted {
+		if baseline, err = c.GetAppInstances(request.AppGUID); err != nil {
+			return report, err
+		}
+		report.Instances = &AppInstancesReport{AppGUID: request.AppGUID, Instances: baseline}
+		if unhealthy := notRunning(baseline); len(unhealthy) > 0 || len(baseline) == 0 {
+			msg := fmt.Sprintf("App %v is not healthy before update, instances not running: %v",
+				request.AppGUID, strings.Join(unhealthy, ","))
+			log.Error(msg)
+			report.Aborted = true
+			return report, errors.Annotate(types.RollingUpdateAbortedError, msg)
+		}
+	}
+
+	fields := map[string]interface{}{}
+	for name, value := range request.Fields {
+		fields[name] = value
+	}
+	if len(request.SetEnv) > 0 || len(request.UnsetEnv) > 0 {
+		fields["environment_json"] = mergeEnv(app.Entity.Envs, request.SetEnv, request.UnsetEnv)
+	}
+	if len(fields) > 0 {
+		if err := c.updateAppFields(request.AppGUID, fields); err != nil {
+			return report, err
+		}
+		report.Updated = true
+	}
+	if app.Entity.State != types.AppStarted {
+		log.Infof("App %v is not started, no instances to restart", request.AppGUID)
+		return report, nil
+	}
+
+	batchSize := request.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	indexes := sortedIndexes(baseline)
+	for start := 0; start < len(indexes); start += batchSize {
+		end := start + batchSize
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		batch := []int{}
+		for _, index := range indexes[start:end] {
+			i, _ := strconv.Atoi(index)
+			batch = append(batch, i)
+		}
+		report.Batches = append(report.Batches, batch)
+		log.Infof("Rolling update of app %v, restarting instances %v", request.AppGUID, batch)
+
+		for _, index := range batch {
+			if err := c.RestartAppInstance(request.AppGUID, index); err != nil {
+				report.Aborted = true
+				return report, err
+			}
+		}
+		instances, err := c.waitForBatch(request.AppGUID, batch, baseline, request.Wait)
+		if instances != nil {
+			report.Instances.Instances = instances
+		}
+		if err != nil {
+			report.Aborted = true
+			log.Errorf("Rolling update of app %v aborted: %v", request.AppGUID, err)
+			return report, err
+		}
+		baseline = instances
+	}
+	log.Info(report.String())
+	return report, nil
+}
+
+// waitForBatch waits until restarted instances run again, which they report with newer since,
+// and every other instance is still running. Crashed or flapping instance is health regression.
+func (c *CfAPI) waitForBatch(appGUID string, batch []int, before map[string]types.CfAppInstance,
+	options WaitOptions) (map[string]types.CfAppInstance, error) {
+
+	var current map[string]types.CfAppInstance
+	err := pollUntil(options, func() string {
+		report := &AppInstancesReport{AppGUID: appGUID, Instances: current}
+		return fmt.Sprintf("restarted instances %v of %v", batch, report)
+	}, func() (bool, error) {
+		instances, pending, err := c.getAppInstances(appGUID)
+		if err != nil || pending != "" {
+			return false, err
+		}
+		current = instances
+		for _, index := range sortedIndexes(instances) {
+			switch instances[index].State {
+			case types.InstanceCrashed, types.InstanceFlapping:
+				msg := fmt.Sprintf("Health of app %v regressed, instance %v is %v", appGUID, index, instances[index].State)
+				if details := instances[index].Details; details != "" {
+					msg += ": " + details
+				}
+				log.Error(msg)
+				return false, errors.Annotate(types.RollingUpdateAbortedError, msg)
+			}
+		}
+		if len(notRunning(instances)) > 0 || len(instances) < len(before) {
+			return false, nil
+		}
+		for _, index := range batch {
+			key := strconv.Itoa(index)
+			if instances[key].Since <= before[key].Since {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	return current, err
+}
+
+func validateRollingUpdate(request RollingUpdateRequest) error {
+	for _, name := range request.UnsetEnv {
+		if _, ok := request.SetEnv[name]; ok {
+			return errors.Annotate(types.InvalidEnvPatchError, fmt.Sprintf("Variable %v can not be both set and unset", name))
+		}
+	}
+	for _, name := range rollingRejectedFields {
+		if _, ok := request.Fields[name]; ok {
+			return errors.Annotate(types.InvalidInputError, fmt.Sprintf("Field %v can not be changed by rolling update", name))
+		}
+	}
+	if _, ok := request.Fields["environment_json"]; ok {
+		return errors.Annotate(types.InvalidInputError, "Environment is changed with SetEnv and UnsetEnv")
+	}
+	return nil
+}
+
+// notRunning returns indexes of instances which are not running
+func notRunning(instances map[string]types.CfAppInstance) []string {
+	toReturn := []string{}
+	for _, index := range sortedIndexes(instances) {
+		if instances[index].State != types.InstanceRunning {
+			toReturn = append(toReturn, index)
+		}
+	}
+	return toReturn
+}
@@ -0,0 +1,123 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("Cf rolling update", func() {
+
+	var (
+		sut      CfAPI
+		options  WaitOptions
+		restarts []string
+	)
+
+	instances := func(states ...interface{}) map[string]types.CfAppInstance {
+		toReturn := map[string]types.CfAppInstance{}
+		for i := 0; i < len(states); i += 2 {
+			toReturn[states[i].(string)] = types.CfAppInstance{State: types.InstanceRunning, Since: states[i+1].(float64)}
+		}
+		return toReturn
+	}
+
+	withState := func(all map[string]types.CfAppInstance, index, state string) map[string]types.CfAppInstance {
+		instance := all[index]
+		instance.State = state
+		all[index] = instance
+		return all
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		options = WaitOptions{Timeout: 200 * time.Millisecond, PollInterval: time.Millisecond}
+		restarts = []string{}
+		httpmock.RegisterResponder("GET", "/v2/apps/app", responderGenerator(200, types.CfAppResource{
+			Meta: types.CfMeta{GUID: "app"}, Entity: types.CfApp{Name: "web", State: types.AppStarted, InstanceCount: 2}}))
+		httpmock.RegisterResponder("PUT", "/v2/apps/app", responderGenerator(201, nil))
+		for _, index := range []string{"0", "1"} {
+			path := "/v2/apps/app/instances/" + index
+			httpmock.RegisterResponder("DELETE", path, func(req *http.Request) (*http.Response, error) {
+				restarts = append(restarts, req.URL.Path)
+				return httpmock.NewStringResponse(204, ""), nil
+			})
+		}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should wait until restarted instance reports newer since", func() {
+		httpmock.RegisterResponder("GET", "/v2/apps/app/instances", sequenceResponder(
+			responderGenerator(200, instances("0", 1.0, "1", 1.0)),
+			// the instance still looks running before it is killed
+			responderGenerator(200, instances("0", 1.0, "1", 1.0)),
+			responderGenerator(200, withState(instances("0", 1.0, "1", 1.0), "0", types.InstanceDown)),
+			responderGenerator(200, instances("0", 2.0, "1", 1.0)),
+			responderGenerator(200, instances("0", 2.0, "1", 3.0))))
+
+		report, err := sut.RollingRestart("app", 1, options)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restarts).To(Equal([]string{"/v2/apps/app/instances/0", "/v2/apps/app/instances/1"}))
+		Expect(report.Aborted).To(BeFalse())
+	})
+
+	It("should abort when restarted instance crashes", func() {
+		httpmock.RegisterResponder("GET", "/v2/apps/app/instances", sequenceResponder(
+			responderGenerator(200, instances("0", 1.0, "1", 1.0)),
+			responderGenerator(200, withState(instances("0", 2.0, "1", 1.0), "0", types.InstanceCrashed))))
+
+		report, err := sut.RollingUpdate(RollingUpdateRequest{AppGUID: "app",
+			SetEnv: map[string]interface{}{"LEVEL": "debug"}, Wait: options})
+
+		Expect(errors.Cause(err)).To(Equal(types.RollingUpdateAbortedError))
+		Expect(errors.Details(err)).To(ContainSubstring("instance 0 is CRASHED"))
+		Expect(report.Aborted).To(BeTrue())
+		Expect(report.Updated).To(BeTrue())
+		Expect(report.Batches).To(Equal([][]int{{0}}))
+		Expect(restarts).To(HaveLen(1))
+	})
+
+	It("should abort when restarted instance does not come back", func() {
+		httpmock.RegisterResponder("GET", "/v2/apps/app/instances", responderGenerator(200, instances("0", 1.0, "1", 1.0)))
+
+		report, err := sut.RollingRestart("app", 2, options)
+
+		Expect(errors.Cause(err)).To(Equal(types.TimeoutOccurredError))
+		Expect(report.Aborted).To(BeTrue())
+		Expect(report.Batches).To(Equal([][]int{{0, 1}}))
+	})
+
+	It("should reject fields which restart does not apply or which replace all instances", func() {
+		for _, field := range []string{"memory", "health_check_type", "health_check_http_endpoint", "enable_ssh", "ports"} {
+			_, err := sut.RollingUpdate(RollingUpdateRequest{AppGUID: "app", Fields: map[string]interface{}{field: "x"}})
+
+			Expect(errors.Cause(err)).To(Equal(types.InvalidInputError), field)
+		}
+		Expect(restarts).To(BeEmpty())
+	})
+})
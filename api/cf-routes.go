@@ -45,7 +45,9 @@ func (c *CfAPI) CreateRoute(req *types.CfCreateRouteRequest) (*types.CfRouteReso
 	}
 
 	toReturn := new(types.CfRouteResource)
-	json.NewDecoder(resp.Body).Decode(toReturn)
+	if err := decodeResponse(resp, toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
 	log.Debugf("CreateRoute status code: [%v]", resp.StatusCode)
 	log.Debugf("CreateRoute returned GUID: [%v]", toReturn.Meta.GUID)
 	return toReturn, nil
@@ -63,6 +65,11 @@ func (c *CfAPI) AssociateRoute(appID string, routeID string) error {
 	}
 
 	log.Debugf("AssociateRoute status code: [%v]", resp.StatusCode)
+	if !IsSuccessStatus(resp.StatusCode) {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("AssociateRoute finished with error: %v", message)
+		return CreateCcError(message, types.InternalServerError)
+	}
 	return nil
 }
 
@@ -84,7 +91,9 @@ func (c *CfAPI) GetAppRoutes(appID string) (*types.CfRoutesResponse, error) {
 	}
 
 	toReturn := new(types.CfRoutesResponse)
-	json.NewDecoder(response.Body).Decode(toReturn)
+	if err := decodeResponse(response, toReturn, "resources"); err != nil {
+		return nil, err
+	}
 	log.Debugf("Get routes status code: [%v]", response.StatusCode)
 	return toReturn, nil
 }
@@ -97,12 +106,31 @@ func (c *CfAPI) GetSpaceRoutesForHostname(spaceGUID, hostname string) (*types.Cf
 	}
 
 	toReturn := new(types.CfRoutesResponse)
-	json.NewDecoder(response.Body).Decode(toReturn)
+	if err := decodeResponse(response, toReturn, "resources"); err != nil {
+		return nil, err
+	}
 	log.Debugf("Get routes status code: [%v]", response.StatusCode)
 	log.Debugf("Retrieved %v route(s)", toReturn.Count)
 	return toReturn, nil
 }
 
+// GetDomainByName returns shared or private domain of given name.
+// types.EntityNotFoundError is returned when there is no such domain.
+func (c *CfAPI) GetDomainByName(name string) (*types.CfDomainResponse, error) {
+	for _, kind := range []string{"shared_domains", "private_domains"} {
+		address := fmt.Sprintf("%v/v2/%v?%v", c.BaseAddress, kind, filtersQuery(map[string]string{"name": name}))
+		domains := new(types.CfDomainsResponse)
+		if err := c.getAndDecode(address, "domains", domains, "resources"); err != nil {
+			return nil, err
+		}
+		if len(domains.Resources) > 0 {
+			return &domains.Resources[0], nil
+		}
+	}
+	log.Infof("Domain %v not found", name)
+	return nil, types.EntityNotFoundError
+}
+
 func (c *CfAPI) GetAppsFromRoute(routeGUID string) (*types.CfAppsResponse, error) {
 	address := fmt.Sprintf("%v/v2/routes/%v/apps", c.BaseAddress, routeGUID)
 	response, err := c.getEntity(address, "apps")
@@ -111,7 +139,9 @@ func (c *CfAPI) GetAppsFromRoute(routeGUID string) (*types.CfAppsResponse, error
 	}
 
 	toReturn := new(types.CfAppsResponse)
-	json.NewDecoder(response.Body).Decode(toReturn)
+	if err := decodeResponse(response, toReturn, "resources"); err != nil {
+		return nil, err
+	}
 	log.Debugf("Get apps status code: [%v]", response.StatusCode)
 	log.Debugf("Retrieved %v app(s)", toReturn.Count)
 	return toReturn, nil
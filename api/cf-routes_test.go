@@ -86,6 +86,16 @@ var _ = Describe("cf routes", func() {
 				Expect(results).To(BeNil())
 			})
 		})
+		Context("when response has no GUID", func() {
+			It("should return malformed response error", func() {
+				httpmock.RegisterResponder("POST", "/v2/routes", responderGenerator(201, types.CfRouteResource{}))
+
+				results, err := sut.CreateRoute(&req)
+
+				Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+				Expect(results).To(BeNil())
+			})
+		})
 	})
 
 	Describe("associate route method", func() {
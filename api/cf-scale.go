@@ -0,0 +1,234 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+)
+
+// ScaleRequest lists app resources to change. Nil fields are left untouched.
+type ScaleRequest struct {
+	Instances *int
+	// Memory and DiskQuota are in MB
+	Memory    *int64
+	DiskQuota *int64
+	// Wait configures waiting for instances of started app
+	Wait WaitOptions
+}
+
+type quotaScope struct {
+	name           string
+	quota          types.CfQuotaDefinition
+	memoryUsage    func() (int64, error)
+	instancesUsage func() (int, error)
+}
+
+// ScaleApp changes instances, memory or disk quota of the app after checking space and
+// organization quotas. When the app is started it waits until requested number of
+// instances is running. Stopped app is only updated and reported without instances.
+func (c *CfAPI) ScaleApp(appGUID string, request ScaleRequest) (*AppInstancesReport, error) {
+	fields, err := request.fields()
+	if err != nil {
+		return nil, err
+	}
+	app, err := c.GetApp(appGUID)
+	if err != nil {
+		return nil, err
+	}
+
+	scaled := app.Entity
+	if request.Instances != nil {
+		scaled.InstanceCount = *request.Instances
+	}
+	if request.Memory != nil {
+		scaled.Memory = *request.Memory
+	}
+	if request.DiskQuota != nil {
+		scaled.DiskQuota = *request.DiskQuota
+	}
+	if err := c.checkQuotas(&app.Entity, &scaled); err != nil {
+		return nil, err
+	}
+
+	log.Infof("Scaling app %v to %d instances, %d MB memory, %d MB disk", appGUID,
+		scaled.InstanceCount, scaled.Memory, scaled.DiskQuota)
+	if err := c.updateAppFields(appGUID, fields); err != nil {
+		return nil, err
+	}
+	if app.Entity.State != types.AppStarted {
+		return &AppInstancesReport{AppGUID: appGUID, Instances: map[string]types.CfAppInstance{}}, nil
+	}
+	return c.waitForAppRunning(appGUID, scaled.InstanceCount, request.Wait)
+}
+
+func (r ScaleRequest) fields() (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if r.Instances != nil {
+		if *r.Instances < 0 {
+			return nil, errors.Annotate(types.InvalidScaleRequestError, "Instances can not be negative")
+		}
+		fields["instances"] = *r.Instances
+	}
+	if r.Memory != nil {
+		if *r.Memory <= 0 {
+			return nil, errors.Annotate(types.InvalidScaleRequestError, "Memory has to be positive")
+		}
+		fields["memory"] = *r.Memory
+	}
+	if r.DiskQuota != nil {
+		if *r.DiskQuota <= 0 {
+			return nil, errors.Annotate(types.InvalidScaleRequestError, "Disk quota has to be positive")
+		}
+		fields["disk_quota"] = *r.DiskQuota
+	}
+	if len(fields) == 0 {
+		return nil, errors.Annotate(types.InvalidScaleRequestError, "Nothing to scale")
+	}
+	return fields, nil
+}
+
+// checkQuotas verifies scaled app fits into quotas of its space and organization.
+// Total memory and instances are checked only for started apps, as stopped ones use none.
+func (c *CfAPI) checkQuotas(current, scaled *types.CfApp) error {
+	var memoryDelta int64
+	var instancesDelta int
+	if current.State == types.AppStarted {
+		memoryDelta = scaled.Memory*int64(scaled.InstanceCount) - current.Memory*int64(current.InstanceCount)
+		instancesDelta = scaled.InstanceCount - current.InstanceCount
+	}
+
+	space, err := c.GetSpace(current.SpaceGUID)
+	if err != nil {
+		return err
+	}
+	scopes := []quotaScope{}
+	if space.Entity.SpaceQuotaGUID != "" {
+		quota, err := c.GetSpaceQuotaDefinition(space.Entity.SpaceQuotaGUID)
+		if err != nil {
+			return err
+		}
+		scopes = append(scopes, quotaScope{
+			name:  "space",
+			quota: quota.Entity,
+			memoryUsage: func() (int64, error) {
+				memory, _, err := c.spaceUsage(current.SpaceGUID)
+				return memory, err
+			},
+			instancesUsage: func() (int, error) {
+				_, instances, err := c.spaceUsage(current.SpaceGUID)
+				return instances, err
+			},
+		})
+	}
+
+	orgGUID := space.Entity.OrgGUID
+	org, err := c.GetOrganization(orgGUID)
+	if err != nil {
+		return err
+	}
+	if org.Entity.QuotaGUID != "" {
+		quota, err := c.GetQuotaDefinition(org.Entity.QuotaGUID)
+		if err != nil {
+			return err
+		}
+		scopes = append(scopes, quotaScope{
+			name:  "organization",
+			quota: quota.Entity,
+			memoryUsage: func() (int64, error) {
+				return c.GetOrganizationMemoryUsage(orgGUID)
+			},
+			instancesUsage: func() (int, error) {
+				return c.orgInstancesUsage(orgGUID)
+			},
+		})
+	}
+
+	for _, scope := range scopes {
+		if err := scope.check(scaled, memoryDelta, instancesDelta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check enforces limits of the quota. Instance memory and app instance limits
+// which are unlimited or unknown to CC are skipped, as is unlimited (-1) memory limit.
+func (s quotaScope) check(scaled *types.CfApp, memoryDelta int64, instancesDelta int) error {
+	if s.quota.InstanceMemoryLimit > 0 && scaled.Memory > s.quota.InstanceMemoryLimit {
+		return quotaExceeded(fmt.Sprintf("%v quota %v allows %d MB per instance, requested %d MB",
+			s.name, s.quota.Name, s.quota.InstanceMemoryLimit, scaled.Memory))
+	}
+	if memoryDelta > 0 && s.quota.MemoryLimit >= 0 {
+		used, err := s.memoryUsage()
+		if err != nil {
+			return err
+		}
+		if used+memoryDelta > s.quota.MemoryLimit {
+			return quotaExceeded(fmt.Sprintf("%v quota %v allows %d MB of memory, %d MB used, %d MB more requested",
+				s.name, s.quota.Name, s.quota.MemoryLimit, used, memoryDelta))
+		}
+	}
+	if instancesDelta > 0 && s.quota.AppInstanceLimit > 0 {
+		used, err := s.instancesUsage()
+		if err != nil {
+			return err
+		}
+		if used+instancesDelta > s.quota.AppInstanceLimit {
+			return quotaExceeded(fmt.Sprintf("%v quota %v allows %d app instances, %d used, %d more requested",
+				s.name, s.quota.Name, s.quota.AppInstanceLimit, used, instancesDelta))
+		}
+	}
+	return nil
+}
+
+func (c *CfAPI) spaceUsage(spaceGUID string) (int64, int, error) {
+	summary, err := c.GetSpaceSummary(spaceGUID)
+	if err != nil {
+		return 0, 0, err
+	}
+	var memory int64
+	var instances int
+	for _, app := range summary.Apps {
+		if app.State == types.AppStarted {
+			memory += app.Memory * int64(app.InstanceCount)
+			instances += app.InstanceCount
+		}
+	}
+	return memory, instances, nil
+}
+
+func (c *CfAPI) orgInstancesUsage(orgGUID string) (int, error) {
+	apps, err := c.ListApps(map[string]string{"organization_guid": orgGUID})
+	if err != nil {
+		return 0, err
+	}
+	instances := 0
+	for _, app := range apps.Resources {
+		if app.Entity.State == types.AppStarted {
+			instances += app.Entity.InstanceCount
+		}
+	}
+	return instances, nil
+}
+
+func quotaExceeded(msg string) error {
+	log.Error(msg)
+	return errors.Annotate(types.QuotaExceededError, msg)
+}
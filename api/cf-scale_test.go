@@ -0,0 +1,231 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("Cf scale", func() {
+
+	var (
+		sut        CfAPI
+		app        types.CfAppResource
+		updateBody string
+		request    ScaleRequest
+	)
+
+	intPtr := func(value int) *int { return &value }
+	int64Ptr := func(value int64) *int64 { return &value }
+	quotaResponder := func(quota types.CfQuotaDefinition) httpmock.Responder {
+		return responderGenerator(200, types.CfQuotaDefinitionResource{Meta: types.CfMeta{GUID: "quota"}, Entity: quota})
+	}
+	unlimited := types.CfQuotaDefinition{Name: "default", MemoryLimit: 10240,
+		InstanceMemoryLimit: types.QuotaUnlimited, AppInstanceLimit: types.QuotaUnlimited}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		app = types.CfAppResource{Meta: types.CfMeta{GUID: "guid"}, Entity: types.CfApp{
+			Name: "app", SpaceGUID: "space", State: types.AppStopped, InstanceCount: 1, Memory: 512, DiskQuota: 1024}}
+		request = ScaleRequest{Wait: WaitOptions{Timeout: 200 * time.Millisecond, PollInterval: time.Millisecond}}
+		updateBody = ""
+
+		httpmock.RegisterResponder("GET", "/v2/apps/guid", func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(200, app)
+		})
+		httpmock.RegisterResponder("PUT", "/v2/apps/guid", func(req *http.Request) (*http.Response, error) {
+			updateBody = helpers.ReaderToString(req.Body)
+			return httpmock.NewJsonResponse(201, app)
+		})
+		httpmock.RegisterResponder("GET", "/v2/spaces/space", responderGenerator(200, types.CfSpaceResource{
+			Meta: types.CfMeta{GUID: "space"}, Entity: types.CfSpace{Name: "space", OrgGUID: "org"}}))
+		httpmock.RegisterResponder("GET", "/v2/organizations/org", responderGenerator(200, types.CfOrgResource{
+			Meta: types.CfMeta{GUID: "org"}, Entity: types.CfOrg{Name: "org", QuotaGUID: "org_quota"}}))
+		httpmock.RegisterResponder("GET", "/v2/quota_definitions/org_quota", quotaResponder(unlimited))
+		httpmock.RegisterResponder("GET", "/v2/organizations/org/memory_usage",
+			responderGenerator(200, types.CfOrgMemoryUsage{MemoryUsageInMB: 512}))
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Context("with invalid request", func() {
+		It("should refuse empty request", func() {
+			_, err := sut.ScaleApp("guid", request)
+
+			Expect(errors.Cause(err)).To(Equal(types.InvalidScaleRequestError))
+		})
+
+		It("should refuse negative instances", func() {
+			request.Instances = intPtr(-1)
+
+			_, err := sut.ScaleApp("guid", request)
+
+			Expect(errors.Cause(err)).To(Equal(types.InvalidScaleRequestError))
+			Expect(updateBody).To(BeEmpty())
+		})
+	})
+
+	Context("with stopped app", func() {
+		It("should send only requested fields and report no instances", func() {
+			request.Memory = int64Ptr(2048)
+			request.DiskQuota = int64Ptr(2048)
+
+			report, err := sut.ScaleApp("guid", request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updateBody).To(MatchJSON(`{"memory": 2048, "disk_quota": 2048}`))
+			Expect(report.Instances).To(BeEmpty())
+		})
+
+		It("should not check total memory of stopped app", func() {
+			request.Instances = intPtr(100)
+
+			_, err := sut.ScaleApp("guid", request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updateBody).To(MatchJSON(`{"instances": 100}`))
+		})
+	})
+
+	Context("with started app", func() {
+		BeforeEach(func() {
+			app.Entity.State = types.AppStarted
+		})
+
+		It("should wait until requested instances are running", func() {
+			request.Instances = intPtr(3)
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", sequenceResponder(
+				responderGenerator(200, map[string]types.CfAppInstance{"0": {State: "RUNNING"}}),
+				responderGenerator(200, map[string]types.CfAppInstance{
+					"0": {State: "RUNNING"}, "1": {State: "STARTING"}, "2": {State: "STARTING"}}),
+				responderGenerator(200, map[string]types.CfAppInstance{
+					"0": {State: "RUNNING"}, "1": {State: "RUNNING"}, "2": {State: "RUNNING"}})))
+
+			report, err := sut.ScaleApp("guid", request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updateBody).To(MatchJSON(`{"instances": 3}`))
+			Expect(report.Instances).To(HaveLen(3))
+		})
+
+		It("should report instances when timeout occurs", func() {
+			request.Instances = intPtr(2)
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", responderGenerator(200,
+				map[string]types.CfAppInstance{"0": {State: "RUNNING"}, "1": {State: "STARTING"}}))
+
+			report, err := sut.ScaleApp("guid", request)
+
+			Expect(errors.Cause(err)).To(Equal(types.TimeoutOccurredError))
+			Expect(report.Instances["1"].State).To(Equal(types.InstanceStarting))
+		})
+
+		It("should refuse memory above organization memory limit", func() {
+			request.Instances = intPtr(21)
+
+			_, err := sut.ScaleApp("guid", request)
+
+			Expect(errors.Cause(err)).To(Equal(types.QuotaExceededError))
+			Expect(errors.Details(err)).To(ContainSubstring("organization quota default"))
+			Expect(updateBody).To(BeEmpty())
+		})
+
+		It("should allow memory under unlimited organization memory limit", func() {
+			limitless := unlimited
+			limitless.MemoryLimit = types.QuotaUnlimited
+			httpmock.RegisterResponder("GET", "/v2/quota_definitions/org_quota", quotaResponder(limitless))
+			httpmock.RegisterResponder("GET", "/v2/apps/guid/instances", responderGenerator(200,
+				map[string]types.CfAppInstance{"0": {State: "RUNNING"}, "1": {State: "RUNNING"}}))
+			request.Instances = intPtr(2)
+			request.Memory = int64Ptr(65536)
+
+			_, err := sut.ScaleApp("guid", request)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updateBody).To(MatchJSON(`{"instances": 2, "memory": 65536}`))
+		})
+
+		It("should refuse instances above organization app instance limit", func() {
+			limited := unlimited
+			limited.AppInstanceLimit = 2
+			httpmock.RegisterResponder("GET", "/v2/quota_definitions/org_quota", quotaResponder(limited))
+			httpmock.RegisterResponder("GET", "/v2/apps?q=organization_guid%3Aorg",
+				responderGenerator(200, types.CfAppsResponse{Count: 1, Resources: []types.CfAppResource{app}}))
+			request.Instances = intPtr(3)
+
+			_, err := sut.ScaleApp("guid", request)
+
+			Expect(errors.Cause(err)).To(Equal(types.QuotaExceededError))
+			Expect(errors.Details(err)).To(ContainSubstring("2 app instances"))
+		})
+
+		Context("in space with quota", func() {
+			BeforeEach(func() {
+				httpmock.RegisterResponder("GET", "/v2/spaces/space", responderGenerator(200, types.CfSpaceResource{
+					Meta:   types.CfMeta{GUID: "space"},
+					Entity: types.CfSpace{Name: "space", OrgGUID: "org", SpaceQuotaGUID: "space_quota"}}))
+				httpmock.RegisterResponder("GET", "/v2/space_quota_definitions/space_quota", quotaResponder(
+					types.CfQuotaDefinition{Name: "small", MemoryLimit: 2048, InstanceMemoryLimit: 1024,
+						AppInstanceLimit: types.QuotaUnlimited}))
+				summary := types.CfSpaceSummary{GUID: "space", Apps: []types.CfAppSummary{
+					{GUID: "guid", CfApp: app.Entity},
+					{GUID: "other", CfApp: types.CfApp{State: types.AppStarted, InstanceCount: 2, Memory: 512}},
+					{GUID: "stopped", CfApp: types.CfApp{State: types.AppStopped, InstanceCount: 4, Memory: 512}}}}
+				summary.Apps[0].State = types.AppStarted
+				httpmock.RegisterResponder("GET", "/v2/spaces/space/summary", responderGenerator(200, summary))
+			})
+
+			It("should refuse memory above instance memory limit", func() {
+				request.Memory = int64Ptr(1536)
+
+				_, err := sut.ScaleApp("guid", request)
+
+				Expect(errors.Cause(err)).To(Equal(types.QuotaExceededError))
+				Expect(errors.Details(err)).To(ContainSubstring("1024 MB per instance"))
+			})
+
+			It("should refuse memory above space memory limit", func() {
+				request.Instances = intPtr(3)
+
+				_, err := sut.ScaleApp("guid", request)
+
+				Expect(errors.Cause(err)).To(Equal(types.QuotaExceededError))
+				Expect(errors.Details(err)).To(ContainSubstring("1536 MB used, 1024 MB more"))
+			})
+
+			It("should allow scaling down", func() {
+				request.Memory = int64Ptr(256)
+				httpmock.RegisterResponder("GET", "/v2/apps/guid/instances",
+					responderGenerator(200, map[string]types.CfAppInstance{"0": {State: "RUNNING"}}))
+
+				_, err := sut.ScaleApp("guid", request)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(updateBody).To(MatchJSON(`{"memory": 256}`))
+			})
+		})
+	})
+})
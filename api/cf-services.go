@@ -47,14 +47,23 @@ func (c *CfAPI) CreateServiceInstance(req *types.CfServiceInstanceCreateRequest)
 	}
 
 	toReturn := new(types.CfServiceInstanceCreateResponse)
-	json.NewDecoder(resp.Body).Decode(toReturn)
+	if err := decodeResponse(resp, toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
 	log.Debugf("createServiceInstance status code: [%v]", resp.StatusCode)
 	log.Debugf("createServiceInstance returned GUID: [%v]", toReturn.Meta.GUID)
 	return toReturn, nil
 }
 
+// CreateServiceBinding binds service instance to the app. Foundations supporting asynchronous
+// bindings are asked for one, which is then waited for with default WaitOptions, so the binding
+// is usable once CreateServiceBinding returns.
 func (c *CfAPI) CreateServiceBinding(req *types.CfServiceBindingCreateRequest) (*types.CfServiceBindingCreateResponse, error) {
 	address := c.BaseAddress + "/v2/service_bindings"
+	async := c.asyncBindingsAvailable()
+	if async {
+		address += "?accepts_incomplete=true"
+	}
 	log.Infof("Requesting service binding creation: %v", address)
 	marshalled, err := json.Marshal(req)
 	if err != nil {
@@ -66,18 +75,65 @@ func (c *CfAPI) CreateServiceBinding(req *types.CfServiceBindingCreateRequest) (
 		log.Errorf("Could not create service binding: [%v]", err)
 		return nil, errors.Annotate(types.InternalServerError, "Cloud Foundry API was not able to create service binding")
 	}
-	if resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusCreated && !(async && resp.StatusCode == http.StatusAccepted) {
 		log.Errorf("createServiceBinding failed. Response from CC: [%v]", helpers.ReaderToString(resp.Body))
 		return nil, errors.Annotate(types.InternalServerError, "Unacceptable response code from Cloud Foundry API after trying to create service binding")
 	}
 
 	toReturn := new(types.CfServiceBindingCreateResponse)
-	json.NewDecoder(resp.Body).Decode(toReturn)
+	if err := decodeResponse(resp, toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
 	log.Debugf("createServiceBinding status code: [%v]", resp.StatusCode)
 	log.Debugf("createServiceBinding returned GUID: [%v]", toReturn.Meta.GUID)
+	if operation := toReturn.Entity.LastOperation; operation != nil && operation.State == types.LastOperationInProgress {
+		return c.WaitForServiceBinding(toReturn.Meta.GUID, WaitOptions{})
+	}
 	return toReturn, nil
 }
 
+// WaitForServiceBinding polls asynchronous binding until broker finishes it.
+// Failed binding results in CcBindingFailedError.
+func (c *CfAPI) WaitForServiceBinding(bindingGUID string, options WaitOptions) (*types.CfServiceBindingCreateResponse, error) {
+	address := fmt.Sprintf("%v/v2/service_bindings/%v", c.BaseAddress, bindingGUID)
+	binding := new(types.CfServiceBindingCreateResponse)
+	err := pollUntil(options, func() string {
+		return "service binding " + bindingGUID
+	}, func() (bool, error) {
+		if err := c.getAndDecode(address, "service binding", binding, "metadata.guid"); err != nil {
+			return false, err
+		}
+		operation := binding.Entity.LastOperation
+		if operation == nil {
+			return true, nil
+		}
+		log.Debugf("Service binding %v last operation: %v", bindingGUID, operation.State)
+		switch operation.State {
+		case types.LastOperationSucceeded:
+			return true, nil
+		case types.LastOperationFailed:
+			msg := fmt.Sprintf("Service binding %v failed: %v", bindingGUID, operation.Description)
+			log.Error(msg)
+			return false, errors.Annotate(types.CcBindingFailedError, msg)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return binding, nil
+}
+
+// asyncBindingsAvailable falls back to synchronous bindings when capabilities are not known
+func (c *CfAPI) asyncBindingsAvailable() bool {
+	capabilities, err := c.GetCapabilities()
+	if err != nil {
+		log.Warnf("Could not determine foundation capabilities, binding synchronously: %v", err)
+		return false
+	}
+	return capabilities.AsyncBindings
+}
+
 func (c *CfAPI) GetServiceBindings(id string) (*types.CfBindingsResources, error) {
 	address := fmt.Sprintf("%v/v2/service_instances/%v/service_bindings", c.BaseAddress, id)
 	response, err := c.getEntity(address, "service bindings")
@@ -86,12 +142,30 @@ func (c *CfAPI) GetServiceBindings(id string) (*types.CfBindingsResources, error
 	}
 
 	toReturn := new(types.CfBindingsResources)
-	json.NewDecoder(response.Body).Decode(toReturn)
+	if err := decodeResponse(response, toReturn, "resources"); err != nil {
+		return nil, err
+	}
 	log.Debugf("Get bindings status code: [%v]", response.StatusCode)
 	log.Debugf("Bindings retrieved. Got %d of %d results", len(toReturn.Resources), toReturn.TotalResults)
 	return toReturn, nil
 }
 
+// GetServiceInstanceByName returns managed or user provided service instance of given name from space.
+// types.EntityNotFoundError is returned when there is no such instance.
+func (c *CfAPI) GetServiceInstanceByName(spaceGUID, name string) (*types.CfServiceInstanceResource, error) {
+	address := fmt.Sprintf("%v/v2/spaces/%v/service_instances?%v&return_user_provided_service_instances=true",
+		c.BaseAddress, spaceGUID, filtersQuery(map[string]string{"name": name}))
+	instances := new(types.CfServiceInstancesResponse)
+	if err := c.getAndDecode(address, "service instances", instances, "resources"); err != nil {
+		return nil, err
+	}
+	if len(instances.Resources) == 0 {
+		log.Infof("Service instance %v not found in space %v", name, spaceGUID)
+		return nil, types.EntityNotFoundError
+	}
+	return &instances.Resources[0], nil
+}
+
 func (c *CfAPI) DeleteServiceInstance(id string) error {
 	address := fmt.Sprintf("%v/v2/service_instances/%v", c.BaseAddress, id)
 	err := c.deleteEntity(address, "service instance")
@@ -116,7 +190,9 @@ func (c *CfAPI) GetServiceOfName(name string) (*types.CfServiceResource, error)
 	}
 
 	resource := new(types.CfServicesResources)
-	json.NewDecoder(resp.Body).Decode(resource)
+	if err := decodeResponse(resp, resource, "resources"); err != nil {
+		return nil, err
+	}
 	if resource.TotalResults > 0 {
 		log.Debugf("Service with name [%v] found", name)
 		return &resource.Resources[0], nil
@@ -133,7 +209,11 @@ func (c *CfAPI) PurgeService(serviceID string, serviceName string, servicePlansU
 		return errors.Annotate(types.InternalServerError, msg)
 	}
 	plans := new(types.CfServicePlansResources)
-	json.NewDecoder(resp.Body).Decode(plans)
+	if IsSuccessStatus(resp.StatusCode) {
+		if err := decodeResponse(resp, plans, "resources"); err != nil {
+			return err
+		}
+	}
 
 	for _, plan := range plans.Resources {
 		address := fmt.Sprintf("%v/v2/service_plans/%v", c.BaseAddress, plan.Meta.GUID)
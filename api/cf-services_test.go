@@ -20,8 +20,10 @@ import (
 	"github.com/jarcoal/httpmock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
 	"github.com/trustedanalytics/go-cf-lib/types"
 	"net/http"
+	"time"
 )
 
 var _ = Describe("cf services", func() {
@@ -100,6 +102,17 @@ var _ = Describe("cf services", func() {
 				Expect(results).To(BeNil())
 			})
 		})
+		Context("when router responds with HTML page", func() {
+			It("should return malformed response error", func() {
+				httpmock.RegisterResponder("POST", "/v2/service_instances?accepts_incomplete=false",
+					htmlResponderGenerator(201, "<html>Bad Gateway</html>"))
+
+				results, err := sut.CreateServiceInstance(&req)
+
+				Expect(err).To(BeAssignableToTypeOf(&types.ErrMalformedResponse{}))
+				Expect(results).To(BeNil())
+			})
+		})
 	})
 
 	Describe("create service binding method", func() {
@@ -123,6 +136,67 @@ var _ = Describe("cf services", func() {
 				Expect(results.Meta.GUID).To(Equal(res.Meta.GUID))
 			})
 		})
+		Context("when foundation supports asynchronous bindings", func() {
+			BeforeEach(func() {
+				httpmock.RegisterResponder("GET", "/v2/info", responderGenerator(200, types.CfInfo{APIVersion: "2.100.0"}))
+				httpmock.RegisterResponder("GET", "/", responderGenerator(404, nil))
+			})
+
+			binding := func(state string) types.CfServiceBindingCreateResponse {
+				toReturn := res
+				toReturn.Entity.LastOperation = &types.CfLastOperation{Type: "create", State: state, Description: "broker says " + state}
+				return toReturn
+			}
+
+			It("should wait until binding in progress succeeds", func() {
+				httpmock.RegisterResponder("POST", "/v2/service_bindings?accepts_incomplete=true",
+					responderGenerator(202, binding(types.LastOperationInProgress)))
+				httpmock.RegisterResponder("GET", "/v2/service_bindings/guid",
+					responderGenerator(200, binding(types.LastOperationSucceeded)))
+
+				results, err := sut.CreateServiceBinding(&req)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(results.Entity.LastOperation.State).To(Equal(types.LastOperationSucceeded))
+			})
+
+			It("should return error when binding in progress fails", func() {
+				httpmock.RegisterResponder("POST", "/v2/service_bindings?accepts_incomplete=true",
+					responderGenerator(202, binding(types.LastOperationInProgress)))
+				httpmock.RegisterResponder("GET", "/v2/service_bindings/guid",
+					responderGenerator(200, binding(types.LastOperationFailed)))
+
+				results, err := sut.CreateServiceBinding(&req)
+
+				Expect(errors.Cause(err)).To(Equal(types.CcBindingFailedError))
+				Expect(errors.Details(err)).To(ContainSubstring("broker says failed"))
+				Expect(results).To(BeNil())
+			})
+
+			It("should poll binding until broker finishes it", func() {
+				httpmock.RegisterResponder("GET", "/v2/service_bindings/guid", sequenceResponder(
+					responderGenerator(200, binding(types.LastOperationInProgress)),
+					responderGenerator(200, binding(types.LastOperationSucceeded))))
+
+				results, err := sut.WaitForServiceBinding("guid", WaitOptions{Timeout: time.Second, PollInterval: time.Millisecond})
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(results.Entity.LastOperation.State).To(Equal(types.LastOperationSucceeded))
+			})
+		})
+		Context("when foundation supports only synchronous bindings", func() {
+			It("should not ask for asynchronous binding", func() {
+				httpmock.RegisterResponder("GET", "/v2/info", responderGenerator(200, types.CfInfo{APIVersion: "2.75.0"}))
+				httpmock.RegisterResponder("GET", "/", responderGenerator(404, nil))
+				httpmock.RegisterResponder("POST", "/v2/service_bindings?accepts_incomplete=true", responderGenerator(202, res))
+				httpmock.RegisterResponder("POST", "/v2/service_bindings", responderGenerator(201, res))
+
+				results, err := sut.CreateServiceBinding(&req)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(results.Meta.GUID).To(Equal("guid"))
+			})
+		})
 		Context("when CF responds with different status code", func() {
 			It("should return error", func() {
 				httpmock.RegisterResponder("POST", "/v2/service_bindings", negativeResponder)
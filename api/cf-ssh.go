@@ -0,0 +1,164 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultSSHOAuthClient is UAA client used for SSH codes when CC does not name one
+const DefaultSSHOAuthClient = "ssh-proxy"
+
+// SSHInfo describes SSH proxy of the foundation
+type SSHInfo struct {
+	// Endpoint is host:port of SSH proxy
+	Endpoint           string
+	HostKeyFingerprint string
+	OAuthClient        string
+}
+
+// GetSSHInfo returns SSH proxy settings from /v2/info. Foundation without SSH proxy
+// results in SSHUnavailableError.
+func (c *CfAPI) GetSSHInfo() (*SSHInfo, error) {
+	info, err := c.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.AppSSHEndpoint == "" {
+		return nil, errors.Annotate(types.SSHUnavailableError, "CC does not expose app_ssh_endpoint")
+	}
+	toReturn := &SSHInfo{
+		Endpoint:           info.AppSSHEndpoint,
+		HostKeyFingerprint: info.AppSSHHostKeyFingerprint,
+		OAuthClient:        info.AppSSHOAuthClient,
+	}
+	if toReturn.OAuthClient == "" {
+		toReturn.OAuthClient = DefaultSSHOAuthClient
+	}
+	return toReturn, nil
+}
+
+// SSHUsername returns user name for SSH session to the app instance, e.g. cf:<guid>/0
+func SSHUsername(appGUID string, index int) string {
+	return fmt.Sprintf("cf:%v/%d", appGUID, index)
+}
+
+// GetSSHCode gets one-time authorization code from UAA, which is the password of SSH session.
+// The code is issued for the token the API uses, so that user needs space developer role.
+// UAA authorizes users only, so CfAPI built by NewCfAPI, which uses client_credentials grant,
+// fails with SSHCodeFailedError. Client shall carry user token, e.g. from oauth2 password grant.
+func (c *CfAPI) GetSSHCode() (string, error) {
+	if c.clientCredentials {
+		return "", errors.Annotate(types.SSHCodeFailedError,
+			"SSH code requires user token, but CC is accessed with client_credentials grant")
+	}
+	sshInfo, err := c.GetSSHInfo()
+	if err != nil {
+		return "", err
+	}
+	info, err := c.GetInfo()
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", sshInfo.OAuthClient)
+	address := fmt.Sprintf("%v/oauth/authorize?%v", strings.TrimSuffix(info.AuthorizationEndpoint, "/"), query.Encode())
+	log.Infof("Requesting SSH code: %v", address)
+
+	// UAA returns the code in redirect to the client, which must not be followed
+	resp, err := c.noRedirectClient().Get(address)
+	if err != nil {
+		log.Errorf("Could not get SSH code: [%v]", err)
+		return "", errors.Wrap(types.SSHCodeFailedError, err)
+	}
+	defer resp.Body.Close()
+	if !isRedirect(resp.StatusCode) {
+		msg := fmt.Sprintf("UAA did not authorize SSH code: (%d) %v", resp.StatusCode, helpers.ReaderToString(resp.Body))
+		log.Error(msg)
+		return "", errors.Annotate(types.SSHCodeFailedError, msg)
+	}
+	location, err := resp.Location()
+	if err != nil {
+		log.Errorf("Invalid UAA redirect: [%v]", err)
+		return "", errors.Wrap(types.SSHCodeFailedError, err)
+	}
+	code := location.Query().Get("code")
+	if code == "" {
+		msg := fmt.Sprintf("UAA redirect does not contain code: %v", location.Query().Get("error"))
+		log.Error(msg)
+		return "", errors.Annotate(types.SSHCodeFailedError, msg)
+	}
+	return code, nil
+}
+
+// SetAppSSH enables or disables SSH access to instances of the app. Running instances are not restarted.
+func (c *CfAPI) SetAppSSH(appGUID string, enabled bool) error {
+	return c.updateAppFields(appGUID, map[string]interface{}{"enable_ssh": enabled})
+}
+
+// SetSpaceSSH allows or forbids SSH access to apps of the space. It requires space manager role.
+func (c *CfAPI) SetSpaceSSH(spaceGUID string, allowed bool) error {
+	address := fmt.Sprintf("%v/v2/spaces/%v", c.BaseAddress, spaceGUID)
+	log.Infof("Updating space SSH setting: %v", address)
+	raw, _ := json.Marshal(map[string]bool{"allow_ssh": allowed})
+	request, _ := http.NewRequest("PUT", address, bytes.NewReader(raw))
+	resp, err := c.Do(request)
+	if err != nil {
+		log.Errorf("Could not update space: [%v]", err)
+		return errors.Wrap(types.InternalServerError, err)
+	} else if resp.StatusCode == http.StatusNotFound {
+		return types.EntityNotFoundError
+	} else if !IsSuccessStatus(resp.StatusCode) {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("Updating space finished with error: %v", message)
+		return CreateCcError(message, types.InternalServerError)
+	}
+	return nil
+}
+
+// CheckAppSSH tells whether SSH session to the app can be opened. SSHUnavailableError
+// is annotated with the reason: missing SSH proxy, or SSH disabled for the space or the app.
+func (c *CfAPI) CheckAppSSH(appGUID string) error {
+	if _, err := c.GetSSHInfo(); err != nil {
+		return err
+	}
+	app, err := c.GetApp(appGUID)
+	if err != nil {
+		return err
+	}
+	space, err := c.GetSpace(app.Entity.SpaceGUID)
+	if err != nil {
+		return err
+	}
+	if space.Entity.AllowSSH != nil && !*space.Entity.AllowSSH {
+		return errors.Annotate(types.SSHUnavailableError, fmt.Sprintf("SSH is disabled for space %v", space.Entity.Name))
+	}
+	if app.Entity.EnableSSH != nil && !*app.Entity.EnableSSH {
+		return errors.Annotate(types.SSHUnavailableError, fmt.Sprintf("SSH is disabled for app %v", app.Entity.Name))
+	}
+	return nil
+}
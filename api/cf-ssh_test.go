@@ -0,0 +1,171 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+)
+
+var _ = Describe("Cf SSH", func() {
+
+	const authorizeURL = "https://login.example.com/oauth/authorize?client_id=ssh-proxy&response_type=code"
+
+	var (
+		sut  CfAPI
+		info types.CfInfo
+	)
+
+	redirectResponder := func(location string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(302, "")
+			resp.Header.Set("Location", location)
+			return resp, nil
+		}
+	}
+
+	boolPtr := func(b bool) *bool {
+		return &b
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		info = types.CfInfo{APIVersion: "2.65.0", AuthorizationEndpoint: "https://login.example.com",
+			AppSSHEndpoint: "ssh.example.com:2222", AppSSHHostKeyFingerprint: "a6:d1:08:0b"}
+		httpmock.RegisterResponder("GET", "/v2/info", func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(200, info)
+		})
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should return SSH proxy settings with default client", func() {
+		result, err := sut.GetSSHInfo()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*result).To(Equal(SSHInfo{Endpoint: "ssh.example.com:2222", HostKeyFingerprint: "a6:d1:08:0b",
+			OAuthClient: DefaultSSHOAuthClient}))
+		Expect(SSHUsername("guid", 1)).To(Equal("cf:guid/1"))
+	})
+
+	It("should fail when foundation has no SSH proxy", func() {
+		info.AppSSHEndpoint = ""
+
+		_, err := sut.GetSSHCode()
+
+		Expect(errors.Cause(err)).To(Equal(types.SSHUnavailableError))
+	})
+
+	Describe("SSH code", func() {
+		It("should read code from UAA redirect without following it", func() {
+			httpmock.RegisterResponder("GET", authorizeURL, redirectResponder("https://uaa.example.com/login?code=abc123"))
+
+			code, err := sut.GetSSHCode()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal("abc123"))
+		})
+
+		It("should use client named by CC", func() {
+			info.AppSSHOAuthClient = "custom-proxy"
+			httpmock.RegisterResponder("GET", "https://login.example.com/oauth/authorize?client_id=custom-proxy&response_type=code",
+				redirectResponder("https://uaa.example.com/login?code=xyz"))
+
+			Expect(sut.GetSSHCode()).To(Equal("xyz"))
+		})
+
+		It("should fail when UAA does not redirect", func() {
+			httpmock.RegisterResponder("GET", authorizeURL, httpmock.NewStringResponder(401, "unauthorized"))
+
+			_, err := sut.GetSSHCode()
+
+			Expect(errors.Cause(err)).To(Equal(types.SSHCodeFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("(401)"))
+		})
+
+		It("should fail when redirect carries error instead of code", func() {
+			httpmock.RegisterResponder("GET", authorizeURL, redirectResponder("https://uaa.example.com/login?error=access_denied"))
+
+			_, err := sut.GetSSHCode()
+
+			Expect(errors.Cause(err)).To(Equal(types.SSHCodeFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("access_denied"))
+		})
+
+		It("should fail without calling UAA when client_credentials grant is used", func() {
+			sut.clientCredentials = true
+			httpmock.RegisterResponder("GET", authorizeURL, redirectResponder("https://uaa.example.com/login?code=abc123"))
+
+			_, err := sut.GetSSHCode()
+
+			Expect(errors.Cause(err)).To(Equal(types.SSHCodeFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("client_credentials"))
+		})
+	})
+
+	Describe("SSH settings", func() {
+		var body string
+
+		recordingResponder := func(req *http.Request) (*http.Response, error) {
+			raw, _ := ioutil.ReadAll(req.Body)
+			body = string(raw)
+			return httpmock.NewStringResponse(201, "{}"), nil
+		}
+
+		It("should disable SSH of the app", func() {
+			httpmock.RegisterResponder("PUT", "/v2/apps/app", recordingResponder)
+
+			Expect(sut.SetAppSSH("app", false)).To(Succeed())
+			Expect(body).To(Equal(`{"enable_ssh":false}`))
+		})
+
+		It("should allow SSH in the space", func() {
+			httpmock.RegisterResponder("PUT", "/v2/spaces/space", recordingResponder)
+
+			Expect(sut.SetSpaceSSH("space", true)).To(Succeed())
+			Expect(body).To(Equal(`{"allow_ssh":true}`))
+		})
+
+		It("should return not found for unknown space", func() {
+			httpmock.RegisterResponder("PUT", "/v2/spaces/space", responderGenerator(404, nil))
+
+			Expect(sut.SetSpaceSSH("space", true)).To(Equal(types.EntityNotFoundError))
+		})
+
+		It("should tell that SSH is disabled for the space", func() {
+			httpmock.RegisterResponder("GET", "/v2/apps/app", responderGenerator(200, types.CfAppResource{
+				Meta:   types.CfMeta{GUID: "app"},
+				Entity: types.CfApp{Name: "web", SpaceGUID: "space", EnableSSH: boolPtr(true)}}))
+			httpmock.RegisterResponder("GET", "/v2/spaces/space", responderGenerator(200, types.CfSpaceResource{
+				Meta:   types.CfMeta{GUID: "space"},
+				Entity: types.CfSpace{Name: "prod", AllowSSH: boolPtr(false)}}))
+
+			err := sut.CheckAppSSH("app")
+
+			Expect(errors.Cause(err)).To(Equal(types.SSHUnavailableError))
+			Expect(errors.Details(err)).To(ContainSubstring("space prod"))
+		})
+	})
+})
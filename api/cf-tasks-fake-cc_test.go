@@ -0,0 +1,89 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"time"
+)
+
+var _ = Describe("Cf tasks with fake CC", func() {
+
+	var (
+		fake       *cctest.FakeCC
+		sut        *api.CfAPI
+		sourceGUID string
+		wait       api.WaitOptions
+	)
+
+	BeforeEach(func() {
+		fake, sut = newFakeCCAPI()
+		fake.SetV3Version("3.10.0")
+		sourceGUID = fake.AddApp(types.CfApp{Name: "source", SpaceGUID: "space", State: types.AppStarted})
+		wait = api.WaitOptions{Timeout: time.Second, PollInterval: time.Millisecond}
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("should run task and report its failure", func() {
+		task, err := sut.RunTask(sourceGUID, "rake db:migrate", 256, 0, "migrate")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(task.State).To(Equal(types.TaskRunning))
+		Expect(task.DiskInMB).To(Equal(1024))
+
+		fake.SetTaskState(task.GUID, types.TaskFailed, "Exited with status 1")
+		result, err := sut.WaitForTask(task.GUID, wait)
+
+		Expect(errors.Cause(err)).To(Equal(types.CcTaskFailedError))
+		Expect(result.Result.FailureReason).To(Equal("Exited with status 1"))
+	})
+
+	It("should list tasks by state and cancel running ones", func() {
+		first, err := sut.RunTask(sourceGUID, "true", 0, 0, "")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := sut.RunTask(sourceGUID, "sleep 60", 0, 0, "")
+		Expect(err).NotTo(HaveOccurred())
+		fake.SetTaskState(first.GUID, types.TaskSucceeded, "")
+
+		running, err := sut.ListTasks(sourceGUID, types.TaskRunning)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(running).To(HaveLen(1))
+		Expect(running[0].GUID).To(Equal(second.GUID))
+
+		_, err = sut.CancelTask(second.GUID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sut.ListTasks(sourceGUID, types.TaskRunning)).To(BeEmpty())
+		_, err = sut.CancelTask(first.GUID)
+		Expect(errors.Details(err)).To(ContainSubstring("cannot be canceled"))
+	})
+
+	It("should reject task of app without droplet", func() {
+		app, err := sut.CreateApp(types.CfApp{Name: "empty", SpaceGUID: "space"})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = sut.RunTask(app.Meta.GUID, "true", 0, 0, "")
+
+		Expect(errors.Details(err)).To(ContainSubstring("Task must have a droplet"))
+	})
+})
@@ -0,0 +1,178 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RunTask runs command as one-off task with droplet of the app. Zero memory and disk
+// mean CC defaults, empty name lets CC generate one.
+func (c *CfAPI) RunTask(appGUID, command string, memoryInMB, diskInMB int, name string) (*types.CfV3Task, error) {
+	if err := c.requireTasks(); err != nil {
+		return nil, err
+	}
+	address := fmt.Sprintf("%v/v3/apps/%v/tasks", c.BaseAddress, appGUID)
+	log.Infof("Running task: %v", address)
+	raw, _ := json.Marshal(types.CfV3TaskCreateRequest{Name: name, Command: command, MemoryInMB: memoryInMB, DiskInMB: diskInMB})
+	return c.taskRequest("POST", address, raw, "run task")
+}
+
+// GetTask returns current state of the task
+func (c *CfAPI) GetTask(taskGUID string) (*types.CfV3Task, error) {
+	address := fmt.Sprintf("%v/v3/tasks/%v", c.BaseAddress, taskGUID)
+	toReturn := new(types.CfV3Task)
+	if err := c.getAndDecode(address, "task", toReturn, "guid", "state"); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+// CancelTask requests cancellation of the task. CC stops it asynchronously,
+// WaitForTask tells when it is done.
+func (c *CfAPI) CancelTask(taskGUID string) (*types.CfV3Task, error) {
+	address := fmt.Sprintf("%v/v3/tasks/%v/actions/cancel", c.BaseAddress, taskGUID)
+	log.Infof("Cancelling task: %v", address)
+	return c.taskRequest("POST", address, nil, "cancel task")
+}
+
+// WaitForTask polls task until it is SUCCEEDED or FAILED and returns it in the final state.
+// Failed task results in CcTaskFailedError annotated with failure reason reported by CC.
+func (c *CfAPI) WaitForTask(taskGUID string, options WaitOptions) (*types.CfV3Task, error) {
+	var current *types.CfV3Task
+	err := pollUntil(options, func() string {
+		state := "unknown"
+		if current != nil {
+			state = current.State
+		}
+		return fmt.Sprintf("task %v, state %v", taskGUID, state)
+	}, func() (bool, error) {
+		task, err := c.GetTask(taskGUID)
+		if err != nil {
+			return false, err
+		}
+		log.Debugf("Task %v check: [%v]", taskGUID, task.State)
+		current = task
+
+		switch task.State {
+		case types.TaskSucceeded:
+			return true, nil
+		case types.TaskFailed:
+			msg := fmt.Sprintf("Task %v (%v) failed: %v", task.Name, taskGUID, task.Result.FailureReason)
+			log.Error(msg)
+			return false, errors.Annotate(types.CcTaskFailedError, msg)
+		}
+		return false, nil
+	})
+	if err != nil && errors.Cause(err) != types.CcTaskFailedError {
+		return nil, err
+	}
+	return current, err
+}
+
+// ListTasks returns tasks of the app, oldest first. Tasks may be filtered by states, e.g. types.TaskRunning.
+func (c *CfAPI) ListTasks(appGUID string, states ...string) ([]types.CfV3Task, error) {
+	if err := c.requireTasks(); err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("order_by", "created_at")
+	query.Set("per_page", "100")
+	if len(states) > 0 {
+		query.Set("states", strings.Join(states, ","))
+	}
+	address := fmt.Sprintf("%v/v3/apps/%v/tasks?%v", c.BaseAddress, appGUID, query.Encode())
+
+	toReturn := []types.CfV3Task{}
+	err := c.forEachPage(address, "tasks", func(response *http.Response) (string, error) {
+		page := new(types.CfV3TasksResponse)
+		if err := decodeResponse(response, page); err != nil {
+			return "", err
+		}
+		toReturn = append(toReturn, page.Resources...)
+		if page.Pagination.Next == nil {
+			return "", nil
+		}
+		// v3 API returns absolute links, which need not start with BaseAddress
+		next, err := url.Parse(page.Pagination.Next.Href)
+		if err != nil {
+			return "", errors.Wrap(types.InternalServerError, err)
+		}
+		return next.RequestURI(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+func (c *CfAPI) requireTasks() error {
+	return c.requireFeature("Tasks", func(cap *Capabilities) bool { return cap.Tasks }, "v3 "+MinVersionTasks)
+}
+
+func (c *CfAPI) taskRequest(method, address string, body []byte, action string) (*types.CfV3Task, error) {
+	request, err := http.NewRequest(method, address, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(types.InternalServerError, err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := c.Do(request)
+	if err != nil {
+		msg := fmt.Sprintf("Could not %v: [%v]", action, err)
+		log.Error(msg)
+		return nil, errors.Annotate(types.InternalServerError, msg)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, types.EntityNotFoundError
+	}
+	if !IsSuccessStatus(resp.StatusCode) {
+		message := helpers.ReaderToString(resp.Body)
+		log.Errorf("Could not %v: (%d) %v", action, resp.StatusCode, message)
+		return nil, createV3CcError(message, types.InternalServerError)
+	}
+	task := new(types.CfV3Task)
+	if err := decodeResponse(resp, task, "guid", "state"); err != nil {
+		return nil, err
+	}
+	log.Debugf("Task %v state: [%v]", task.GUID, task.State)
+	return task, nil
+}
+
+// createV3CcError annotates parentErr with details of v3 API error response
+func createV3CcError(message string, parentErr error) error {
+	response := struct {
+		Errors []struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}{}
+	json.NewDecoder(strings.NewReader(message)).Decode(&response)
+	details := []string{}
+	for _, e := range response.Errors {
+		details = append(details, e.Title+": "+e.Detail)
+	}
+	return errors.Annotate(parentErr, strings.Join(details, "; "))
+}
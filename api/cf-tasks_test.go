@@ -0,0 +1,171 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("Cf tasks", func() {
+
+	var (
+		sut     CfAPI
+		options WaitOptions
+	)
+
+	task := func(state, failureReason string) types.CfV3Task {
+		toReturn := types.CfV3Task{GUID: "task", Name: "migrate", Command: "rake db:migrate", State: state}
+		toReturn.Result.FailureReason = failureReason
+		return toReturn
+	}
+
+	foundation := func(v3Version string) {
+		root := types.CfRootLinks{Links: map[string]types.CfRootLink{}}
+		if v3Version != "" {
+			link := types.CfRootLink{Href: "https://api.example.com/v3"}
+			link.Meta.Version = v3Version
+			root.Links["cloud_controller_v3"] = link
+		}
+		httpmock.RegisterResponder("GET", "/v2/info", responderGenerator(200, types.CfInfo{APIVersion: "2.65.0"}))
+		httpmock.RegisterResponder("GET", "/", responderGenerator(200, root))
+	}
+
+	BeforeEach(func() {
+		httpmock.Activate()
+		sut = CfAPI{Client: http.DefaultClient}
+		options = WaitOptions{Timeout: 200 * time.Millisecond, PollInterval: time.Millisecond}
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	Describe("RunTask", func() {
+		It("should post task with given resources", func() {
+			foundation("3.10.0")
+			var sent map[string]interface{}
+			httpmock.RegisterResponder("POST", "/v3/apps/app/tasks", func(req *http.Request) (*http.Response, error) {
+				body, _ := ioutil.ReadAll(req.Body)
+				json.Unmarshal(body, &sent)
+				return httpmock.NewJsonResponse(202, task(types.TaskRunning, ""))
+			})
+
+			result, err := sut.RunTask("app", "rake db:migrate", 256, 512, "migrate")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.GUID).To(Equal("task"))
+			Expect(sent).To(Equal(map[string]interface{}{"name": "migrate", "command": "rake db:migrate",
+				"memory_in_mb": 256.0, "disk_in_mb": 512.0}))
+		})
+
+		It("should return CC error details", func() {
+			foundation("3.10.0")
+			httpmock.RegisterResponder("POST", "/v3/apps/app/tasks", httpmock.NewStringResponder(422,
+				`{"errors":[{"code":10008,"title":"CF-UnprocessableEntity","detail":"Task must have a droplet"}]}`))
+
+			_, err := sut.RunTask("app", "true", 0, 0, "")
+
+			Expect(errors.Cause(err)).To(Equal(types.InternalServerError))
+			Expect(errors.Details(err)).To(ContainSubstring("Task must have a droplet"))
+		})
+
+		It("should fail on foundation without v3 API", func() {
+			foundation("")
+
+			_, err := sut.RunTask("app", "true", 0, 0, "")
+
+			Expect(err).To(BeAssignableToTypeOf(&types.ErrUnsupportedByFoundation{}))
+		})
+	})
+
+	Describe("WaitForTask", func() {
+		It("should poll until task succeeds", func() {
+			httpmock.RegisterResponder("GET", "/v3/tasks/task", sequenceResponder(
+				responderGenerator(200, task(types.TaskPending, "")),
+				responderGenerator(200, task(types.TaskRunning, "")),
+				responderGenerator(200, task(types.TaskSucceeded, ""))))
+
+			result, err := sut.WaitForTask("task", options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.State).To(Equal(types.TaskSucceeded))
+		})
+
+		It("should return failed task with failure reason", func() {
+			httpmock.RegisterResponder("GET", "/v3/tasks/task",
+				responderGenerator(200, task(types.TaskFailed, "Exited with status 1")))
+
+			result, err := sut.WaitForTask("task", options)
+
+			Expect(errors.Cause(err)).To(Equal(types.CcTaskFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("Exited with status 1"))
+			Expect(result.Result.FailureReason).To(Equal("Exited with status 1"))
+		})
+
+		It("should time out when task keeps running", func() {
+			httpmock.RegisterResponder("GET", "/v3/tasks/task", responderGenerator(200, task(types.TaskRunning, "")))
+
+			result, err := sut.WaitForTask("task", options)
+
+			Expect(errors.Cause(err)).To(Equal(types.TimeoutOccurredError))
+			Expect(errors.Details(err)).To(ContainSubstring("task task, state RUNNING"))
+			Expect(result).To(BeNil())
+		})
+	})
+
+	It("should cancel task", func() {
+		httpmock.RegisterResponder("POST", "/v3/tasks/task/actions/cancel",
+			responderGenerator(202, task(types.TaskCanceling, "")))
+
+		result, err := sut.CancelTask("task")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.State).To(Equal(types.TaskCanceling))
+	})
+
+	It("should return not found when cancelling unknown task", func() {
+		httpmock.RegisterResponder("POST", "/v3/tasks/task/actions/cancel", responderGenerator(404, nil))
+
+		_, err := sut.CancelTask("task")
+
+		Expect(err).To(Equal(types.EntityNotFoundError))
+	})
+
+	It("should list tasks filtered by states following pages", func() {
+		foundation("3.10.0")
+		first := types.CfV3TasksResponse{Resources: []types.CfV3Task{task(types.TaskRunning, "")}}
+		first.Pagination.Next = &types.CfV3Link{Href: "https://api.example.com/v3/apps/app/tasks?page=2"}
+		second := types.CfV3TasksResponse{Resources: []types.CfV3Task{task(types.TaskPending, "")}}
+		httpmock.RegisterResponder("GET", "/v3/apps/app/tasks?order_by=created_at&per_page=100&states=PENDING%2CRUNNING",
+			responderGenerator(200, first))
+		httpmock.RegisterResponder("GET", "/v3/apps/app/tasks?page=2", responderGenerator(200, second))
+
+		result, err := sut.ListTasks("app", types.TaskPending, types.TaskRunning)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(HaveLen(2))
+		Expect(result[1].State).To(Equal(types.TaskPending))
+	})
+})
@@ -0,0 +1,154 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TransportConfig describes how connections to UAA and CloudController are established
+type TransportConfig struct {
+	// CACertsPEM holds additional PEM encoded CA certificates trusted besides system ones
+	CACertsPEM [][]byte
+	// CACertFiles holds paths to files with additional PEM encoded CA certificates
+	CACertFiles []string
+	// SkipSSLValidation disables server certificate verification. Use only for development.
+	SkipSSLValidation bool
+	// ClientCertPEM and ClientKeyPEM hold client certificate used for mutual TLS
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+	// ClientCertFile and ClientKeyFile are paths to client certificate used for mutual TLS
+	ClientCertFile string
+	ClientKeyFile  string
+	// ProxyURL is an explicit proxy used for all requests. When empty proxy ENVs are honoured.
+	ProxyURL string
+	// NoProxy lists hosts (or domain suffixes starting with a dot) reached without a proxy,
+	// either explicit or taken from ENVs
+	NoProxy []string
+}
+
+// NewHTTPTransport builds http.Transport according to provided configuration
+func NewHTTPTransport(config TransportConfig) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := newProxyFunc(config)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Transport{
+		Proxy: proxy,
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     tlsConfig,
+	}, nil
+}
+
+func newTLSConfig(config TransportConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.SkipSSLValidation}
+	if config.SkipSSLValidation {
+		log.Warn("SSL validation of CF endpoints is disabled")
+	}
+
+	caCerts := config.CACertsPEM
+	for _, path := range config.CACertFiles {
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, configError(fmt.Sprintf("Could not read CA certificate file %v: [%v]", path, err))
+		}
+		caCerts = append(caCerts, pem)
+	}
+	if len(caCerts) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			log.Warnf("System certificate pool not available, trusting only provided CAs: [%v]", err)
+			pool = x509.NewCertPool()
+		}
+		for i, pem := range caCerts {
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, configError(fmt.Sprintf("No valid certificate found in CA PEM #%d", i))
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certPEM, keyPEM := config.ClientCertPEM, config.ClientKeyPEM
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		var err error
+		if certPEM, err = ioutil.ReadFile(config.ClientCertFile); err != nil {
+			return nil, configError(fmt.Sprintf("Could not read client certificate file: [%v]", err))
+		}
+		if keyPEM, err = ioutil.ReadFile(config.ClientKeyFile); err != nil {
+			return nil, configError(fmt.Sprintf("Could not read client key file: [%v]", err))
+		}
+	}
+	if len(certPEM) > 0 || len(keyPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, configError(fmt.Sprintf("Invalid client certificate: [%v]", err))
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// proxyFromEnvironment is replaced in tests, as http.ProxyFromEnvironment reads ENVs only once
+var proxyFromEnvironment = http.ProxyFromEnvironment
+
+func newProxyFunc(config TransportConfig) (func(*http.Request) (*url.URL, error), error) {
+	proxy := proxyFromEnvironment
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, configError(fmt.Sprintf("Invalid proxy URL: %v", config.ProxyURL))
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	// NoProxy applies to both explicit proxy and proxy from ENVs
+	return func(req *http.Request) (*url.URL, error) {
+		host := req.URL.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		for _, exempt := range config.NoProxy {
+			if host == exempt || (strings.HasPrefix(exempt, ".") && strings.HasSuffix(host, exempt)) {
+				return nil, nil
+			}
+		}
+		return proxy(req)
+	}, nil
+}
+
+func configError(msg string) error {
+	log.Error(msg)
+	return errors.Annotate(types.InvalidConfigurationError, msg)
+}
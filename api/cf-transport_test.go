@@ -0,0 +1,201 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+)
+
+var _ = Describe("Cf transport", func() {
+
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	serverCAPEM := func() []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	}
+
+	get := func(config TransportConfig) error {
+		transport, err := NewHTTPTransport(config)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	Describe("server verification", func() {
+		Context("when no CA provided", func() {
+			It("should reject untrusted certificate", func() {
+				Expect(get(TransportConfig{})).To(HaveOccurred())
+			})
+		})
+		Context("when CA PEM provided", func() {
+			It("should trust the server", func() {
+				Expect(get(TransportConfig{CACertsPEM: [][]byte{serverCAPEM()}})).NotTo(HaveOccurred())
+			})
+		})
+		Context("when SSL validation is skipped", func() {
+			It("should trust the server", func() {
+				Expect(get(TransportConfig{SkipSSLValidation: true})).NotTo(HaveOccurred())
+			})
+		})
+		Context("when CA PEM is malformed", func() {
+			It("should return error", func() {
+				_, err := NewHTTPTransport(TransportConfig{CACertsPEM: [][]byte{[]byte("garbage")}})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		Context("when CA file does not exist", func() {
+			It("should return error", func() {
+				_, err := NewHTTPTransport(TransportConfig{CACertFiles: []string{"/non/existing.pem"}})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("client certificate", func() {
+		Context("when server requires client certificate", func() {
+			BeforeEach(func() {
+				server.Close()
+				server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+				server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+				server.StartTLS()
+			})
+
+			It("should fail without certificate", func() {
+				Expect(get(TransportConfig{SkipSSLValidation: true})).To(HaveOccurred())
+			})
+
+			It("should succeed with certificate", func() {
+				certPEM, keyPEM := generateCertificate()
+				config := TransportConfig{SkipSSLValidation: true, ClientCertPEM: certPEM, ClientKeyPEM: keyPEM}
+				Expect(get(config)).NotTo(HaveOccurred())
+			})
+		})
+		Context("when key does not match", func() {
+			It("should return error", func() {
+				certPEM, _ := generateCertificate()
+				_, keyPEM := generateCertificate()
+				_, err := NewHTTPTransport(TransportConfig{ClientCertPEM: certPEM, ClientKeyPEM: keyPEM})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("proxy", func() {
+		request := func(address string) *http.Request {
+			req, _ := http.NewRequest(MethodGet, address, nil)
+			return req
+		}
+
+		Context("when proxy URL provided", func() {
+			It("should route requests through proxy", func() {
+				transport, err := NewHTTPTransport(TransportConfig{ProxyURL: "http://proxy:3128"})
+				Expect(err).NotTo(HaveOccurred())
+
+				proxy, err := transport.Proxy(request("https://api.example.com/v2/info"))
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(proxy.String()).To(Equal("http://proxy:3128"))
+			})
+			It("should omit proxy for excluded hosts", func() {
+				config := TransportConfig{ProxyURL: "http://proxy:3128", NoProxy: []string{"uaa.local", ".internal"}}
+				transport, err := NewHTTPTransport(config)
+				Expect(err).NotTo(HaveOccurred())
+
+				direct, _ := transport.Proxy(request("https://uaa.local:8443/oauth/token"))
+				suffix, _ := transport.Proxy(request("https://api.sys.internal/v2/info"))
+
+				Expect(direct).To(BeNil())
+				Expect(suffix).To(BeNil())
+			})
+		})
+		Context("when proxy is taken from ENVs", func() {
+			envProxy, _ := url.Parse("http://env-proxy:3128")
+
+			BeforeEach(func() {
+				proxyFromEnvironment = http.ProxyURL(envProxy)
+			})
+
+			AfterEach(func() {
+				proxyFromEnvironment = http.ProxyFromEnvironment
+			})
+
+			It("should omit proxy for excluded hosts", func() {
+				transport, err := NewHTTPTransport(TransportConfig{NoProxy: []string{"uaa.local", ".internal"}})
+				Expect(err).NotTo(HaveOccurred())
+
+				direct, _ := transport.Proxy(request("https://uaa.local:8443/oauth/token"))
+				suffix, _ := transport.Proxy(request("https://api.sys.internal/v2/info"))
+				proxy, _ := transport.Proxy(request("https://api.example.com/v2/info"))
+
+				Expect(direct).To(BeNil())
+				Expect(suffix).To(BeNil())
+				Expect(proxy).To(Equal(envProxy))
+			})
+		})
+		Context("when proxy URL is invalid", func() {
+			It("should return error", func() {
+				_, err := NewHTTPTransport(TransportConfig{ProxyURL: "::"})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
+
+func generateCertificate() ([]byte, []byte) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-cf-lib"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
@@ -0,0 +1,56 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/url"
+)
+
+const (
+	// DefaultUsageEventsPageSize is the number of app usage events requested at once
+	DefaultUsageEventsPageSize = 100
+	// MaxUsageEventsPageSize is the most app usage events CC returns in one page
+	MaxUsageEventsPageSize = 100
+)
+
+// GetAppUsageEvents returns up to limit app usage events following the event of afterGUID,
+// oldest first. Limit above MaxUsageEventsPageSize is lowered to it. Empty afterGUID starts
+// from the oldest event kept by CC. It requires admin or admin read-only role.
+func (c *CfAPI) GetAppUsageEvents(afterGUID string, limit int) ([]types.CfAppUsageEventResource, error) {
+	if limit <= 0 {
+		limit = DefaultUsageEventsPageSize
+	} else if limit > MaxUsageEventsPageSize {
+		limit = MaxUsageEventsPageSize
+	}
+	query := url.Values{}
+	if afterGUID != "" {
+		query.Set("after_guid", afterGUID)
+	}
+	query.Set("order-direction", "asc")
+	query.Set("results-per-page", fmt.Sprint(limit))
+	address := fmt.Sprintf("%v/v2/app_usage_events?%v", c.BaseAddress, query.Encode())
+
+	page := new(types.CfAppUsageEventsResponse)
+	if err := c.getAndDecode(address, "app usage events", page, "resources"); err != nil {
+		return nil, err
+	}
+	log.Debugf("Retrieved %d app usage event(s) after [%v]", len(page.Resources), afterGUID)
+	return page.Resources, nil
+}
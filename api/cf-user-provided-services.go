@@ -28,6 +28,14 @@ import (
 )
 
 func (c *CfAPI) CreateUserProvidedServiceInstance(req *types.CfUserProvidedService) (*types.CfUserProvidedServiceResource, error) {
+	if req.RouteServiceURL != "" {
+		err := c.requireFeature("Route services",
+			func(cap *Capabilities) bool { return cap.RouteServices }, MinVersionRouteServices)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	address := c.BaseAddress + "/v2/user_provided_service_instances"
 	log.Infof("Requesting user provided service instance creation: %v", address)
 	marshalled, err := json.Marshal(req)
@@ -47,7 +55,9 @@ func (c *CfAPI) CreateUserProvidedServiceInstance(req *types.CfUserProvidedServi
 	}
 
 	toReturn := new(types.CfUserProvidedServiceResource)
-	json.NewDecoder(resp.Body).Decode(toReturn)
+	if err := decodeResponse(resp, toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
 	log.Debugf("createUserProvidedServiceInstance status code: [%v]", resp.StatusCode)
 	log.Debugf("createUserProvidedServiceInstance returned GUID: [%v]", toReturn.Meta.GUID)
 	return toReturn, nil
@@ -62,8 +72,7 @@ func (c *CfAPI) GetUserProvidedService(guid string) (*types.CfUserProvidedServic
 	}
 
 	toReturn := new(types.CfUserProvidedServiceResource)
-	err = json.NewDecoder(resp.Body).Decode(toReturn)
-	if err != nil {
+	if err := decodeResponse(resp, toReturn, "metadata.guid"); err != nil {
 		return nil, err
 	}
 	log.Debugf("User provided service with guid [%v] found", guid)
@@ -89,7 +98,9 @@ func (c *CfAPI) CreateUserProvidedServiceBinding(req *types.CfServiceBindingCrea
 	}
 
 	toReturn := new(types.CfServiceBindingCreateResponse)
-	json.NewDecoder(resp.Body).Decode(toReturn)
+	if err := decodeResponse(resp, toReturn, "metadata.guid"); err != nil {
+		return nil, err
+	}
 	log.Debugf("createServiceBinding status code: [%v]", resp.StatusCode)
 	log.Debugf("createServiceBinding returned GUID: [%v]", toReturn.Meta.GUID)
 	return toReturn, nil
@@ -113,7 +124,9 @@ func (c *CfAPI) GetUserProvidedServiceBindings(id string) (*types.CfBindingsReso
 	}
 
 	toReturn := new(types.CfBindingsResources)
-	json.NewDecoder(response.Body).Decode(toReturn)
+	if err := decodeResponse(response, toReturn, "resources"); err != nil {
+		return nil, err
+	}
 	log.Debugf("Get bindings status code: [%v]", response.StatusCode)
 	log.Debugf("Bindings retrieved. Got %d of %d results", len(toReturn.Resources), toReturn.TotalResults)
 	return toReturn, nil
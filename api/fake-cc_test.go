@@ -0,0 +1,39 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api_test
+
+import (
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+	"net/http"
+)
+
+// Specs in package api_test run api features against cctest.FakeCC, which imports api itself
+
+func newFakeCCAPI() (*cctest.FakeCC, *api.CfAPI) {
+	fake := cctest.NewFakeCC()
+	return fake, &api.CfAPI{BaseAddress: fake.URL, Client: http.DefaultClient}
+}
+
+func routeHosts(fake *cctest.FakeCC, appGUID string) []string {
+	hosts := []string{}
+	for _, routeGUID := range fake.AppRoutes(appGUID) {
+		route, _ := fake.Route(routeGUID)
+		hosts = append(hosts, route.Host)
+	}
+	return hosts
+}
@@ -0,0 +1,101 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cctest provides HTTP transports recording real CloudController
+// interactions to cassettes and replaying them in tests.
+package cctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Cassette is a list of recorded HTTP interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a single request with the response CC returned for it
+type Interaction struct {
+	Request  RecordedRequest  `json:"request" yaml:"request"`
+	Response RecordedResponse `json:"response" yaml:"response"`
+}
+
+type RecordedRequest struct {
+	Method  string              `json:"method" yaml:"method"`
+	URL     string              `json:"url" yaml:"url"`
+	Headers map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string              `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int                 `json:"status_code" yaml:"status_code"`
+	Headers    map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string              `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// RequestURI returns recorded path with query, without scheme and host
+func (r RecordedRequest) RequestURI() string {
+	parsed, err := url.Parse(r.URL)
+	if err != nil {
+		return r.URL
+	}
+	return parsed.RequestURI()
+}
+
+// LoadCassette reads cassette from file. Format is chosen by file extension:
+// .yml and .yaml files are read as YAML, everything else as JSON.
+func LoadCassette(path string) (*Cassette, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := new(Cassette)
+	if isYAML(path) {
+		err = yaml.Unmarshal(raw, cassette)
+	} else {
+		err = json.Unmarshal(raw, cassette)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse cassette %v: %v", path, err)
+	}
+	return cassette, nil
+}
+
+// Save writes cassette to file, in format chosen the same way as in LoadCassette
+func (c *Cassette) Save(path string) error {
+	var raw []byte
+	var err error
+	if isYAML(path) {
+		raw, err = yaml.Marshal(c)
+	} else {
+		raw, err = json.MarshalIndent(c, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, raw, 0644)
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yml" || ext == ".yaml"
+}
@@ -0,0 +1,657 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+func (f *FakeCC) registerAppEndpoints() {
+	f.handle("GET", "/v2/apps", f.listApps)
+	f.handle("POST", "/v2/apps", f.createApp)
+	f.handle("GET", "/v2/apps/:guid", f.getApp)
+	f.handle("PUT", "/v2/apps/:guid", f.updateApp)
+	f.handle("DELETE", "/v2/apps/:guid", f.deleteApp)
+	f.handle("GET", "/v2/apps/:guid/summary", f.getAppSummary)
+	f.handle("GET", "/v2/apps/:guid/instances", f.getAppInstances)
+	f.handle("DELETE", "/v2/apps/:guid/instances/:index", f.restartAppInstance)
+	f.handle("GET", "/v2/apps/:guid/stats", f.getAppStats)
+	f.handle("GET", "/v3/processes/:guid/stats", f.getProcessStats)
+	f.handle("GET", "/v2/apps/:guid/env", f.getAppEnv)
+	f.handle("POST", "/v2/apps/:guid/copy_bits", f.copyBits)
+	f.handle("PUT", "/v2/apps/:guid/bits", f.uploadBits)
+	f.handle("PUT", "/v2/resource_match", f.matchResources)
+	f.handle("POST", "/v2/apps/:guid/restage", f.restageApp)
+	f.handle("GET", "/v2/apps/:guid/service_bindings", f.getAppBindings)
+	f.handle("DELETE", "/v2/apps/:guid/service_bindings/:binding", f.deleteAppBinding)
+	f.handle("GET", "/v2/apps/:guid/routes", f.getAppRoutes)
+	f.handle("PUT", "/v2/apps/:guid/routes/:route", f.associateRoute)
+	f.handle("DELETE", "/v2/apps/:guid/routes/:route", f.unassociateRoute)
+	f.handle("GET", "/v2/spaces/:guid/apps", f.listSpaceApps)
+}
+
+// AddApp stores app in fake CC and returns its GUID. Apps added this way have bits uploaded.
+func (f *FakeCC) AddApp(app types.CfApp) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	guid := newGUID()
+	f.apps[guid] = &fakeApp{entity: f.normalizeApp(guid, app), hasBits: true}
+	return guid
+}
+
+// App returns app of given GUID
+func (f *FakeCC) App(guid string) (types.CfApp, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	app, ok := f.apps[guid]
+	if !ok {
+		return types.CfApp{}, false
+	}
+	return app.entity, true
+}
+
+// AppRoutes returns GUIDs of routes mapped to app
+func (f *FakeCC) AppRoutes(appGUID string) []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	app, ok := f.apps[appGUID]
+	if !ok {
+		return nil
+	}
+	return append([]string{}, app.routes...)
+}
+
+// AppHasBits tells whether bits were uploaded or copied to app
+func (f *FakeCC) AppHasBits(appGUID string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	app, ok := f.apps[appGUID]
+	return ok && app.hasBits
+}
+
+func (f *FakeCC) normalizeApp(guid string, app types.CfApp) types.CfApp {
+	if app.State == "" {
+		app.State = types.AppStopped
+	}
+	if app.InstanceCount == 0 {
+		app.InstanceCount = 1
+	}
+	if app.Memory == 0 {
+		app.Memory = 1024
+	}
+	if app.DiskQuota == 0 {
+		app.DiskQuota = 1024
+	}
+	app.RoutesURL = "/v2/apps/" + guid + "/routes"
+	return app
+}
+
+// appResource renders app with package state derived from its bits, as fake staging is instant
+func (f *FakeCC) appResource(guid string) map[string]interface{} {
+	app := f.apps[guid]
+	entity := app.entity
+	// CC never returns registry credentials
+	entity.DockerCredentials = nil
+	entity.PackageState = types.PackagePending
+	if app.hasBits || entity.IsDocker() {
+		entity.PackageState = types.PackageStaged
+	}
+	return resource(guid, "/v2/apps/"+guid, entity)
+}
+
+func (f *FakeCC) findApp(w http.ResponseWriter, guid string) (*fakeApp, bool) {
+	app, ok := f.apps[guid]
+	if !ok {
+		writeCcError(w, http.StatusNotFound, 100004, "CF-AppNotFound", "The app could not be found: "+guid)
+	}
+	return app, ok
+}
+
+func (f *FakeCC) getApp(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.findApp(w, params["guid"]); !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, f.appResource(params["guid"]))
+}
+
+func (f *FakeCC) listApps(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	spaceGUID, _ := queryFilter(r, "space_guid")
+	f.writeApps(w, r, spaceGUID)
+}
+
+func (f *FakeCC) listSpaceApps(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	f.writeApps(w, r, params["guid"])
+}
+
+func (f *FakeCC) writeApps(w http.ResponseWriter, r *http.Request, spaceGUID string) {
+	name, byName := queryFilter(r, "name")
+	resources := []interface{}{}
+	for guid, app := range f.apps {
+		if (spaceGUID == "" || app.entity.SpaceGUID == spaceGUID) && (!byName || app.entity.Name == name) {
+			resources = append(resources, f.appResource(guid))
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+func (f *FakeCC) createApp(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app := types.CfApp{}
+	if !decodeBody(w, r, &app) {
+		return
+	}
+	if app.Name == "" || app.SpaceGUID == "" {
+		writeCcError(w, http.StatusBadRequest, 1001, "CF-MessageParseError", "Request invalid: name and space_guid are required")
+		return
+	}
+	for _, existing := range f.apps {
+		if existing.entity.Name == app.Name && existing.entity.SpaceGUID == app.SpaceGUID {
+			writeCcError(w, http.StatusBadRequest, 100002, "CF-AppNameTaken", "The app name is taken: "+app.Name)
+			return
+		}
+	}
+	guid := newGUID()
+	f.apps[guid] = &fakeApp{entity: f.normalizeApp(guid, app)}
+	writeJSON(w, http.StatusCreated, f.appResource(guid))
+}
+
+func (f *FakeCC) updateApp(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	fields := map[string]json.RawMessage{}
+	if err == nil {
+		err = json.Unmarshal(body, &fields)
+	}
+	if err != nil {
+		writeCcError(w, http.StatusBadRequest, 1001, "CF-MessageParseError", "Request invalid due to parse error: "+err.Error())
+		return
+	}
+	// environment_json is replaced as a whole, like CC does, instead of being merged by decoder
+	updated := app.entity
+	if _, ok := fields["environment_json"]; ok {
+		updated.Envs = nil
+	}
+	json.Unmarshal(body, &updated)
+	if updated.State == types.AppStarted && !app.hasBits && !updated.IsDocker() {
+		writeCcError(w, http.StatusBadRequest, 150001, "CF-AppPackageInvalid",
+			"The app package is invalid: bits have not been uploaded")
+		return
+	}
+	app.entity = f.normalizeApp(params["guid"], updated)
+	writeJSON(w, http.StatusCreated, f.appResource(params["guid"]))
+}
+
+func (f *FakeCC) deleteApp(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.findApp(w, params["guid"]); !ok {
+		return
+	}
+	for guid, binding := range f.bindings {
+		if binding.AppGUID == params["guid"] {
+			delete(f.bindings, guid)
+		}
+	}
+	delete(f.apps, params["guid"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) getAppSummary(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	entity := app.entity
+	entity.DockerCredentials = nil
+	summary := types.CfAppSummary{
+		CfApp:    entity,
+		GUID:     params["guid"],
+		Routes:   []types.CfAppSummaryRoute{},
+		Services: []types.CfAppSummaryService{},
+	}
+	for _, routeGUID := range app.routes {
+		route := f.routes[routeGUID]
+		summary.Routes = append(summary.Routes, types.CfAppSummaryRoute{
+			GUID:   routeGUID,
+			Host:   route.entity.Host,
+			Domain: f.domains[route.entity.DomainGUID],
+		})
+	}
+	for _, binding := range f.bindings {
+		if binding.AppGUID == params["guid"] {
+			summary.Services = append(summary.Services, f.serviceSummary(binding.ServiceInstanceGUID))
+		}
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func (f *FakeCC) serviceSummary(instanceGUID string) types.CfAppSummaryService {
+	if ups, ok := f.userProvided[instanceGUID]; ok {
+		return types.CfAppSummaryService{GUID: instanceGUID, Name: ups.Name}
+	}
+	instance := f.serviceInstances[instanceGUID]
+	plan := f.plans[instance.entity.PlanGUID]
+	return types.CfAppSummaryService{
+		GUID: instanceGUID,
+		Name: instance.entity.Name,
+		Plan: types.CfAppSummaryServicePlan{
+			GUID: instance.entity.PlanGUID,
+			Name: plan.name,
+			Service: types.CfAppSummaryServicePlanService{
+				GUID:  plan.serviceGUID,
+				Label: f.services[plan.serviceGUID].Name,
+			},
+		},
+	}
+}
+
+func (f *FakeCC) getAppInstances(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	if app.entity.State != types.AppStarted {
+		writeCcError(w, http.StatusBadRequest, 220001, "CF-InstancesError",
+			"Instances error: Request failed for app: "+app.entity.Name+" as the app is in stopped state.")
+		return
+	}
+	writeJSON(w, http.StatusOK, app.currentInstances())
+}
+
+// getProcessStats serves stats of the web process, which has GUID of the app
+func (f *FakeCC) getProcessStats(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.apps[params["guid"]]
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Process not found")
+		return
+	}
+	stats := types.CfV3ProcessStatsResponse{Resources: []types.CfV3ProcessStats{}}
+	if app.entity.State == types.AppStarted {
+		for index, instance := range app.currentInstances() {
+			i, _ := strconv.Atoi(index)
+			stats.Resources = append(stats.Resources, types.CfV3ProcessStats{Type: "web", Index: i,
+				State: instance.State, Uptime: instance.Uptime, Details: instance.Details})
+		}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (f *FakeCC) getAppEnv(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	env := types.CfAppEnv{
+		StagingEnv:  map[string]interface{}{},
+		RunningEnv:  map[string]interface{}{},
+		Environment: app.entity.Envs,
+		SystemEnv:   types.CfAppSystemEnv{VcapServices: map[string][]types.CfVcapService{}},
+	}
+	if env.Environment == nil {
+		env.Environment = map[string]interface{}{}
+	}
+	for _, binding := range f.bindings {
+		if binding.AppGUID != params["guid"] {
+			continue
+		}
+		service := types.CfVcapService{Credentials: map[string]interface{}{}}
+		if instance, ok := f.serviceInstances[binding.ServiceInstanceGUID]; ok {
+			service.Name = instance.entity.Name
+			service.Tags = instance.entity.Tags
+			plan := f.plans[instance.entity.PlanGUID]
+			service.Plan = plan.name
+			service.Label = f.services[plan.serviceGUID].Name
+		} else if ups, ok := f.userProvided[binding.ServiceInstanceGUID]; ok {
+			service.Name = ups.Name
+			service.Label = "user-provided"
+			service.Credentials = ups.Credentials
+		}
+		env.SystemEnv.VcapServices[service.Label] = append(env.SystemEnv.VcapServices[service.Label], service)
+	}
+	env.ApplicationEnv.VcapApplication = types.CfVcapApplication{
+		Name:            app.entity.Name,
+		ApplicationID:   params["guid"],
+		ApplicationName: app.entity.Name,
+		SpaceID:         app.entity.SpaceGUID,
+	}
+	writeJSON(w, http.StatusOK, env)
+}
+
+func (f *FakeCC) getAppStats(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	if app.entity.State != types.AppStarted {
+		writeCcError(w, http.StatusBadRequest, 200003, "CF-AppStoppedStatsError",
+			"Could not fetch stats for stopped app: "+app.entity.Name)
+		return
+	}
+	stats := map[string]types.CfAppInstanceStats{}
+	for index, instance := range app.currentInstances() {
+		stats[index] = types.CfAppInstanceStats{State: instance.State, Details: instance.Details,
+			Stats: types.CfInstanceStats{
+				Name:      app.entity.Name,
+				Uptime:    instance.Uptime,
+				MemQuota:  app.entity.Memory * 1024 * 1024,
+				DiskQuota: app.entity.DiskQuota * 1024 * 1024,
+			}}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (f *FakeCC) restartAppInstance(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	index, err := strconv.Atoi(params["index"])
+	if err != nil || index < 0 || index >= app.entity.InstanceCount || app.entity.State != types.AppStarted {
+		writeCcError(w, http.StatusBadRequest, 220001, "CF-InstancesError",
+			"Instances error: Request failed for app: "+app.entity.Name+" as the instance is out of range.")
+		return
+	}
+	app.restarts = append(app.restarts, index)
+	if app.restartedAt == nil {
+		app.restartedAt = map[string]float64{}
+	}
+	app.restartedAt[params["index"]] = float64(time.Now().UnixNano()) / float64(time.Second)
+	if app.instances != nil {
+		app.instances[params["index"]] = types.CfAppInstance{State: types.InstanceRunning}
+	}
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+// SetAppInstances overrides instances reported for started app, e.g. to simulate crashes.
+// Restarted instance is reported RUNNING afterwards, with since of the restart. Nil restores all instances RUNNING.
+func (f *FakeCC) SetAppInstances(appGUID string, instances map[string]types.CfAppInstance) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if app, ok := f.apps[appGUID]; ok {
+		app.instances = instances
+	}
+}
+
+// AppInstanceRestarts returns indexes of app instances restarted so far, in order
+func (f *FakeCC) AppInstanceRestarts(appGUID string) []int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	app, ok := f.apps[appGUID]
+	if !ok {
+		return nil
+	}
+	return append([]int{}, app.restarts...)
+}
+
+func (a *fakeApp) currentInstances() map[string]types.CfAppInstance {
+	instances := map[string]types.CfAppInstance{}
+	if a.instances != nil {
+		for index, instance := range a.instances {
+			instances[index] = instance
+		}
+	} else {
+		for i := 0; i < a.entity.InstanceCount; i++ {
+			instances[strconv.Itoa(i)] = types.CfAppInstance{State: types.InstanceRunning}
+		}
+	}
+	for index, since := range a.restartedAt {
+		if instance, ok := instances[index]; ok && instance.Since < since {
+			instance.Since = since
+			instances[index] = instance
+		}
+	}
+	return instances
+}
+
+func (f *FakeCC) copyBits(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	request := types.CfCopyBitsRequest{}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	source, ok := f.apps[request.SrcAppGUID]
+	var job types.CfJob
+	if !ok || !source.hasBits || source.entity.IsDocker() {
+		job = f.newJob("failed", "Source app has no bits: "+request.SrcAppGUID)
+	} else {
+		app.hasBits = true
+		app.files = source.files
+		job = f.newJob("finished", "")
+	}
+	writeJSON(w, http.StatusCreated, resource(job.GUID, "/v2/jobs/"+job.GUID, job))
+}
+
+func (f *FakeCC) uploadBits(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	if app.entity.IsDocker() {
+		writeCcError(w, http.StatusBadRequest, 160001, "CF-AppBitsUploadInvalid",
+			"The app upload is invalid: cannot upload bits to a docker app")
+		return
+	}
+	resources := []types.CfResource{}
+	var files map[string]fakeAppFile
+	err := r.ParseMultipartForm(32 << 20)
+	if err == nil {
+		err = json.Unmarshal([]byte(r.FormValue("resources")), &resources)
+	}
+	if err == nil {
+		files, err = f.unzipBits(r, resources)
+	}
+	for _, resource := range resources {
+		if err != nil {
+			break
+		}
+		content, ok := f.resourcePool[resource.SHA1]
+		mode, parseErr := strconv.ParseUint(resource.Mode, 8, 32)
+		if !ok || parseErr != nil {
+			err = fmt.Errorf("resource %v of %v can not be used", resource.SHA1, resource.Path)
+			break
+		}
+		files[resource.Path] = fakeAppFile{content: content, mode: os.FileMode(mode)}
+	}
+	if err != nil {
+		writeCcError(w, http.StatusBadRequest, 160001, "CF-AppBitsUploadInvalid",
+			"The app upload is invalid: "+err.Error())
+		return
+	}
+	for _, file := range files {
+		f.resourcePool[sha1Hex(file.content)] = file.content
+	}
+	app.files = files
+	app.hasBits = true
+	job := f.newJob(types.JobFinished, "")
+	writeJSON(w, http.StatusCreated, resource(job.GUID, "/v2/jobs/"+job.GUID, job))
+}
+
+func (f *FakeCC) unzipBits(r *http.Request, resources []types.CfResource) (map[string]fakeAppFile, error) {
+	files := map[string]fakeAppFile{}
+	file, header, err := r.FormFile("application")
+	if err == http.ErrMissingFile {
+		return files, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	archive, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name] = fakeAppFile{content: content, mode: entry.Mode()}
+	}
+	return files, nil
+}
+
+func (f *FakeCC) matchResources(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	resources := []types.CfResource{}
+	if !decodeBody(w, r, &resources) {
+		return
+	}
+	matched := []types.CfResource{}
+	for _, resource := range resources {
+		if content, ok := f.resourcePool[resource.SHA1]; ok && int64(len(content)) == resource.Size {
+			matched = append(matched, resource)
+		}
+	}
+	writeJSON(w, http.StatusOK, matched)
+}
+
+// AddToResourcePool makes content known to fake CC, as if it was uploaded before. SHA1 of content is returned.
+func (f *FakeCC) AddToResourcePool(content []byte) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	sum := sha1Hex(content)
+	f.resourcePool[sum] = content
+	return sum
+}
+
+func sha1Hex(content []byte) string {
+	sum := sha1.Sum(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// AppFiles returns paths and permissions of files uploaded as app bits
+func (f *FakeCC) AppFiles(appGUID string) map[string]os.FileMode {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	files := map[string]os.FileMode{}
+	if app, ok := f.apps[appGUID]; ok {
+		for name, file := range app.files {
+			files[name] = file.mode
+		}
+	}
+	return files
+}
+
+// AppFileContent returns content of file uploaded as app bits
+func (f *FakeCC) AppFileContent(appGUID, path string) ([]byte, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	app, ok := f.apps[appGUID]
+	if !ok {
+		return nil, false
+	}
+	file, ok := app.files[path]
+	return file.content, ok
+}
+
+func (f *FakeCC) restageApp(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	if !app.hasBits && !app.entity.IsDocker() {
+		writeCcError(w, http.StatusBadRequest, 170002, "CF-NotStaged", "App has not finished staging")
+		return
+	}
+	writeJSON(w, http.StatusCreated, f.appResource(params["guid"]))
+}
+
+func (f *FakeCC) getAppBindings(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.findApp(w, params["guid"]); !ok {
+		return
+	}
+	f.writeBindings(w, func(binding types.CfBinding) bool { return binding.AppGUID == params["guid"] })
+}
+
+func (f *FakeCC) deleteAppBinding(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	binding, ok := f.bindings[params["binding"]]
+	if !ok || binding.AppGUID != params["guid"] {
+		writeNotFound(w, "Service binding")
+		return
+	}
+	delete(f.bindings, params["binding"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) getAppRoutes(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	resources := []interface{}{}
+	for _, routeGUID := range app.routes {
+		resources = append(resources, f.routeResource(routeGUID))
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+func (f *FakeCC) associateRoute(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	if _, ok := f.routes[params["route"]]; !ok {
+		writeNotFound(w, "Route")
+		return
+	}
+	if indexOf(app.routes, params["route"]) < 0 {
+		app.routes = append(app.routes, params["route"])
+	}
+	writeJSON(w, http.StatusCreated, f.appResource(params["guid"]))
+}
+
+func (f *FakeCC) unassociateRoute(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.findApp(w, params["guid"])
+	if !ok {
+		return
+	}
+	i := indexOf(app.routes, params["route"])
+	if i < 0 {
+		writeNotFound(w, "Route mapping")
+		return
+	}
+	app.routes = append(app.routes[:i], app.routes[i+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func indexOf(elems []string, elem string) int {
+	for i, e := range elems {
+		if e == elem {
+			return i
+		}
+	}
+	return -1
+}
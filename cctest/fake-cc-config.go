@@ -0,0 +1,134 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"sort"
+)
+
+// defaultFeatureFlags are the flags fake CC starts with, with CC default values
+var defaultFeatureFlags = map[string]bool{
+	"app_bits_upload":           true,
+	"app_scaling":               true,
+	"diego_docker":              false,
+	"private_domain_creation":   true,
+	"route_creation":            true,
+	"service_instance_creation": true,
+	"task_creation":             true,
+	"user_org_creation":         false,
+}
+
+func (f *FakeCC) registerConfigEndpoints() {
+	f.handle("GET", "/v2/config/environment_variable_group/:group", f.getEnvVarGroup)
+	f.handle("PUT", "/v2/config/environment_variable_group/:group", f.setEnvVarGroup)
+	f.handle("GET", "/v2/config/feature_flags", f.getFeatureFlags)
+	f.handle("GET", "/v2/config/feature_flags/:name", f.getFeatureFlag)
+	f.handle("PUT", "/v2/config/feature_flags/:name", f.setFeatureFlag)
+}
+
+// EnvVarGroup returns variables of running or staging group
+func (f *FakeCC) EnvVarGroup(group string) map[string]interface{} {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	toReturn := map[string]interface{}{}
+	for name, value := range f.envGroups[group] {
+		toReturn[name] = value
+	}
+	return toReturn
+}
+
+// FeatureFlag tells whether feature flag is enabled
+func (f *FakeCC) FeatureFlag(name string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.featureFlags[name]
+}
+
+func (f *FakeCC) getEnvVarGroup(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	vars, ok := f.envGroups[params["group"]]
+	if !ok {
+		writeNotFound(w, "Environment variable group")
+		return
+	}
+	writeJSON(w, http.StatusOK, vars)
+}
+
+func (f *FakeCC) setEnvVarGroup(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.envGroups[params["group"]]; !ok {
+		writeNotFound(w, "Environment variable group")
+		return
+	}
+	vars := map[string]interface{}{}
+	if !decodeBody(w, r, &vars) {
+		return
+	}
+	f.envGroups[params["group"]] = vars
+	writeJSON(w, http.StatusOK, vars)
+}
+
+func (f *FakeCC) getFeatureFlags(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	names := []string{}
+	for name := range f.featureFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	flags := []types.CfFeatureFlag{}
+	for _, name := range names {
+		flags = append(flags, f.featureFlag(name))
+	}
+	writeJSON(w, http.StatusOK, flags)
+}
+
+func (f *FakeCC) getFeatureFlag(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.featureFlags[params["name"]]; !ok {
+		writeCcError(w, http.StatusNotFound, 330000, "CF-FeatureFlagNotFound", "The feature flag could not be found: "+params["name"])
+		return
+	}
+	writeJSON(w, http.StatusOK, f.featureFlag(params["name"]))
+}
+
+func (f *FakeCC) setFeatureFlag(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.featureFlags[params["name"]]; !ok {
+		writeCcError(w, http.StatusNotFound, 330000, "CF-FeatureFlagNotFound", "The feature flag could not be found: "+params["name"])
+		return
+	}
+	request := struct {
+		Enabled *bool `json:"enabled"`
+	}{}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	if request.Enabled == nil {
+		writeCcError(w, http.StatusBadRequest, 330001, "CF-FeatureFlagInvalid", "The feature flag is invalid: enabled is required")
+		return
+	}
+	f.featureFlags[params["name"]] = *request.Enabled
+	writeJSON(w, http.StatusOK, f.featureFlag(params["name"]))
+}
+
+func (f *FakeCC) featureFlag(name string) types.CfFeatureFlag {
+	enabled := f.featureFlags[name]
+	return types.CfFeatureFlag{
+		Name:         name,
+		Enabled:      enabled,
+		Overridden:   enabled != defaultFeatureFlags[name],
+		DefaultValue: defaultFeatureFlags[name],
+		URL:          "/v2/config/feature_flags/" + name,
+	}
+}
@@ -0,0 +1,156 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+)
+
+type fakeRouteEntity struct {
+	types.CfRoute
+	SpaceGUID string `json:"space_guid"`
+}
+
+func (f *FakeCC) registerRouteEndpoints() {
+	f.handle("POST", "/v2/routes", f.createRoute)
+	f.handle("DELETE", "/v2/routes/:guid", f.deleteRoute)
+	f.handle("GET", "/v2/routes/:guid/apps", f.getRouteApps)
+	f.handle("GET", "/v2/spaces/:guid/routes", f.getSpaceRoutes)
+	f.handle("GET", "/v2/shared_domains", f.getSharedDomains)
+	f.handle("GET", "/v2/private_domains", f.getPrivateDomains)
+}
+
+// AddDomain stores shared domain in fake CC and returns its GUID
+func (f *FakeCC) AddDomain(name string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	guid := newGUID()
+	f.domains[guid] = types.CfDomain{GUID: guid, Name: name}
+	return guid
+}
+
+// AddRoute stores route in fake CC and returns its GUID
+func (f *FakeCC) AddRoute(route types.CfCreateRouteRequest) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	guid := newGUID()
+	f.routes[guid] = f.newRoute(route)
+	return guid
+}
+
+// Route returns route of given GUID
+func (f *FakeCC) Route(guid string) (types.CfRoute, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	route, ok := f.routes[guid]
+	if !ok {
+		return types.CfRoute{}, false
+	}
+	return route.entity, true
+}
+
+func (f *FakeCC) newRoute(route types.CfCreateRouteRequest) *fakeRoute {
+	return &fakeRoute{
+		entity: types.CfRoute{
+			Host:       route.Host,
+			DomainGUID: route.DomainGUID,
+			DomainURL:  "/v2/domains/" + route.DomainGUID,
+		},
+		spaceGUID: route.SpaceGUID,
+	}
+}
+
+func (f *FakeCC) routeResource(guid string) map[string]interface{} {
+	route := f.routes[guid]
+	return resource(guid, "/v2/routes/"+guid, fakeRouteEntity{CfRoute: route.entity, SpaceGUID: route.spaceGUID})
+}
+
+func (f *FakeCC) createRoute(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	request := types.CfCreateRouteRequest{}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	if _, ok := f.domains[request.DomainGUID]; !ok {
+		writeCcError(w, http.StatusBadRequest, 130002, "CF-DomainInvalid", "The domain is invalid: "+request.DomainGUID)
+		return
+	}
+	for _, existing := range f.routes {
+		if existing.entity.Host == request.Host && existing.entity.DomainGUID == request.DomainGUID {
+			writeCcError(w, http.StatusBadRequest, 210003, "CF-RouteHostTaken", "The host is taken: "+request.Host)
+			return
+		}
+	}
+	guid := newGUID()
+	f.routes[guid] = f.newRoute(request)
+	writeJSON(w, http.StatusCreated, f.routeResource(guid))
+}
+
+func (f *FakeCC) deleteRoute(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.routes[params["guid"]]; !ok {
+		writeNotFound(w, "Route")
+		return
+	}
+	for _, app := range f.apps {
+		if i := indexOf(app.routes, params["guid"]); i >= 0 {
+			app.routes = append(app.routes[:i], app.routes[i+1:]...)
+		}
+	}
+	delete(f.routes, params["guid"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) getRouteApps(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.routes[params["guid"]]; !ok {
+		writeNotFound(w, "Route")
+		return
+	}
+	resources := []interface{}{}
+	for guid, app := range f.apps {
+		if indexOf(app.routes, params["guid"]) >= 0 {
+			resources = append(resources, f.appResource(guid))
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+func (f *FakeCC) getSpaceRoutes(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	host, filtered := queryFilter(r, "host")
+	resources := []interface{}{}
+	for guid, route := range f.routes {
+		if route.spaceGUID == params["guid"] && (!filtered || route.entity.Host == host) {
+			resources = append(resources, f.routeResource(guid))
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+func (f *FakeCC) getSharedDomains(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	name, filtered := queryFilter(r, "name")
+	resources := []interface{}{}
+	for guid, domain := range f.domains {
+		if !filtered || domain.Name == name {
+			resources = append(resources, resource(guid, "/v2/shared_domains/"+guid, domain))
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+// getPrivateDomains returns no domains, as every domain of fake CC is shared
+func (f *FakeCC) getPrivateDomains(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	writeJSON(w, http.StatusOK, resourceList([]interface{}{}))
+}
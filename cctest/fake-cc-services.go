@@ -0,0 +1,381 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+)
+
+type fakePlanEntity struct {
+	Name        string `json:"name"`
+	ServiceGUID string `json:"service_guid"`
+}
+
+type fakeServiceInstanceEntity struct {
+	types.CfServiceInstanceCreateRequest
+	ServiceBindingsURL string `json:"service_bindings_url"`
+}
+
+func (f *FakeCC) registerServiceEndpoints() {
+	f.handle("POST", "/v2/service_instances", f.createServiceInstance)
+	f.handle("DELETE", "/v2/service_instances/:guid", f.deleteServiceInstance)
+	f.handle("GET", "/v2/service_instances/:guid/service_bindings", f.getInstanceBindings)
+	f.handle("GET", "/v2/spaces/:guid/service_instances", f.getSpaceServiceInstances)
+	f.handle("POST", "/v2/user_provided_service_instances", f.createUserProvidedService)
+	f.handle("GET", "/v2/user_provided_service_instances/:guid", f.getUserProvidedService)
+	f.handle("DELETE", "/v2/user_provided_service_instances/:guid", f.deleteUserProvidedService)
+	f.handle("GET", "/v2/user_provided_service_instances/:guid/service_bindings", f.getInstanceBindings)
+	f.handle("POST", "/v2/service_bindings", f.createServiceBinding)
+	f.handle("GET", "/v2/services", f.getServices)
+	f.handle("DELETE", "/v2/services/:guid", f.deleteService)
+	f.handle("GET", "/v2/services/:guid/service_plans", f.getServicePlans)
+	f.handle("DELETE", "/v2/service_plans/:guid", f.deleteServicePlan)
+	f.handle("POST", "/v2/service_brokers", f.registerBroker)
+	f.handle("PUT", "/v2/service_brokers/:guid", f.updateBroker)
+	f.handle("GET", "/v2/service_brokers", f.getBrokers)
+}
+
+// AddService stores service offering with plans of given names in fake CC.
+// It returns GUID of the service and GUIDs of plans in order of names.
+func (f *FakeCC) AddService(label string, planNames ...string) (string, []string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	guid := newGUID()
+	f.services[guid] = types.CfService{Name: label, PlansURL: "/v2/services/" + guid + "/service_plans"}
+	planGUIDs := []string{}
+	for _, name := range planNames {
+		planGUID := newGUID()
+		f.plans[planGUID] = fakePlan{name: name, serviceGUID: guid}
+		planGUIDs = append(planGUIDs, planGUID)
+	}
+	return guid, planGUIDs
+}
+
+// AddServiceInstance stores service instance in fake CC and returns its GUID
+func (f *FakeCC) AddServiceInstance(instance types.CfServiceInstanceCreateRequest) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	guid := newGUID()
+	f.serviceInstances[guid] = &fakeServiceInstance{entity: instance}
+	return guid
+}
+
+// AddBinding binds service or user provided service instance to app and returns binding GUID
+func (f *FakeCC) AddBinding(appGUID, instanceGUID string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	guid := newGUID()
+	f.bindings[guid] = types.CfBinding{GUID: guid, AppGUID: appGUID, ServiceInstanceGUID: instanceGUID}
+	return guid
+}
+
+// ServiceInstance returns managed service instance of given GUID
+func (f *FakeCC) ServiceInstance(guid string) (types.CfServiceInstanceCreateRequest, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	instance, ok := f.serviceInstances[guid]
+	if !ok {
+		return types.CfServiceInstanceCreateRequest{}, false
+	}
+	return instance.entity, true
+}
+
+// UserProvidedService returns user provided service instance of given GUID
+func (f *FakeCC) UserProvidedService(guid string) (types.CfUserProvidedService, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	ups, ok := f.userProvided[guid]
+	return ups, ok
+}
+
+// Bindings returns all service bindings
+func (f *FakeCC) Bindings() []types.CfBinding {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	toReturn := []types.CfBinding{}
+	for _, binding := range f.bindings {
+		toReturn = append(toReturn, binding)
+	}
+	return toReturn
+}
+
+// Service returns service offering of given GUID
+func (f *FakeCC) Service(guid string) (types.CfService, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	service, ok := f.services[guid]
+	return service, ok
+}
+
+// Broker returns service broker of given GUID
+func (f *FakeCC) Broker(guid string) (types.CfServiceBroker, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	broker, ok := f.brokers[guid]
+	return broker, ok
+}
+
+func (f *FakeCC) isBound(instanceGUID string) bool {
+	for _, binding := range f.bindings {
+		if binding.ServiceInstanceGUID == instanceGUID {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeCC) writeBindings(w http.ResponseWriter, filter func(types.CfBinding) bool) {
+	resources := []interface{}{}
+	for guid, binding := range f.bindings {
+		if filter(binding) {
+			resources = append(resources, resource(guid, "/v2/service_bindings/"+guid, binding))
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+func (f *FakeCC) createServiceInstance(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	request := types.CfServiceInstanceCreateRequest{}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	if _, ok := f.plans[request.PlanGUID]; !ok {
+		writeCcError(w, http.StatusBadRequest, 60003, "CF-InvalidRelation", "Invalid service plan: "+request.PlanGUID)
+		return
+	}
+	for _, existing := range f.serviceInstances {
+		if existing.entity.Name == request.Name && existing.entity.SpaceGUID == request.SpaceGUID {
+			writeCcError(w, http.StatusBadRequest, 60002, "CF-ServiceInstanceNameTaken",
+				"The service instance name is taken: "+request.Name)
+			return
+		}
+	}
+	guid := newGUID()
+	f.serviceInstances[guid] = &fakeServiceInstance{entity: request}
+	writeJSON(w, http.StatusCreated, resource(guid, "/v2/service_instances/"+guid, fakeServiceInstanceEntity{
+		CfServiceInstanceCreateRequest: request,
+		ServiceBindingsURL:             "/v2/service_instances/" + guid + "/service_bindings",
+	}))
+}
+
+func (f *FakeCC) deleteServiceInstance(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.serviceInstances[params["guid"]]; !ok {
+		writeNotFound(w, "Service instance")
+		return
+	}
+	if f.isBound(params["guid"]) {
+		writeCcError(w, http.StatusBadRequest, 10006, "CF-AssociationNotEmpty",
+			"Please delete the service_bindings associations for your service_instances.")
+		return
+	}
+	delete(f.serviceInstances, params["guid"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) getInstanceBindings(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	_, managed := f.serviceInstances[params["guid"]]
+	_, userProvided := f.userProvided[params["guid"]]
+	if !managed && !userProvided {
+		writeNotFound(w, "Service instance")
+		return
+	}
+	f.writeBindings(w, func(binding types.CfBinding) bool { return binding.ServiceInstanceGUID == params["guid"] })
+}
+
+func (f *FakeCC) getSpaceServiceInstances(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	name, filtered := queryFilter(r, "name")
+	matches := func(instanceName, spaceGUID string) bool {
+		return spaceGUID == params["guid"] && (!filtered || instanceName == name)
+	}
+	resources := []interface{}{}
+	for guid, instance := range f.serviceInstances {
+		if matches(instance.entity.Name, instance.entity.SpaceGUID) {
+			resources = append(resources, resource(guid, "/v2/service_instances/"+guid, types.CfServiceInstance{
+				Name: instance.entity.Name, SpaceGUID: instance.entity.SpaceGUID, Type: "managed_service_instance"}))
+		}
+	}
+	if r.URL.Query().Get("return_user_provided_service_instances") == "true" {
+		for guid, ups := range f.userProvided {
+			if matches(ups.Name, ups.SpaceGUID) {
+				resources = append(resources, resource(guid, "/v2/user_provided_service_instances/"+guid, types.CfServiceInstance{
+					Name: ups.Name, SpaceGUID: ups.SpaceGUID, Type: "user_provided_service_instance"}))
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+func (f *FakeCC) createUserProvidedService(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	request := types.CfUserProvidedService{}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	guid := newGUID()
+	f.userProvided[guid] = request
+	writeJSON(w, http.StatusCreated, resource(guid, "/v2/user_provided_service_instances/"+guid, request))
+}
+
+func (f *FakeCC) getUserProvidedService(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	ups, ok := f.userProvided[params["guid"]]
+	if !ok {
+		writeNotFound(w, "User provided service instance")
+		return
+	}
+	writeJSON(w, http.StatusOK, resource(params["guid"], "/v2/user_provided_service_instances/"+params["guid"], ups))
+}
+
+func (f *FakeCC) deleteUserProvidedService(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.userProvided[params["guid"]]; !ok {
+		writeNotFound(w, "User provided service instance")
+		return
+	}
+	if f.isBound(params["guid"]) {
+		writeCcError(w, http.StatusBadRequest, 10006, "CF-AssociationNotEmpty",
+			"Please delete the service_bindings associations for your service_instances.")
+		return
+	}
+	delete(f.userProvided, params["guid"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) createServiceBinding(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	request := types.CfServiceBindingCreateRequest{}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	if _, ok := f.apps[request.AppGUID]; !ok {
+		writeCcError(w, http.StatusBadRequest, 1002, "CF-InvalidRelation", "Invalid app: "+request.AppGUID)
+		return
+	}
+	_, managed := f.serviceInstances[request.ServiceInstanceGUID]
+	_, userProvided := f.userProvided[request.ServiceInstanceGUID]
+	if !managed && !userProvided {
+		writeCcError(w, http.StatusBadRequest, 1002, "CF-InvalidRelation",
+			"Invalid service instance: "+request.ServiceInstanceGUID)
+		return
+	}
+	for _, existing := range f.bindings {
+		if existing.AppGUID == request.AppGUID && existing.ServiceInstanceGUID == request.ServiceInstanceGUID {
+			writeCcError(w, http.StatusBadRequest, 90003, "CF-ServiceBindingAppServiceTaken",
+				"The app is already bound to the service instance")
+			return
+		}
+	}
+	guid := newGUID()
+	binding := types.CfBinding{GUID: guid, AppGUID: request.AppGUID, ServiceInstanceGUID: request.ServiceInstanceGUID}
+	f.bindings[guid] = binding
+	writeJSON(w, http.StatusCreated, resource(guid, "/v2/service_bindings/"+guid, binding))
+}
+
+func (f *FakeCC) getServices(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	label, filtered := queryFilter(r, "label")
+	resources := []interface{}{}
+	for guid, service := range f.services {
+		if !filtered || service.Name == label {
+			resources = append(resources, resource(guid, "/v2/services/"+guid, service))
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+func (f *FakeCC) deleteService(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.services[params["guid"]]; !ok {
+		writeNotFound(w, "Service")
+		return
+	}
+	delete(f.services, params["guid"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) getServicePlans(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.services[params["guid"]]; !ok {
+		writeNotFound(w, "Service")
+		return
+	}
+	resources := []interface{}{}
+	for guid, plan := range f.plans {
+		if plan.serviceGUID == params["guid"] {
+			resources = append(resources, resource(guid, "/v2/service_plans/"+guid,
+				fakePlanEntity{Name: plan.name, ServiceGUID: plan.serviceGUID}))
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+func (f *FakeCC) deleteServicePlan(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.plans[params["guid"]]; !ok {
+		writeNotFound(w, "Service plan")
+		return
+	}
+	for _, instance := range f.serviceInstances {
+		if instance.entity.PlanGUID == params["guid"] {
+			writeCcError(w, http.StatusBadRequest, 10006, "CF-AssociationNotEmpty",
+				"Please delete the service_instances associations for your service_plans.")
+			return
+		}
+	}
+	delete(f.plans, params["guid"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) registerBroker(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	request := types.CfServiceBroker{}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	for _, existing := range f.brokers {
+		if existing.Name == request.Name {
+			writeCcError(w, http.StatusBadRequest, 270002, "CF-ServiceBrokerNameTaken",
+				"The service broker name is taken: "+request.Name)
+			return
+		}
+	}
+	guid := newGUID()
+	f.brokers[guid] = request
+	writeJSON(w, http.StatusCreated, resource(guid, "/v2/service_brokers/"+guid, brokerEntity(request)))
+}
+
+func (f *FakeCC) updateBroker(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	broker, ok := f.brokers[params["guid"]]
+	if !ok {
+		writeNotFound(w, "Service broker")
+		return
+	}
+	if !decodeBody(w, r, &broker) {
+		return
+	}
+	f.brokers[params["guid"]] = broker
+	writeJSON(w, http.StatusOK, resource(params["guid"], "/v2/service_brokers/"+params["guid"], brokerEntity(broker)))
+}
+
+func (f *FakeCC) getBrokers(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	name, filtered := queryFilter(r, "name")
+	resources := []interface{}{}
+	for guid, broker := range f.brokers {
+		if !filtered || broker.Name == name {
+			resources = append(resources, resource(guid, "/v2/service_brokers/"+guid, brokerEntity(broker)))
+		}
+	}
+	writeJSON(w, http.StatusOK, resourceList(resources))
+}
+
+// brokerEntity hides broker password the same way CC does
+func brokerEntity(broker types.CfServiceBroker) types.CfServiceBroker {
+	broker.Password = ""
+	return broker
+}
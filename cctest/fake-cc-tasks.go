@@ -0,0 +1,160 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"fmt"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type fakeTask struct {
+	entity  types.CfV3Task
+	appGUID string
+}
+
+func (f *FakeCC) registerTaskEndpoints() {
+	f.handle("POST", "/v3/apps/:guid/tasks", f.createTask)
+	f.handle("GET", "/v3/apps/:guid/tasks", f.listTasks)
+	f.handle("GET", "/v3/tasks/:guid", f.getTask)
+	f.handle("POST", "/v3/tasks/:guid/actions/cancel", f.cancelTask)
+}
+
+// SetTaskState moves task to given state, e.g. to finish it as the droplet process would
+func (f *FakeCC) SetTaskState(taskGUID, state, failureReason string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if task, ok := f.tasks[taskGUID]; ok {
+		task.entity.State = state
+		task.entity.Result.FailureReason = failureReason
+		task.entity.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+}
+
+// Tasks returns tasks of the app in order of creation
+func (f *FakeCC) Tasks(appGUID string) []types.CfV3Task {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.appTasks(appGUID, nil)
+}
+
+func (f *FakeCC) appTasks(appGUID string, states map[string]bool) []types.CfV3Task {
+	toReturn := []types.CfV3Task{}
+	for _, guid := range f.taskOrder {
+		task := f.tasks[guid]
+		if task.appGUID == appGUID && (len(states) == 0 || states[task.entity.State]) {
+			toReturn = append(toReturn, task.entity)
+		}
+	}
+	return toReturn
+}
+
+func (f *FakeCC) createTask(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	app, ok := f.apps[params["guid"]]
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "App not found")
+		return
+	}
+	if !app.hasBits && !app.entity.IsDocker() {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", "Task must have a droplet. Assign current droplet to app.")
+		return
+	}
+	request := types.CfV3TaskCreateRequest{}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	if request.Command == "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", "Command can't be blank")
+		return
+	}
+
+	sequence := len(f.appTasks(params["guid"], nil)) + 1
+	task := &fakeTask{appGUID: params["guid"], entity: types.CfV3Task{
+		GUID:       newGUID(),
+		SequenceID: sequence,
+		Name:       request.Name,
+		Command:    request.Command,
+		State:      types.TaskRunning,
+		MemoryInMB: request.MemoryInMB,
+		DiskInMB:   request.DiskInMB,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}}
+	if task.entity.Name == "" {
+		task.entity.Name = fmt.Sprintf("task-%d", sequence)
+	}
+	if task.entity.MemoryInMB == 0 {
+		task.entity.MemoryInMB = 1024
+	}
+	if task.entity.DiskInMB == 0 {
+		task.entity.DiskInMB = 1024
+	}
+	task.entity.UpdatedAt = task.entity.CreatedAt
+	f.tasks[task.entity.GUID] = task
+	f.taskOrder = append(f.taskOrder, task.entity.GUID)
+	writeJSON(w, http.StatusAccepted, task.entity)
+}
+
+func (f *FakeCC) listTasks(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := f.apps[params["guid"]]; !ok {
+		writeV3Error(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "App not found")
+		return
+	}
+	states := map[string]bool{}
+	if filter := r.URL.Query().Get("states"); filter != "" {
+		for _, state := range strings.Split(filter, ",") {
+			states[state] = true
+		}
+	}
+	tasks := f.appTasks(params["guid"], states)
+	response := types.CfV3TasksResponse{Resources: tasks}
+	response.Pagination.TotalResults = len(tasks)
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (f *FakeCC) getTask(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	task, ok := f.tasks[params["guid"]]
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Task not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, task.entity)
+}
+
+func (f *FakeCC) cancelTask(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	task, ok := f.tasks[params["guid"]]
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Task not found")
+		return
+	}
+	if task.entity.State == types.TaskSucceeded || task.entity.State == types.TaskFailed {
+		writeV3Error(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity",
+			"Task state is "+task.entity.State+" and therefore cannot be canceled")
+		return
+	}
+	// real CC reports CANCELING until the process is stopped; fake stops it at once
+	task.entity.State = types.TaskFailed
+	task.entity.Result.FailureReason = "task was canceled"
+	writeJSON(w, http.StatusAccepted, task.entity)
+}
+
+func writeV3Error(w http.ResponseWriter, status, code int, title, detail string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []map[string]interface{}{{"code": code, "title": title, "detail": detail}},
+	})
+}
@@ -0,0 +1,72 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (f *FakeCC) registerUsageEndpoints() {
+	f.handle("GET", "/v2/app_usage_events", f.getAppUsageEvents)
+}
+
+// AddAppUsageEvent appends app usage event created at given time and returns its GUID
+func (f *FakeCC) AddAppUsageEvent(event types.CfAppUsageEvent, createdAt time.Time) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	guid := newGUID()
+	f.usageEvents = append(f.usageEvents, types.CfAppUsageEventResource{
+		Meta:   types.CfUsageEventMeta{GUID: guid, URL: "/v2/app_usage_events/" + guid, CreatedAt: createdAt.UTC()},
+		Entity: event,
+	})
+	return guid
+}
+
+func (f *FakeCC) getAppUsageEvents(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	query := r.URL.Query()
+	start := 0
+	if after := query.Get("after_guid"); after != "" {
+		start = -1
+		for i, event := range f.usageEvents {
+			if event.Meta.GUID == after {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			writeCcError(w, http.StatusBadRequest, 1005, "CF-BadQueryParameter",
+				"The query parameter is invalid: After guid Unknown after_guid: "+after)
+			return
+		}
+	}
+	limit, err := strconv.Atoi(query.Get("results-per-page"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	} else if limit > 100 {
+		limit = 100
+	}
+	end := start + limit
+	if end > len(f.usageEvents) {
+		end = len(f.usageEvents)
+	}
+	page := types.CfAppUsageEventsResponse{Count: end - start, Pages: 1,
+		Resources: append([]types.CfAppUsageEventResource{}, f.usageEvents[start:end]...)}
+	writeJSON(w, http.StatusOK, page)
+}
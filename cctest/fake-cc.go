@@ -0,0 +1,351 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nu7hatch/gouuid"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Failure describes a response injected instead of the regular one
+type Failure struct {
+	StatusCode int
+	Body       string
+	// Times limits how many requests fail. Zero means every request.
+	Times int
+	// DropConnection closes the connection without response, which client sees as transport error
+	DropConnection bool
+}
+
+type fakeHandler func(w http.ResponseWriter, r *http.Request, params map[string]string)
+
+type fakeEndpoint struct {
+	method   string
+	pattern  string
+	segments []string
+	handler  fakeHandler
+}
+
+type fakeApp struct {
+	entity    types.CfApp
+	routes    []string
+	hasBits   bool
+	instances map[string]types.CfAppInstance
+	restarts  []int
+	// restartedAt holds since of restarted instances by index
+	restartedAt map[string]float64
+	files       map[string]fakeAppFile
+}
+
+type fakeAppFile struct {
+	content []byte
+	mode    os.FileMode
+}
+
+type fakeRoute struct {
+	entity    types.CfRoute
+	spaceGUID string
+}
+
+type fakeServiceInstance struct {
+	entity types.CfServiceInstanceCreateRequest
+}
+
+type fakePlan struct {
+	name        string
+	serviceGUID string
+}
+
+// FakeCC is an in-memory CloudController serving the /v2 endpoints used by this library.
+// Its state may be seeded and inspected directly, endpoints may be forced to fail.
+type FakeCC struct {
+	*httptest.Server
+
+	mutex     sync.Mutex
+	endpoints []fakeEndpoint
+	failures  map[string]*Failure
+	requests  []string
+
+	apps             map[string]*fakeApp
+	routes           map[string]*fakeRoute
+	domains          map[string]types.CfDomain
+	services         map[string]types.CfService
+	plans            map[string]fakePlan
+	serviceInstances map[string]*fakeServiceInstance
+	userProvided     map[string]types.CfUserProvidedService
+	bindings         map[string]types.CfBinding
+	brokers          map[string]types.CfServiceBroker
+	jobs             map[string]types.CfJob
+	resourcePool     map[string][]byte
+	tasks            map[string]*fakeTask
+	taskOrder        []string
+	envGroups        map[string]map[string]interface{}
+	featureFlags     map[string]bool
+	usageEvents      []types.CfAppUsageEventResource
+
+	info      types.CfInfo
+	v3Version string
+}
+
+// NewFakeCC starts fake CloudController. It shall be closed with Close when no longer needed.
+func NewFakeCC() *FakeCC {
+	f := &FakeCC{
+		failures:         map[string]*Failure{},
+		apps:             map[string]*fakeApp{},
+		routes:           map[string]*fakeRoute{},
+		domains:          map[string]types.CfDomain{},
+		services:         map[string]types.CfService{},
+		plans:            map[string]fakePlan{},
+		serviceInstances: map[string]*fakeServiceInstance{},
+		userProvided:     map[string]types.CfUserProvidedService{},
+		bindings:         map[string]types.CfBinding{},
+		brokers:          map[string]types.CfServiceBroker{},
+		jobs:             map[string]types.CfJob{},
+		resourcePool:     map[string][]byte{},
+		tasks:            map[string]*fakeTask{},
+		envGroups:        map[string]map[string]interface{}{"running": {}, "staging": {}},
+		featureFlags:     map[string]bool{},
+		info:             types.CfInfo{Name: "fake-cc", APIVersion: "2.65.0"},
+	}
+	f.registerAppEndpoints()
+	f.registerRouteEndpoints()
+	f.registerServiceEndpoints()
+	f.registerTaskEndpoints()
+	f.registerConfigEndpoints()
+	f.registerUsageEndpoints()
+	f.handle("GET", "/v2/jobs/:guid", f.getJob)
+	f.handle("GET", "/v2/info", f.getInfo)
+	f.handle("GET", "/", f.getRoot)
+	for name, enabled := range defaultFeatureFlags {
+		f.featureFlags[name] = enabled
+	}
+	f.Server = httptest.NewServer(f)
+	return f
+}
+
+// InjectFailure makes requests to endpoint fail. Pattern is the endpoint path
+// as served by FakeCC, e.g. "/v2/apps/:guid/summary".
+func (f *FakeCC) InjectFailure(method, pattern string, failure Failure) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.failures[method+" "+pattern] = &failure
+}
+
+// ClearFailures removes all injected failures
+func (f *FakeCC) ClearFailures() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.failures = map[string]*Failure{}
+}
+
+// Requests returns "METHOD /path?query" of every request received so far
+func (f *FakeCC) Requests() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.requests...)
+}
+
+func (f *FakeCC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.RequestURI())
+
+	for _, endpoint := range f.endpoints {
+		params, ok := endpoint.match(r)
+		if !ok {
+			continue
+		}
+		if f.applyFailure(w, endpoint.method+" "+endpoint.pattern) {
+			return
+		}
+		endpoint.handler(w, r, params)
+		return
+	}
+	writeCcError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+}
+
+func (f *FakeCC) applyFailure(w http.ResponseWriter, key string) bool {
+	failure, ok := f.failures[key]
+	if !ok {
+		return false
+	}
+	if failure.Times > 0 {
+		failure.Times--
+		if failure.Times == 0 {
+			delete(f.failures, key)
+		}
+	}
+	if failure.DropConnection {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(failure.StatusCode)
+	fmt.Fprint(w, failure.Body)
+	return true
+}
+
+func (f *FakeCC) handle(method, pattern string, handler fakeHandler) {
+	f.endpoints = append(f.endpoints, fakeEndpoint{
+		method:   method,
+		pattern:  pattern,
+		segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler:  handler,
+	})
+}
+
+func (e fakeEndpoint) match(r *http.Request) (map[string]string, bool) {
+	if r.Method != e.method {
+		return nil, false
+	}
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) != len(e.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, segment := range e.segments {
+		if strings.HasPrefix(segment, ":") {
+			params[segment[1:]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// SetInfo replaces /v2/info served by fake CC
+func (f *FakeCC) SetInfo(info types.CfInfo) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.info = info
+}
+
+// SetV3Version makes fake CC advertise v3 API of given version. Empty version hides v3 API.
+func (f *FakeCC) SetV3Version(version string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.v3Version = version
+}
+
+func (f *FakeCC) getInfo(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	writeJSON(w, http.StatusOK, f.info)
+}
+
+func (f *FakeCC) getRoot(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	root := types.CfRootLinks{Links: map[string]types.CfRootLink{}}
+	v2 := types.CfRootLink{Href: f.URL + "/v2"}
+	v2.Meta.Version = f.info.APIVersion
+	root.Links["cloud_controller_v2"] = v2
+	if f.v3Version != "" {
+		v3 := types.CfRootLink{Href: f.URL + "/v3"}
+		v3.Meta.Version = f.v3Version
+		root.Links["cloud_controller_v3"] = v3
+	}
+	writeJSON(w, http.StatusOK, root)
+}
+
+func (f *FakeCC) getJob(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	job, ok := f.jobs[params["guid"]]
+	if !ok {
+		writeNotFound(w, "Job")
+		return
+	}
+	writeJSON(w, http.StatusOK, resource(job.GUID, "/v2/jobs/"+job.GUID, job))
+}
+
+func (f *FakeCC) newJob(status, jobError string) types.CfJob {
+	job := types.CfJob{GUID: newGUID(), Status: status, Error: jobError}
+	f.jobs[job.GUID] = job
+	return job
+}
+
+// Job returns job of given GUID
+func (f *FakeCC) Job(guid string) (types.CfJob, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	job, ok := f.jobs[guid]
+	return job, ok
+}
+
+func newGUID() string {
+	guid, _ := uuid.NewV4()
+	return guid.String()
+}
+
+// queryFilter returns value of CC filter "q=field:value" if present
+func queryFilter(r *http.Request, field string) (string, bool) {
+	for _, q := range r.URL.Query()["q"] {
+		if strings.HasPrefix(q, field+":") {
+			return strings.TrimPrefix(q, field+":"), true
+		}
+	}
+	return "", false
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeCcError(w, http.StatusBadRequest, 1001, "CF-MessageParseError", "Request invalid due to parse error: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func resource(guid, url string, entity interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": types.CfMeta{GUID: guid, URL: url},
+		"entity":   entity,
+	}
+}
+
+func resourceList(resources []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"total_results": len(resources),
+		"total_pages":   1,
+		"resources":     resources,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeCcError(w http.ResponseWriter, status, code int, errorCode, description string) {
+	writeJSON(w, status, map[string]interface{}{
+		"code":        code,
+		"description": description,
+		"error_code":  errorCode,
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, entityName string) {
+	writeCcError(w, http.StatusNotFound, 10000, "CF-NotFound", entityName+" could not be found")
+}
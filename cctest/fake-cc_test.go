@@ -0,0 +1,341 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var _ = Describe("Fake CC", func() {
+
+	var (
+		fake       *FakeCC
+		sut        *api.CfAPI
+		domainGUID string
+		sourceGUID string
+	)
+
+	BeforeEach(func() {
+		fake = NewFakeCC()
+		sut = &api.CfAPI{BaseAddress: fake.URL, Client: http.DefaultClient}
+
+		domainGUID = fake.AddDomain("example.com")
+		sourceGUID = fake.AddApp(types.CfApp{Name: "source", SpaceGUID: "space", State: types.AppStarted,
+			Envs: map[string]interface{}{"FOO": "bar"}})
+		routeGUID := fake.AddRoute(types.CfCreateRouteRequest{Host: "source", DomainGUID: domainGUID, SpaceGUID: "space"})
+		Expect(sut.AssociateRoute(sourceGUID, routeGUID)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	Describe("application clone", func() {
+		It("should create app with route in requested space", func() {
+			clone, err := sut.CreateApplicationClone(sourceGUID, "other_space", map[string]string{"name": "clone", "BAZ": "qux"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clone.Meta.URL).To(Equal("clone.example.com"))
+			app, ok := fake.App(clone.Meta.GUID)
+			Expect(ok).To(BeTrue())
+			Expect(app.SpaceGUID).To(Equal("other_space"))
+			Expect(app.State).To(Equal(types.AppStopped))
+			Expect(app.Envs).To(HaveKeyWithValue("FOO", "bar"))
+			Expect(app.Envs).To(HaveKeyWithValue("BAZ", "qux"))
+			routes := fake.AppRoutes(clone.Meta.GUID)
+			Expect(routes).To(HaveLen(1))
+			route, _ := fake.Route(routes[0])
+			Expect(route.Host).To(Equal("clone"))
+		})
+
+		It("should allow starting the clone once bits are copied", func() {
+			clone, err := sut.CreateApplicationClone(sourceGUID, "space", map[string]string{"name": "clone"})
+			Expect(err).NotTo(HaveOccurred())
+			errCh := make(chan error, 1)
+
+			sut.CopyBits(sourceGUID, clone.Meta.GUID, errCh)
+			Expect(<-errCh).NotTo(HaveOccurred())
+			Expect(sut.StartApp(clone)).To(Succeed())
+
+			app, _ := fake.App(clone.Meta.GUID)
+			Expect(app.State).To(Equal(types.AppStarted))
+		})
+
+		It("should refuse starting app without bits", func() {
+			clone, _ := sut.CreateApplicationClone(sourceGUID, "space", map[string]string{"name": "clone"})
+
+			Expect(sut.StartApp(clone)).NotTo(Succeed())
+		})
+
+		It("should fail when route host is taken", func() {
+			_, err := sut.CreateApplicationClone(sourceGUID, "space", map[string]string{"name": "source"})
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("app lookup", func() {
+		It("should find app by name", func() {
+			app, err := sut.GetAppByName("space", "source")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(app.Meta.GUID).To(Equal(sourceGUID))
+		})
+
+		It("should report deleted app as not found", func() {
+			Expect(sut.DeleteApp(sourceGUID)).To(Succeed())
+
+			_, err := sut.GetApp(sourceGUID)
+
+			Expect(err).To(Equal(types.EntityNotFoundError))
+		})
+	})
+
+	Describe("app states", func() {
+		options := api.WaitOptions{PollInterval: time.Millisecond}
+
+		It("should restart app and restage it", func() {
+			report, err := sut.RestartApp(sourceGUID, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Instances).To(HaveKeyWithValue("0", types.CfAppInstance{State: types.InstanceRunning}))
+
+			app, err := sut.RestageAndWait(sourceGUID, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(app.Entity.PackageState).To(Equal(types.PackageStaged))
+		})
+
+		It("should report stats and restart single instance", func() {
+			fake.SetAppInstances(sourceGUID, map[string]types.CfAppInstance{
+				"0": {State: types.InstanceCrashed, Details: "oom"}})
+
+			stats, err := sut.GetAppStats(sourceGUID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats["0"].Details).To(Equal("oom"))
+
+			Expect(sut.RestartAppInstance(sourceGUID, 0)).To(Succeed())
+			Expect(sut.RestartAppInstance(sourceGUID, 1)).NotTo(Succeed())
+			Expect(fake.AppInstanceRestarts(sourceGUID)).To(Equal([]int{0}))
+			instances, err := sut.GetAppInstances(sourceGUID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instances["0"].State).To(Equal(types.InstanceRunning))
+		})
+
+		It("should patch environment and expose it", func() {
+			_, err := sut.PatchAppEnv(sourceGUID, map[string]interface{}{"NEW": "value"}, []string{"FOO"},
+				api.EnvApply{Action: api.EnvApplyRestart, Wait: options})
+			Expect(err).NotTo(HaveOccurred())
+
+			env, err := sut.GetAppEnv(sourceGUID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Environment).To(Equal(map[string]interface{}{"NEW": "value"}))
+			Expect(env.ApplicationEnv.VcapApplication.ApplicationID).To(Equal(sourceGUID))
+			app, _ := fake.App(sourceGUID)
+			Expect(app.State).To(Equal(types.AppStarted))
+		})
+
+		It("should stop app", func() {
+			report, err := sut.StopApp(sourceGUID, options)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Instances).To(BeEmpty())
+			app, _ := fake.App(sourceGUID)
+			Expect(app.State).To(Equal(types.AppStopped))
+		})
+	})
+
+	Describe("bits upload", func() {
+		It("should store uploaded files and allow starting the app", func() {
+			dir, err := ioutil.TempDir("", "fake-cc-bits-")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			Expect(ioutil.WriteFile(filepath.Join(dir, "start.sh"), []byte("run"), 0755)).To(Succeed())
+			Expect(os.Chmod(filepath.Join(dir, "start.sh"), 0755)).To(Succeed())
+			app, err := sut.CreateApp(types.CfApp{Name: "pushed", SpaceGUID: "space"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sut.StartApp(app)).NotTo(Succeed())
+
+			source, err := api.BitsFromPath(dir)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = sut.UploadBits(app.Meta.GUID, source, api.UploadOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fake.AppFiles(app.Meta.GUID)).To(Equal(map[string]os.FileMode{"start.sh": 0755}))
+			content, _ := fake.AppFileContent(app.Meta.GUID, "start.sh")
+			Expect(string(content)).To(Equal("run"))
+			Expect(sut.StartApp(app)).To(Succeed())
+		})
+
+		It("should serve matched resources from files uploaded before", func() {
+			dir, err := ioutil.TempDir("", "fake-cc-bits-")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			Expect(ioutil.WriteFile(filepath.Join(dir, "lib.jar"), []byte("library"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("new code"), 0644)).To(Succeed())
+			fake.AddToResourcePool([]byte("library"))
+			source, _ := api.BitsFromPath(dir)
+
+			report, err := sut.UploadBits(sourceGUID, source, api.UploadOptions{MatchResources: true})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.MatchedFiles).To(Equal(1))
+			Expect(report.BytesSaved).To(Equal(int64(len("library"))))
+			content, ok := fake.AppFileContent(sourceGUID, "lib.jar")
+			Expect(ok).To(BeTrue())
+			Expect(string(content)).To(Equal("library"))
+			Expect(fake.AppFiles(sourceGUID)).To(HaveLen(2))
+		})
+	})
+
+	Describe("routes deletion", func() {
+		It("should unmap and delete app routes", func() {
+			routeGUID := fake.AppRoutes(sourceGUID)[0]
+			errCh := make(chan error, 1)
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+
+			sut.DeleteRoutes(sourceGUID, errCh, wg)
+
+			Expect(<-errCh).NotTo(HaveOccurred())
+			Expect(fake.AppRoutes(sourceGUID)).To(BeEmpty())
+			_, exists := fake.Route(routeGUID)
+			Expect(exists).To(BeFalse())
+		})
+	})
+
+	Describe("services", func() {
+		var instanceGUID string
+
+		BeforeEach(func() {
+			_, plans := fake.AddService("postgresql", "free")
+			instanceGUID = fake.AddServiceInstance(types.CfServiceInstanceCreateRequest{
+				Name: "db", SpaceGUID: "space", PlanGUID: plans[0]})
+			fake.AddBinding(sourceGUID, instanceGUID)
+		})
+
+		It("should clone dependent service", func() {
+			results := make(chan types.ComponentClone, 1)
+			errCh := make(chan error, 1)
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			comp := types.Component{GUID: instanceGUID, DependencyOf: []string{sourceGUID}}
+
+			sut.CreateServiceClone("other_space", nil, comp, "copy", results, errCh, wg)
+
+			Expect(<-errCh).NotTo(HaveOccurred())
+			instance, ok := fake.ServiceInstance((<-results).CloneGUID)
+			Expect(ok).To(BeTrue())
+			Expect(instance.Name).To(Equal("db-copy"))
+			Expect(instance.Tags).To(ConsistOf("postgresql"))
+		})
+
+		It("should delete instance only after unbinding", func() {
+			comp := types.Component{GUID: instanceGUID, Name: "db", Type: types.ComponentService}
+			errCh := make(chan error, 2)
+			wg := &sync.WaitGroup{}
+			wg.Add(2)
+
+			sut.DeleteServiceInstIfUnbound(comp, errCh, wg)
+			Expect(<-errCh).NotTo(HaveOccurred())
+			_, exists := fake.ServiceInstance(instanceGUID)
+			Expect(exists).To(BeTrue())
+
+			unbindWg := &sync.WaitGroup{}
+			unbindWg.Add(1)
+			sut.UnbindAppServices(sourceGUID, errCh, unbindWg)
+			Expect(<-errCh).NotTo(HaveOccurred())
+			sut.DeleteServiceInstIfUnbound(comp, errCh, wg)
+			Expect(<-errCh).NotTo(HaveOccurred())
+			_, exists = fake.ServiceInstance(instanceGUID)
+			Expect(exists).To(BeFalse())
+		})
+	})
+
+	Describe("brokers", func() {
+		It("should register and find broker by name", func() {
+			Expect(sut.RegisterBroker("broker", "http://broker", "user", "pass")).To(Succeed())
+
+			brokers, err := sut.GetBrokers("broker")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(brokers.Resources).To(HaveLen(1))
+			Expect(brokers.Resources[0].Entity.URL).To(Equal("http://broker"))
+		})
+	})
+
+	Describe("info", func() {
+		It("should expose configured API versions", func() {
+			fake.SetInfo(types.CfInfo{APIVersion: "2.120.0"})
+			fake.SetV3Version("3.55.0")
+
+			capabilities, err := sut.GetCapabilities()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(capabilities.APIVersion).To(Equal("2.120.0"))
+			Expect(capabilities.SharedInstances).To(BeTrue())
+		})
+	})
+
+	Describe("failure injection", func() {
+		It("should respond with injected status", func() {
+			fake.InjectFailure("GET", "/v2/apps/:guid/summary", Failure{StatusCode: 500, Body: `{"description":"boom"}`})
+
+			_, err := sut.GetAppSummary(sourceGUID)
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail requested number of times only", func() {
+			fake.InjectFailure("GET", "/v2/apps/:guid/summary", Failure{StatusCode: 500, Times: 1})
+
+			_, err := sut.GetAppSummary(sourceGUID)
+			Expect(err).To(HaveOccurred())
+			_, err = sut.GetAppSummary(sourceGUID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should drop connection", func() {
+			fake.InjectFailure("POST", "/v2/routes", Failure{DropConnection: true})
+
+			_, err := sut.CreateRoute(&types.CfCreateRouteRequest{Host: "new", DomainGUID: domainGUID, SpaceGUID: "space"})
+
+			Expect(err).To(HaveOccurred())
+			Expect(fake.Requests()).To(ContainElement("POST /v2/routes"))
+		})
+
+		It("should keep state untouched by failed clone", func() {
+			fake.InjectFailure("PUT", "/v2/apps/:guid/routes/:route", Failure{DropConnection: true})
+
+			_, err := sut.CreateApplicationClone(sourceGUID, "space", map[string]string{"name": "clone"})
+
+			Expect(err).To(HaveOccurred())
+			fake.ClearFailures()
+			routes, _ := sut.GetSpaceRoutesForHostname("space", "clone")
+			Expect(routes.Count).To(Equal(1))
+			apps, _ := sut.GetAppsFromRoute(routes.Resources[0].Meta.GUID)
+			Expect(apps.Count).To(Equal(0))
+		})
+	})
+})
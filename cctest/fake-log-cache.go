@@ -0,0 +1,147 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeLogCache is an in-memory stand-in of log-cache read API (/api/v1/read/:source_id)
+type FakeLogCache struct {
+	*httptest.Server
+
+	mutex     sync.Mutex
+	envelopes map[string][]fakeEnvelope
+	// FailWith makes every read respond with given status, unless zero
+	FailWith int
+}
+
+type fakeEnvelope struct {
+	Timestamp  string            `json:"timestamp"`
+	SourceID   string            `json:"source_id"`
+	InstanceID string            `json:"instance_id"`
+	Tags       map[string]string `json:"tags"`
+	Log        *fakeLog          `json:"log,omitempty"`
+	Gauge      interface{}       `json:"gauge,omitempty"`
+}
+
+type fakeLog struct {
+	Payload string `json:"payload"`
+	Type    string `json:"type"`
+}
+
+func NewFakeLogCache() *FakeLogCache {
+	f := &FakeLogCache{envelopes: map[string][]fakeEnvelope{}}
+	f.Server = httptest.NewServer(f)
+	return f
+}
+
+// AddLog stores log line of the app. Zero timestamp means now.
+func (f *FakeLogCache) AddLog(appGUID, sourceType, instance, text string, isErr bool, timestamp time.Time) {
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	logType := "OUT"
+	if isErr {
+		logType = "ERR"
+	}
+	f.add(fakeEnvelope{
+		Timestamp:  fmt.Sprint(timestamp.UnixNano()),
+		SourceID:   appGUID,
+		InstanceID: instance,
+		Tags:       map[string]string{"source_type": sourceType},
+		Log:        &fakeLog{Payload: base64.StdEncoding.EncodeToString([]byte(text)), Type: logType},
+	})
+}
+
+// AddMetric stores non-log envelope, which log readers are expected to skip
+func (f *FakeLogCache) AddMetric(appGUID string, timestamp time.Time) {
+	f.add(fakeEnvelope{
+		Timestamp: fmt.Sprint(timestamp.UnixNano()),
+		SourceID:  appGUID,
+		Tags:      map[string]string{},
+		Gauge:     map[string]interface{}{"metrics": map[string]interface{}{}},
+	})
+}
+
+func (f *FakeLogCache) add(e fakeEnvelope) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	list := f.envelopes[e.SourceID]
+	// keep envelopes ordered by time, later ones with equal timestamp go last
+	i := len(list)
+	for i > 0 && nanos(list[i-1]) > nanos(e) {
+		i--
+	}
+	list = append(list, fakeEnvelope{})
+	copy(list[i+1:], list[i:])
+	list[i] = e
+	f.envelopes[e.SourceID] = list
+}
+
+func nanos(e fakeEnvelope) int64 {
+	n, _ := strconv.ParseInt(e.Timestamp, 10, 64)
+	return n
+}
+
+func (f *FakeLogCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" || !strings.HasPrefix(r.URL.Path, "/api/v1/read/") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.FailWith != 0 {
+		writeJSON(w, f.FailWith, map[string]string{"error": "injected failure"})
+		return
+	}
+
+	query := r.URL.Query()
+	start, _ := strconv.ParseInt(query.Get("start_time"), 10, 64)
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	logsOnly := query.Get("envelope_types") == "LOG"
+	descending := query.Get("descending") == "true"
+
+	all := f.envelopes[strings.TrimPrefix(r.URL.Path, "/api/v1/read/")]
+	selected := []fakeEnvelope{}
+	for i := range all {
+		e := all[i]
+		if descending {
+			e = all[len(all)-1-i]
+		}
+		if nanos(e) < start || (logsOnly && e.Log == nil) {
+			continue
+		}
+		if len(selected) == limit {
+			break
+		}
+		selected = append(selected, e)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"envelopes": map[string]interface{}{"batch": selected},
+	})
+}
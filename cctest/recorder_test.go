@@ -0,0 +1,146 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"encoding/json"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ = Describe("Recorder", func() {
+
+	var (
+		server   *httptest.Server
+		recorder *Recorder
+		sut      api.CfAPI
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=s3cr3t")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(types.CfUserProvidedServiceResource{
+				Meta: types.CfMeta{GUID: "ups_guid"},
+				Entity: types.CfUserProvidedService{
+					Name:        "ups",
+					Credentials: map[string]interface{}{"password": "s3cr3t"},
+				},
+			})
+		}))
+		recorder = NewRecorder(nil)
+		sut = api.CfAPI{BaseAddress: server.URL, Client: &http.Client{Transport: recorder}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("round trip", func() {
+		It("should pass response to the caller untouched", func() {
+			result, err := sut.CreateUserProvidedServiceInstance(&types.CfUserProvidedService{Name: "ups"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Meta.GUID).To(Equal("ups_guid"))
+			Expect(result.Entity.Credentials["password"]).To(Equal("s3cr3t"))
+		})
+
+		It("should record interaction", func() {
+			sut.CreateUserProvidedServiceInstance(&types.CfUserProvidedService{Name: "ups"})
+
+			cassette := recorder.Cassette()
+			Expect(cassette.Interactions).To(HaveLen(1))
+			Expect(cassette.Interactions[0].Request.Method).To(Equal("POST"))
+			Expect(cassette.Interactions[0].Request.RequestURI()).To(Equal("/v2/user_provided_service_instances"))
+			Expect(cassette.Interactions[0].Request.Body).To(ContainSubstring(`"name":"ups"`))
+			Expect(cassette.Interactions[0].Response.StatusCode).To(Equal(http.StatusCreated))
+		})
+
+		It("should redact secrets", func() {
+			req, _ := http.NewRequest("POST", server.URL+"/oauth/token",
+				strings.NewReader("grant_type=client_credentials&client_secret=s3cr3t"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("Authorization", "Basic c2VjcmV0")
+			(&http.Client{Transport: recorder}).Do(req)
+
+			interaction := recorder.Cassette().Interactions[0]
+			Expect(interaction.Request.Headers["Authorization"]).To(Equal([]string{Redacted}))
+			Expect(interaction.Request.Body).NotTo(ContainSubstring("s3cr3t"))
+			Expect(interaction.Request.Body).To(ContainSubstring("grant_type=client_credentials"))
+			Expect(interaction.Response.Headers["Set-Cookie"]).To(Equal([]string{Redacted}))
+			Expect(interaction.Response.Body).NotTo(ContainSubstring("s3cr3t"))
+			Expect(interaction.Response.Body).To(ContainSubstring("ups_guid"))
+		})
+	})
+
+	It("should keep shape of redacted credentials so that replayed bindings decode", func() {
+		bindings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"total_results": 1, "resources": [{"metadata": {"guid": "binding_guid"},
+				"entity": {"app_guid": "app", "credentials": {"uri": "postgres://user:s3cr3t@db:5432/db",
+				"port": 5432, "tls": true, "hosts": ["db-0", "db-1"]}}}]}`))
+		}))
+		defer bindings.Close()
+		sut.BaseAddress = bindings.URL
+		_, err := sut.GetAppBindings("app")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Cassette().Interactions[0].Response.Body).NotTo(ContainSubstring("s3cr3t"))
+
+		replayed := api.CfAPI{BaseAddress: bindings.URL, Client: &http.Client{Transport: NewReplayer(recorder.Cassette())}}
+		result, err := replayed.GetAppBindings("app")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Resources[0].Meta.GUID).To(Equal("binding_guid"))
+		Expect(result.Resources[0].Entity.Credentials).To(Equal(map[string]interface{}{
+			"uri": Redacted, "port": float64(0), "tls": false, "hosts": []interface{}{Redacted, Redacted}}))
+	})
+
+	Describe("save", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir, _ = ioutil.TempDir("", "cctest")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		for _, ext := range []string{".yml", ".json"} {
+			ext := ext
+			It("should store cassette readable by LoadCassette as "+ext, func() {
+				sut.CreateUserProvidedServiceInstance(&types.CfUserProvidedService{Name: "ups"})
+				path := filepath.Join(dir, "cassette"+ext)
+
+				Expect(recorder.Save(path)).To(Succeed())
+				loaded, err := LoadCassette(path)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(loaded).To(Equal(recorder.Cassette()))
+			})
+		}
+	})
+})
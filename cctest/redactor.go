@@ -0,0 +1,147 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const Redacted = "[REDACTED]"
+
+// Redactor removes secrets from headers and bodies before they are stored in a cassette
+type Redactor struct {
+	// Headers lists names of headers whose values are replaced
+	Headers []string
+	// Fields lists JSON keys and form fields whose values are replaced, at any depth.
+	// Objects and arrays keep their shape, only values inside them are replaced.
+	Fields []string
+}
+
+// DefaultRedactor hides tokens, passwords and service credentials
+var DefaultRedactor = Redactor{
+	Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+	Fields: []string{"access_token", "refresh_token", "id_token", "client_secret", "password",
+		"auth_password", "credentials", "docker_credentials"},
+}
+
+// RedactHeaders returns copy of headers with secret values replaced
+func (r Redactor) RedactHeaders(headers http.Header) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	toReturn := map[string][]string{}
+	for name, values := range headers {
+		if r.isSecretHeader(name) {
+			toReturn[name] = []string{Redacted}
+			continue
+		}
+		toReturn[name] = append([]string{}, values...)
+	}
+	return toReturn
+}
+
+// RedactBody replaces secret fields of JSON or form encoded body. Other bodies are returned unchanged.
+func (r Redactor) RedactBody(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		for key := range values {
+			if r.isSecretField(key) {
+				values[key] = []string{Redacted}
+			}
+		}
+		return values.Encode()
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return string(body)
+	}
+	redacted, err := json.Marshal(r.redactValue(decoded))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func (r Redactor) redactValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range typed {
+			if r.isSecretField(key) {
+				typed[key] = redactLeaves(nested)
+			} else {
+				typed[key] = r.redactValue(nested)
+			}
+		}
+	case []interface{}:
+		for i, nested := range typed {
+			typed[i] = r.redactValue(nested)
+		}
+	}
+	return value
+}
+
+// redactLeaves replaces strings with Redacted, numbers with 0 and booleans with false,
+// so that replayed body still decodes into the same maps and structs
+func redactLeaves(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range typed {
+			typed[key] = redactLeaves(nested)
+		}
+		return typed
+	case []interface{}:
+		for i, nested := range typed {
+			typed[i] = redactLeaves(nested)
+		}
+		return typed
+	case string:
+		return Redacted
+	case float64:
+		return 0
+	case bool:
+		return false
+	}
+	return value
+}
+
+func (r Redactor) isSecretHeader(name string) bool {
+	for _, secret := range r.Headers {
+		if strings.EqualFold(secret, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Redactor) isSecretField(name string) bool {
+	for _, secret := range r.Fields {
+		if strings.EqualFold(secret, name) {
+			return true
+		}
+	}
+	return false
+}
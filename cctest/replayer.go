@@ -0,0 +1,154 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Matcher decides whether incoming request corresponds to recorded one.
+// Body is already redacted with the Replayer's Redactor.
+type Matcher func(req *http.Request, body string, recorded RecordedRequest) bool
+
+// MatchMethodAndURI compares method, path and query. Scheme and host are ignored
+// so cassettes may be replayed against any BaseAddress.
+func MatchMethodAndURI(req *http.Request, body string, recorded RecordedRequest) bool {
+	return req.Method == recorded.Method && req.URL.RequestURI() == recorded.RequestURI()
+}
+
+// MatchMethodAndURL compares method and full URL
+func MatchMethodAndURL(req *http.Request, body string, recorded RecordedRequest) bool {
+	return req.Method == recorded.Method && req.URL.String() == recorded.URL
+}
+
+// MatchMethodURIAndBody works like MatchMethodAndURI but also requires equal bodies.
+// JSON bodies are compared semantically.
+func MatchMethodURIAndBody(req *http.Request, body string, recorded RecordedRequest) bool {
+	return MatchMethodAndURI(req, body, recorded) && bodiesEqual(body, recorded.Body)
+}
+
+// Replayer is a http.RoundTripper serving responses from cassette.
+// Interactions are used in recorded order: every request gets the first
+// matching interaction which was not served yet.
+type Replayer struct {
+	Matcher  Matcher
+	Redactor Redactor
+	// AllowRepeats makes the last matching interaction to be served again
+	// once all matching ones were used, e.g. for polling loops
+	AllowRepeats bool
+
+	mutex    sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer creates Replayer matching requests with MatchMethodAndURI
+func NewReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{
+		Matcher:  MatchMethodAndURI,
+		Redactor: DefaultRedactor,
+		cassette: cassette,
+		used:     make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// NewReplayerFromFile loads cassette from file and creates Replayer for it
+func NewReplayerFromFile(path string) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(cassette), nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	redacted := r.Redactor.RedactBody(body, req.Header.Get("Content-Type"))
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	lastMatching := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !r.Matcher(req, redacted, interaction.Request) {
+			continue
+		}
+		lastMatching = i
+		if !r.used[i] {
+			r.used[i] = true
+			return newResponse(req, interaction.Response), nil
+		}
+	}
+	if lastMatching >= 0 && r.AllowRepeats {
+		return newResponse(req, r.cassette.Interactions[lastMatching].Response), nil
+	}
+	return nil, fmt.Errorf("No recorded interaction for %v %v", req.Method, req.URL)
+}
+
+// Unused returns interactions which were not served yet
+func (r *Replayer) Unused() []Interaction {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	toReturn := []Interaction{}
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] {
+			toReturn = append(toReturn, interaction)
+		}
+	}
+	return toReturn
+}
+
+func newResponse(req *http.Request, recorded RecordedResponse) *http.Response {
+	header := http.Header{}
+	for name, values := range recorded.Headers {
+		header[name] = append([]string{}, values...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}
+}
+
+func bodiesEqual(actual, recorded string) bool {
+	if actual == recorded {
+		return true
+	}
+	var actualJSON, recordedJSON interface{}
+	if json.Unmarshal([]byte(actual), &actualJSON) != nil || json.Unmarshal([]byte(recorded), &recordedJSON) != nil {
+		return false
+	}
+	return reflect.DeepEqual(actualJSON, recordedJSON)
+}
@@ -0,0 +1,125 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cctest
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"net/http"
+)
+
+var _ = Describe("Replayer", func() {
+
+	var cassette *Cassette
+
+	BeforeEach(func() {
+		cassette = &Cassette{Interactions: []Interaction{
+			{
+				Request: RecordedRequest{Method: "GET", URL: "https://api.example.com/v2/apps/guid/summary"},
+				Response: RecordedResponse{StatusCode: 200, Body: `{"guid":"guid","name":"first"}`,
+					Headers: map[string][]string{"Content-Type": {"application/json"}}},
+			},
+			{
+				Request:  RecordedRequest{Method: "GET", URL: "https://api.example.com/v2/apps/guid/summary"},
+				Response: RecordedResponse{StatusCode: 200, Body: `{"guid":"guid","name":"second"}`},
+			},
+			{
+				Request:  RecordedRequest{Method: "POST", URL: "https://api.example.com/v2/routes", Body: `{"host":"a","domain_guid":"d","space_guid":"s"}`},
+				Response: RecordedResponse{StatusCode: 201, Body: `{"metadata":{"guid":"route_a"}}`},
+			},
+			{
+				Request:  RecordedRequest{Method: "POST", URL: "https://api.example.com/v2/routes", Body: `{"host":"b","domain_guid":"d","space_guid":"s"}`},
+				Response: RecordedResponse{StatusCode: 201, Body: `{"metadata":{"guid":"route_b"}}`},
+			},
+		}}
+	})
+
+	newSut := func(replayer *Replayer) *api.CfAPI {
+		return &api.CfAPI{BaseAddress: "http://localhost", Client: &http.Client{Transport: replayer}}
+	}
+
+	Context("with default matcher", func() {
+		It("should serve matching interactions in recorded order", func() {
+			sut := newSut(NewReplayer(cassette))
+
+			first, err := sut.GetAppSummary("guid")
+			Expect(err).NotTo(HaveOccurred())
+			second, err := sut.GetAppSummary("guid")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first.Name).To(Equal("first"))
+			Expect(second.Name).To(Equal("second"))
+		})
+
+		It("should fail when interactions are exhausted", func() {
+			sut := newSut(NewReplayer(cassette))
+			sut.GetAppSummary("guid")
+			sut.GetAppSummary("guid")
+
+			result, err := sut.GetAppSummary("guid")
+
+			Expect(err).To(HaveOccurred())
+			Expect(result).To(BeNil())
+		})
+
+		It("should repeat the last interaction when allowed", func() {
+			replayer := NewReplayer(cassette)
+			replayer.AllowRepeats = true
+			sut := newSut(replayer)
+			sut.GetAppSummary("guid")
+			sut.GetAppSummary("guid")
+
+			result, err := sut.GetAppSummary("guid")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Name).To(Equal("second"))
+		})
+
+		It("should report unused interactions", func() {
+			replayer := NewReplayer(cassette)
+			newSut(replayer).GetAppSummary("guid")
+
+			Expect(replayer.Unused()).To(HaveLen(3))
+		})
+	})
+
+	Context("with body matcher", func() {
+		It("should choose interaction by request body", func() {
+			replayer := NewReplayer(cassette)
+			replayer.Matcher = MatchMethodURIAndBody
+			sut := newSut(replayer)
+
+			route, err := sut.CreateRoute(&types.CfCreateRouteRequest{Host: "b", DomainGUID: "d", SpaceGUID: "s"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route.Meta.GUID).To(Equal("route_b"))
+		})
+	})
+
+	Context("with full URL matcher", func() {
+		It("should not match other hosts", func() {
+			replayer := NewReplayer(cassette)
+			replayer.Matcher = MatchMethodAndURL
+
+			_, err := newSut(replayer).GetAppSummary("guid")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
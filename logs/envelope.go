@@ -0,0 +1,64 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// envelopeBatch is the body of log-cache read response
+type envelopeBatch struct {
+	Envelopes struct {
+		Batch []envelope `json:"batch"`
+	} `json:"envelopes"`
+}
+
+type envelope struct {
+	Timestamp  string            `json:"timestamp"`
+	SourceID   string            `json:"source_id"`
+	InstanceID string            `json:"instance_id"`
+	Tags       map[string]string `json:"tags"`
+	Log        *struct {
+		Payload string `json:"payload"`
+		Type    string `json:"type"`
+	} `json:"log"`
+}
+
+// toMessage converts log envelope. Envelopes of other kinds, e.g. metrics, are not messages.
+func (e envelope) toMessage() (*LogMessage, bool, error) {
+	if e.Log == nil {
+		return nil, false, nil
+	}
+	nanos, err := strconv.ParseInt(e.Timestamp, 10, 64)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid envelope timestamp %v", e.Timestamp)
+	}
+	payload, err := base64.StdEncoding.DecodeString(e.Log.Payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid envelope payload: %v", err)
+	}
+	return &LogMessage{
+		SourceType: e.Tags["source_type"],
+		Instance:   e.InstanceID,
+		Timestamp:  time.Unix(0, nanos).UTC(),
+		Text:       string(payload),
+		Error:      e.Log.Type == "ERR",
+	}, true, nil
+}
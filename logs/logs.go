@@ -0,0 +1,191 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/helpers"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultRecentLimit  = 1000
+	DefaultPollInterval = time.Second
+)
+
+// LogMessage is single line logged by the app or by platform component on its behalf
+type LogMessage struct {
+	// SourceType is e.g. "APP/PROC/WEB", "STG", "RTR" or "API"
+	SourceType string
+	Instance   string
+	Timestamp  time.Time
+	Text       string
+	// Error tells that the line was written to stderr
+	Error bool
+}
+
+// String formats message like cf logs does
+func (m LogMessage) String() string {
+	stream := "OUT"
+	if m.Error {
+		stream = "ERR"
+	}
+	return fmt.Sprintf("%v [%v/%v] %v %v", m.Timestamp.Format(time.RFC3339Nano), m.SourceType, m.Instance, stream, m.Text)
+}
+
+// Client reads app logs from log-cache-style HTTP endpoint
+type Client struct {
+	Endpoint string
+	*http.Client
+	PollInterval time.Duration
+}
+
+// NewClient creates client for log-cache of the foundation. Its address is derived from
+// doppler_logging_endpoint of /v2/info, the same way cf CLI does it. CC token is used for log-cache too.
+func NewClient(cf *api.CfAPI) (*Client, error) {
+	info, err := cf.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.DopplerLoggingEndpoint == "" {
+		return nil, errors.Annotate(types.LogsUnavailableError, "CC does not expose doppler_logging_endpoint")
+	}
+	endpoint := strings.Replace(info.DopplerLoggingEndpoint, "doppler", "log-cache", 1)
+	endpoint = strings.Replace(strings.Replace(endpoint, "wss://", "https://", 1), "ws://", "http://", 1)
+	return NewClientWithEndpoint(endpoint, cf.Client), nil
+}
+
+// NewClientWithEndpoint creates client for log-cache at given address, e.g. a local stand-in
+func NewClientWithEndpoint(endpoint string, client *http.Client) *Client {
+	return &Client{Endpoint: strings.TrimSuffix(endpoint, "/"), Client: client, PollInterval: DefaultPollInterval}
+}
+
+// Recent returns up to limit most recent messages of the app, oldest first.
+// Non-positive limit means DefaultRecentLimit.
+func (c *Client) Recent(ctx context.Context, appGUID string, limit int) ([]LogMessage, error) {
+	if limit <= 0 {
+		limit = DefaultRecentLimit
+	}
+	query := url.Values{}
+	query.Set("envelope_types", "LOG")
+	query.Set("descending", "true")
+	query.Set("limit", fmt.Sprint(limit))
+	messages, err := c.read(ctx, appGUID, query)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// TaskOutput returns recent messages logged by the task of the app, oldest first.
+// Task processes log with source type APP/TASK/<task name>.
+func (c *Client) TaskOutput(ctx context.Context, appGUID, taskName string, limit int) ([]LogMessage, error) {
+	messages, err := c.Recent(ctx, appGUID, limit)
+	if err != nil {
+		return nil, err
+	}
+	toReturn := []LogMessage{}
+	for _, message := range messages {
+		if message.SourceType == "APP/TASK/"+taskName {
+			toReturn = append(toReturn, message)
+		}
+	}
+	return toReturn, nil
+}
+
+// Stream passes messages of the app logged since the call to handler, in order, until ctx is cancelled.
+// Cancellation ends the stream with nil error. Log-cache is polled every PollInterval.
+func (c *Client) Stream(ctx context.Context, appGUID string, handler func(LogMessage)) error {
+	start := time.Now()
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	for {
+		query := url.Values{}
+		query.Set("envelope_types", "LOG")
+		query.Set("start_time", fmt.Sprint(start.UnixNano()))
+		messages, err := c.read(ctx, appGUID, query)
+		if ctx.Err() != nil {
+			log.Debugf("Streaming logs of app %v cancelled", appGUID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, message := range messages {
+			handler(message)
+			// start time is inclusive, so next poll starts right after the last message
+			start = message.Timestamp.Add(time.Nanosecond)
+		}
+		select {
+		case <-ctx.Done():
+			log.Debugf("Streaming logs of app %v cancelled", appGUID)
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (c *Client) read(ctx context.Context, appGUID string, query url.Values) ([]LogMessage, error) {
+	address := fmt.Sprintf("%v/api/v1/read/%v?%v", c.Endpoint, appGUID, query.Encode())
+	log.Debugf("Reading logs: %v", address)
+	request, err := http.NewRequest("GET", address, nil)
+	if err != nil {
+		return nil, errors.Wrap(types.LogsFetchFailedError, err)
+	}
+	resp, err := ctxhttp.Do(ctx, c.Client, request)
+	if err != nil {
+		log.Errorf("Could not read logs of app %v: [%v]", appGUID, err)
+		return nil, errors.Wrap(types.LogsFetchFailedError, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg := fmt.Sprintf("Reading logs of app %v failed: (%d) %v", appGUID, resp.StatusCode, helpers.ReaderToString(resp.Body))
+		log.Error(msg)
+		return nil, errors.Annotate(types.LogsFetchFailedError, msg)
+	}
+
+	batch := envelopeBatch{}
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, errors.Annotate(types.LogsFetchFailedError, "Invalid log-cache response: "+err.Error())
+	}
+	messages := []LogMessage{}
+	for _, envelope := range batch.Envelopes.Batch {
+		message, ok, err := envelope.toMessage()
+		if err != nil {
+			return nil, errors.Annotate(types.LogsFetchFailedError, err.Error())
+		}
+		if ok {
+			messages = append(messages, *message)
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,165 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logs
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"golang.org/x/net/context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var _ = Describe("Logs", func() {
+
+	const appGUID = "app-guid"
+
+	var (
+		logCache *cctest.FakeLogCache
+		sut      *Client
+		base     time.Time
+	)
+
+	BeforeEach(func() {
+		logCache = cctest.NewFakeLogCache()
+		sut = NewClientWithEndpoint(logCache.URL, http.DefaultClient)
+		sut.PollInterval = 5 * time.Millisecond
+		base = time.Date(2016, 9, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	AfterEach(func() {
+		logCache.Close()
+	})
+
+	Describe("NewClient", func() {
+		var fake *cctest.FakeCC
+
+		BeforeEach(func() {
+			fake = cctest.NewFakeCC()
+		})
+
+		AfterEach(func() {
+			fake.Close()
+		})
+
+		It("should derive log-cache address from doppler endpoint", func() {
+			fake.SetInfo(types.CfInfo{APIVersion: "2.65.0", DopplerLoggingEndpoint: "wss://doppler.example.com:443"})
+
+			client, err := NewClient(&api.CfAPI{BaseAddress: fake.URL, Client: http.DefaultClient})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.Endpoint).To(Equal("https://log-cache.example.com:443"))
+		})
+
+		It("should fail when doppler endpoint is not exposed", func() {
+			_, err := NewClient(&api.CfAPI{BaseAddress: fake.URL, Client: http.DefaultClient})
+
+			Expect(errors.Cause(err)).To(Equal(types.LogsUnavailableError))
+		})
+	})
+
+	Describe("Recent", func() {
+		It("should return latest messages oldest first", func() {
+			logCache.AddLog(appGUID, "STG", "0", "staging", false, base)
+			logCache.AddLog(appGUID, "APP/PROC/WEB", "1", "boom", true, base.Add(2*time.Second))
+			logCache.AddMetric(appGUID, base.Add(3*time.Second))
+			logCache.AddLog(appGUID, "APP/PROC/WEB", "0", "started", false, base.Add(time.Second))
+			logCache.AddLog("other-app", "APP/PROC/WEB", "0", "other", false, base)
+
+			messages, err := sut.Recent(context.Background(), appGUID, 2)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messages).To(Equal([]LogMessage{
+				{SourceType: "APP/PROC/WEB", Instance: "0", Timestamp: base.Add(time.Second), Text: "started"},
+				{SourceType: "APP/PROC/WEB", Instance: "1", Timestamp: base.Add(2 * time.Second), Text: "boom", Error: true},
+			}))
+			Expect(messages[1].String()).To(Equal("2016-09-01T12:00:02Z [APP/PROC/WEB/1] ERR boom"))
+		})
+
+		It("should return log-cache error", func() {
+			logCache.FailWith = http.StatusUnauthorized
+
+			_, err := sut.Recent(context.Background(), appGUID, 0)
+
+			Expect(errors.Cause(err)).To(Equal(types.LogsFetchFailedError))
+			Expect(errors.Details(err)).To(ContainSubstring("(401)"))
+		})
+	})
+
+	It("should return output of the task only", func() {
+		logCache.AddLog(appGUID, "APP/TASK/migrate", "0", "migrating", false, base)
+		logCache.AddLog(appGUID, "APP/PROC/WEB", "0", "serving", false, base.Add(time.Second))
+		logCache.AddLog(appGUID, "APP/TASK/migrate", "0", "failed", true, base.Add(2*time.Second))
+		logCache.AddLog(appGUID, "APP/TASK/other", "0", "other", false, base.Add(3*time.Second))
+
+		messages, err := sut.TaskOutput(context.Background(), appGUID, "migrate", 0)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(HaveLen(2))
+		Expect(messages[0].Text).To(Equal("migrating"))
+		Expect(messages[1].Error).To(BeTrue())
+	})
+
+	Describe("Stream", func() {
+		It("should pass new messages in order until cancelled", func() {
+			logCache.AddLog(appGUID, "APP/PROC/WEB", "0", "old", false, time.Now().Add(-time.Minute))
+			ctx, cancel := context.WithCancel(context.Background())
+			var mutex sync.Mutex
+			received := []string{}
+			done := make(chan error)
+
+			go func() {
+				done <- sut.Stream(ctx, appGUID, func(m LogMessage) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					received = append(received, m.Text)
+				})
+			}()
+			now := time.Now()
+			logCache.AddLog(appGUID, "APP/PROC/WEB", "0", "first", false, now.Add(time.Millisecond))
+			logCache.AddLog(appGUID, "APP/PROC/WEB", "0", "second", false, now.Add(2*time.Millisecond))
+			Eventually(func() []string {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return append([]string{}, received...)
+			}).Should(Equal([]string{"first", "second"}))
+			logCache.AddLog(appGUID, "APP/PROC/WEB", "0", "third", false, now.Add(3*time.Millisecond))
+			Eventually(func() int {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return len(received)
+			}).Should(Equal(3))
+			cancel()
+
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(received).To(Equal([]string{"first", "second", "third"}))
+		})
+
+		It("should stop on log-cache error", func() {
+			logCache.FailWith = http.StatusInternalServerError
+
+			err := sut.Stream(context.Background(), appGUID, func(LogMessage) {})
+
+			Expect(errors.Cause(err)).To(Equal(types.LogsFetchFailedError))
+		})
+	})
+})
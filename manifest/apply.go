@@ -0,0 +1,273 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest
+
+import (
+	"fmt"
+	log "github.com/cihub/seelog"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"strings"
+)
+
+// ApplyOptions control how manifest applications are pushed
+type ApplyOptions struct {
+	// NoStart leaves applications stopped once their bits are pushed
+	NoStart bool
+	// Wait is used for bits upload and application start
+	Wait api.WaitOptions
+}
+
+// AppResult describes what was done for single application of the manifest
+type AppResult struct {
+	Name          string
+	GUID          string
+	Created       bool
+	MappedRoutes  []string
+	BoundServices []string
+	Upload        *api.UploadReport
+	// Instances is nil when application was not started
+	Instances *api.AppInstancesReport
+}
+
+type resolvedRoute struct {
+	url        string
+	host       string
+	domainGUID string
+}
+
+// ApplyManifest creates or updates applications of the manifest in space, maps their routes,
+// binds their services, pushes their bits and starts them. Started applications being updated
+// are stopped before bits upload. Applications are applied in manifest order, the first failure
+// stops the operation and is returned together with results of applications applied so far.
+func ApplyManifest(c *api.CfAPI, spaceGUID string, m *Manifest, options ApplyOptions) ([]AppResult, error) {
+	results := []AppResult{}
+	for _, app := range m.Applications {
+		result, err := applyApplication(c, spaceGUID, app, options)
+		if err != nil {
+			log.Errorf("Could not apply manifest application %v: %v", app.Name, err)
+			return results, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+func applyApplication(c *api.CfAPI, spaceGUID string, app Application, options ApplyOptions) (*AppResult, error) {
+	log.Infof("Applying manifest application %v in space %v", app.Name, spaceGUID)
+	if len(app.Buildpacks) > 1 {
+		return nil, invalidManifest("application %v: multiple buildpacks are not supported", app.Name)
+	}
+	// routes and bits are checked first, so invalid manifest does not leave half applied app behind
+	routes, err := resolveRoutes(c, app.Routes)
+	if err != nil {
+		return nil, err
+	}
+	source, err := api.BitsFromPath(app.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AppResult{Name: app.Name}
+	appResource, err := c.GetAppByName(spaceGUID, app.Name)
+	if err == types.EntityNotFoundError {
+		entity := types.CfApp{Name: app.Name, SpaceGUID: spaceGUID, State: types.AppStopped}
+		setAppFields(&entity, app)
+		if appResource, err = c.CreateApp(entity); err != nil {
+			return nil, err
+		}
+		result.Created = true
+	} else if err != nil {
+		return nil, err
+	} else {
+		setAppFields(&appResource.Entity, app)
+		appResource.Entity.State = types.AppStopped
+		if err := c.UpdateApp(appResource); err != nil {
+			return nil, err
+		}
+	}
+	result.GUID = appResource.Meta.GUID
+
+	if result.MappedRoutes, err = mapRoutes(c, spaceGUID, result.GUID, routes); err != nil {
+		return result, err
+	}
+	if result.BoundServices, err = bindServices(c, spaceGUID, result.GUID, app.Services); err != nil {
+		return result, err
+	}
+	uploadOptions := api.UploadOptions{Wait: options.Wait, MatchResources: true}
+	if result.Upload, err = c.UploadBits(result.GUID, source, uploadOptions); err != nil {
+		return result, err
+	}
+	if !options.NoStart {
+		if result.Instances, err = c.StartAppAndWait(appResource, options.Wait); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// setAppFields copies properties given in manifest into app entity. Manifest env is merged into existing one.
+func setAppFields(entity *types.CfApp, app Application) {
+	if app.Memory > 0 {
+		entity.Memory = app.Memory
+	}
+	if app.DiskQuota > 0 {
+		entity.DiskQuota = app.DiskQuota
+	}
+	if app.Instances != nil {
+		entity.InstanceCount = *app.Instances
+	}
+	if len(app.Buildpacks) == 1 {
+		entity.BuildpackUrl = app.Buildpacks[0]
+	}
+	if app.Command != "" {
+		entity.Command = app.Command
+	}
+	if len(app.Env) > 0 {
+		env := map[string]interface{}{}
+		for key, value := range entity.Envs {
+			env[key] = value
+		}
+		for key, value := range app.Env {
+			env[key] = value
+		}
+		entity.Envs = env
+	}
+	if app.HealthCheckType != "" {
+		entity.HealthCheckType = app.HealthCheckType
+	}
+	if app.HealthCheckHTTPEndpoint != "" {
+		entity.HealthCheckHTTPEndpoint = app.HealthCheckHTTPEndpoint
+	}
+	if app.Timeout > 0 {
+		entity.HealthCheckTimeout = app.Timeout
+	}
+}
+
+// resolveRoutes finds domains of routes given as "host.domain". The longest leading part
+// which is not a domain becomes the host, so route on a domain itself has empty host.
+func resolveRoutes(c *api.CfAPI, routes []string) ([]resolvedRoute, error) {
+	resolved := []resolvedRoute{}
+	for _, route := range routes {
+		if strings.ContainsAny(route, "/:") {
+			return nil, invalidManifest("route %v: route paths and ports are not supported", route)
+		}
+		labels := strings.Split(route, ".")
+		found := false
+		for i := 0; i < len(labels) && !found; i++ {
+			domain, err := c.GetDomainByName(strings.Join(labels[i:], "."))
+			if err == types.EntityNotFoundError {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			resolved = append(resolved, resolvedRoute{
+				url:        route,
+				host:       strings.Join(labels[:i], "."),
+				domainGUID: domain.Meta.GUID,
+			})
+			found = true
+		}
+		if !found {
+			return nil, errors.Annotate(types.EntityNotFoundError, fmt.Sprintf("No domain found for route %v", route))
+		}
+	}
+	return resolved, nil
+}
+
+// mapRoutes maps routes to app, creating those missing in space. It returns routes which were not mapped before.
+func mapRoutes(c *api.CfAPI, spaceGUID, appGUID string, routes []resolvedRoute) ([]string, error) {
+	mapped := []string{}
+	if len(routes) == 0 {
+		return mapped, nil
+	}
+	appRoutes, err := c.GetAppRoutes(appGUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		routeGUID, err := findOrCreateRoute(c, spaceGUID, route)
+		if err != nil {
+			return mapped, err
+		}
+		alreadyMapped := false
+		for _, appRoute := range appRoutes.Resources {
+			alreadyMapped = alreadyMapped || appRoute.Meta.GUID == routeGUID
+		}
+		if alreadyMapped {
+			log.Debugf("Route %v already mapped to app %v", route.url, appGUID)
+			continue
+		}
+		if err := c.AssociateRoute(appGUID, routeGUID); err != nil {
+			return mapped, err
+		}
+		mapped = append(mapped, route.url)
+	}
+	return mapped, nil
+}
+
+func findOrCreateRoute(c *api.CfAPI, spaceGUID string, route resolvedRoute) (string, error) {
+	existing, err := c.GetSpaceRoutesForHostname(spaceGUID, route.host)
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range existing.Resources {
+		if candidate.Entity.Host == route.host && candidate.Entity.DomainGUID == route.domainGUID {
+			return candidate.Meta.GUID, nil
+		}
+	}
+	created, err := c.CreateRoute(&types.CfCreateRouteRequest{Host: route.host, DomainGUID: route.domainGUID, SpaceGUID: spaceGUID})
+	if err != nil {
+		return "", err
+	}
+	return created.Meta.GUID, nil
+}
+
+// bindServices binds service instances of given names to app. It returns services which were not bound before.
+func bindServices(c *api.CfAPI, spaceGUID, appGUID string, services []string) ([]string, error) {
+	bound := []string{}
+	if len(services) == 0 {
+		return bound, nil
+	}
+	bindings, err := c.GetAppBindings(appGUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range services {
+		instance, err := c.GetServiceInstanceByName(spaceGUID, name)
+		if err == types.EntityNotFoundError {
+			return bound, errors.Annotate(types.EntityNotFoundError,
+				fmt.Sprintf("Service instance %v not found in space %v", name, spaceGUID))
+		} else if err != nil {
+			return bound, err
+		}
+		alreadyBound := false
+		for _, binding := range bindings.Resources {
+			alreadyBound = alreadyBound || binding.Entity.ServiceInstanceGUID == instance.Meta.GUID
+		}
+		if alreadyBound {
+			log.Debugf("Service instance %v already bound to app %v", name, appGUID)
+			continue
+		}
+		if _, err := c.CreateServiceBinding(types.NewCfServiceBindingRequest(appGUID, instance.Meta.GUID)); err != nil {
+			return bound, err
+		}
+		bound = append(bound, name)
+	}
+	return bound, nil
+}
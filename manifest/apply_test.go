@@ -0,0 +1,170 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Apply manifest", func() {
+
+	var (
+		fake    *cctest.FakeCC
+		sut     *api.CfAPI
+		dir     string
+		options ApplyOptions
+	)
+
+	BeforeEach(func() {
+		fake = cctest.NewFakeCC()
+		sut = &api.CfAPI{BaseAddress: fake.URL, Client: http.DefaultClient}
+		fake.AddDomain("apps.example.com")
+		var err error
+		dir, err = ioutil.TempDir("", "manifest-apply-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "start.sh"), []byte("run"), 0755)).To(Succeed())
+		options = ApplyOptions{Wait: api.WaitOptions{PollInterval: time.Millisecond}}
+	})
+
+	AfterEach(func() {
+		fake.Close()
+		os.RemoveAll(dir)
+	})
+
+	parse := func(content string) *Manifest {
+		manifest, err := Parse([]byte(content), dir, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return manifest
+	}
+
+	It("should create app with routes and services, push bits and start it", func() {
+		dbGUID := fake.AddServiceInstance(types.CfServiceInstanceCreateRequest{Name: "db", SpaceGUID: "space"})
+		manifest := parse(`
+applications:
+- name: app
+  memory: 256M
+  instances: 2
+  env: {MODE: test}
+  health-check-type: process
+  routes:
+  - route: app.apps.example.com
+  - route: apps.example.com
+  services: [db]
+`)
+
+		results, err := ApplyManifest(sut, "space", manifest, options)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		result := results[0]
+		Expect(result.Created).To(BeTrue())
+		Expect(result.MappedRoutes).To(Equal([]string{"app.apps.example.com", "apps.example.com"}))
+		Expect(result.BoundServices).To(Equal([]string{"db"}))
+		Expect(result.Upload.Files).To(Equal(1))
+		Expect(result.Instances.Instances).To(HaveLen(2))
+
+		app, _ := fake.App(result.GUID)
+		Expect(app.State).To(Equal(types.AppStarted))
+		Expect(app.Memory).To(Equal(int64(256)))
+		Expect(app.InstanceCount).To(Equal(2))
+		Expect(app.Envs).To(Equal(map[string]interface{}{"MODE": "test"}))
+		Expect(app.HealthCheckType).To(Equal("process"))
+		hosts := []string{}
+		for _, routeGUID := range fake.AppRoutes(result.GUID) {
+			route, _ := fake.Route(routeGUID)
+			hosts = append(hosts, route.Host)
+		}
+		Expect(hosts).To(ConsistOf("app", ""))
+		bindings := fake.Bindings()
+		Expect(bindings).To(HaveLen(1))
+		Expect(bindings[0].AppGUID).To(Equal(result.GUID))
+		Expect(bindings[0].ServiceInstanceGUID).To(Equal(dbGUID))
+		Expect(fake.AppFiles(result.GUID)).To(HaveKey("start.sh"))
+	})
+
+	It("should update existing app keeping its env and mappings", func() {
+		manifest := parse(`
+applications:
+- name: app
+  routes: [{route: app.apps.example.com}]
+`)
+		results, err := ApplyManifest(sut, "space", manifest, options)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = sut.PatchAppEnv(results[0].GUID, map[string]interface{}{"KEPT": "yes"}, nil, api.EnvApply{})
+		Expect(err).NotTo(HaveOccurred())
+
+		manifest = parse(`
+applications:
+- name: app
+  memory: 1G
+  env: {ADDED: "yes"}
+  routes: [{route: app.apps.example.com}]
+`)
+		results, err = ApplyManifest(sut, "space", manifest, ApplyOptions{NoStart: true, Wait: options.Wait})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[0].Created).To(BeFalse())
+		Expect(results[0].MappedRoutes).To(BeEmpty())
+		Expect(results[0].Instances).To(BeNil())
+		app, _ := fake.App(results[0].GUID)
+		Expect(app.State).To(Equal(types.AppStopped))
+		Expect(app.Memory).To(Equal(int64(1024)))
+		Expect(app.Envs).To(Equal(map[string]interface{}{"KEPT": "yes", "ADDED": "yes"}))
+		Expect(fake.AppRoutes(results[0].GUID)).To(HaveLen(1))
+	})
+
+	It("should not create app when route domain is unknown", func() {
+		manifest := parse(`
+applications:
+- name: app
+  routes: [{route: app.unknown.org}]
+`)
+
+		results, err := ApplyManifest(sut, "space", manifest, options)
+
+		Expect(errors.Cause(err)).To(Equal(types.EntityNotFoundError))
+		Expect(results).To(BeEmpty())
+		_, err = sut.GetAppByName("space", "app")
+		Expect(err).To(Equal(types.EntityNotFoundError))
+	})
+
+	It("should stop on missing service instance and return results applied so far", func() {
+		manifest := parse(`
+applications:
+- name: first
+- name: second
+  services: [missing]
+`)
+
+		results, err := ApplyManifest(sut, "space", manifest, options)
+
+		Expect(errors.Cause(err)).To(Equal(types.EntityNotFoundError))
+		Expect(errors.Details(err)).To(ContainSubstring("Service instance missing not found"))
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Name).To(Equal("first"))
+	})
+})
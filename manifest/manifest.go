@@ -0,0 +1,359 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest
+
+import (
+	"fmt"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Manifest describes applications of cf manifest.yml in version 1 format
+type Manifest struct {
+	Applications []Application
+}
+
+// Application describes single application of the manifest. Memory and DiskQuota are in MB.
+// Zero values and nil Instances mean the property was not given in the manifest.
+type Application struct {
+	Name                    string
+	Memory                  int64
+	DiskQuota               int64
+	Instances               *int
+	Buildpacks              []string
+	Command                 string
+	Env                     map[string]interface{}
+	Routes                  []string
+	NoRoute                 bool
+	Services                []string
+	HealthCheckType         string
+	HealthCheckHTTPEndpoint string
+	Timeout                 int
+	// Path is absolute path of the app bits, directory of the manifest by default
+	Path string
+}
+
+type rawApplication struct {
+	Name                    string                 `yaml:"name"`
+	Memory                  string                 `yaml:"memory"`
+	DiskQuota               string                 `yaml:"disk_quota"`
+	Instances               *int                   `yaml:"instances"`
+	Buildpack               string                 `yaml:"buildpack"`
+	Buildpacks              []string               `yaml:"buildpacks"`
+	Command                 string                 `yaml:"command"`
+	Env                     map[string]interface{} `yaml:"env"`
+	Routes                  []rawRoute             `yaml:"routes"`
+	NoRoute                 bool                   `yaml:"no-route"`
+	Services                []string               `yaml:"services"`
+	HealthCheckType         string                 `yaml:"health-check-type"`
+	HealthCheckHTTPEndpoint string                 `yaml:"health-check-http-endpoint"`
+	Timeout                 int                    `yaml:"timeout"`
+	Path                    string                 `yaml:"path"`
+}
+
+type rawRoute struct {
+	Route string `yaml:"route"`
+}
+
+var sizePattern = regexp.MustCompile(`^(\d+)\s*(M|MB|G|GB|T|TB)?$`)
+
+// Load reads manifest from file, together with manifests it inherits from.
+// Variables given as ((name)) are substituted with vars.
+func Load(path string, vars map[string]interface{}) (*Manifest, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Annotate(types.InvalidManifestError, err.Error())
+	}
+	tree, err := loadTree(absPath, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return build(tree, filepath.Dir(absPath), vars)
+}
+
+// Parse reads manifest from content. Inherited manifests and app paths are resolved relative to baseDir.
+func Parse(content []byte, baseDir string, vars map[string]interface{}) (*Manifest, error) {
+	absDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, errors.Annotate(types.InvalidManifestError, err.Error())
+	}
+	tree, err := parseTree(content, absDir, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return build(tree, absDir, vars)
+}
+
+// ReadVarsFile reads YAML file of variables to be substituted in manifest
+func ReadVarsFile(path string) (map[string]interface{}, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Annotate(types.InvalidManifestError, err.Error())
+	}
+	raw := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, invalidManifest("vars file %v is not valid YAML: %v", path, err)
+	}
+	return normalizeValue(raw).(map[string]interface{}), nil
+}
+
+func loadTree(path string, visited map[string]bool) (map[string]interface{}, error) {
+	if visited[path] {
+		return nil, invalidManifest("manifest %v inherits from itself", path)
+	}
+	visited[path] = true
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Annotate(types.InvalidManifestError, err.Error())
+	}
+	return parseTree(content, filepath.Dir(path), visited)
+}
+
+// parseTree decodes manifest into generic tree and merges it over the manifest it inherits from
+func parseTree(content []byte, dir string, visited map[string]bool) (map[string]interface{}, error) {
+	raw := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, invalidManifest("manifest is not valid YAML: %v", err)
+	}
+	tree := normalizeValue(raw).(map[string]interface{})
+
+	parent, ok := tree["inherit"]
+	if !ok {
+		return tree, nil
+	}
+	delete(tree, "inherit")
+	parentPath, ok := parent.(string)
+	if !ok || parentPath == "" {
+		return nil, invalidManifest("inherit shall be a path to manifest")
+	}
+	if !filepath.IsAbs(parentPath) {
+		parentPath = filepath.Join(dir, parentPath)
+	}
+	parentTree, err := loadTree(parentPath, visited)
+	if err != nil {
+		return nil, err
+	}
+	return mergeTrees(parentTree, tree), nil
+}
+
+// mergeTrees returns parent with child properties merged over it. Nested maps, e.g. env, are merged
+// and applications of the same name are merged with each other.
+func mergeTrees(parent, child map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range child {
+		parentValue, ok := merged[key]
+		if !ok {
+			merged[key] = value
+			continue
+		}
+		parentMap, parentIsMap := parentValue.(map[string]interface{})
+		childMap, childIsMap := value.(map[string]interface{})
+		parentList, parentIsList := parentValue.([]interface{})
+		childList, childIsList := value.([]interface{})
+		switch {
+		case parentIsMap && childIsMap:
+			merged[key] = mergeTrees(parentMap, childMap)
+		case key == "applications" && parentIsList && childIsList:
+			merged[key] = mergeApplications(parentList, childList)
+		default:
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+func mergeApplications(parent, child []interface{}) []interface{} {
+	merged := append([]interface{}{}, parent...)
+	for _, childApp := range child {
+		childMap, ok := childApp.(map[string]interface{})
+		if !ok {
+			merged = append(merged, childApp)
+			continue
+		}
+		found := false
+		for i, parentApp := range merged {
+			parentMap, ok := parentApp.(map[string]interface{})
+			if ok && childMap["name"] != nil && parentMap["name"] == childMap["name"] {
+				merged[i] = mergeTrees(parentMap, childMap)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, childMap)
+		}
+	}
+	return merged
+}
+
+// build substitutes variables and decodes applications. Top level properties other than
+// applications apply to every application, which may override them.
+func build(tree map[string]interface{}, baseDir string, vars map[string]interface{}) (*Manifest, error) {
+	interpolated, err := interpolate(tree, vars)
+	if err != nil {
+		return nil, err
+	}
+	tree = interpolated.(map[string]interface{})
+
+	apps, ok := tree["applications"].([]interface{})
+	if !ok || len(apps) == 0 {
+		return nil, invalidManifest("manifest has no applications")
+	}
+	globals := map[string]interface{}{}
+	for key, value := range tree {
+		if key != "applications" {
+			globals[key] = value
+		}
+	}
+
+	manifest := &Manifest{}
+	names := map[string]bool{}
+	for i, app := range apps {
+		appMap, ok := app.(map[string]interface{})
+		if !ok {
+			return nil, invalidManifest("application %d is not a map", i)
+		}
+		application, err := decodeApplication(mergeTrees(globals, appMap), baseDir)
+		if err != nil {
+			return nil, err
+		}
+		if names[application.Name] {
+			return nil, invalidManifest("application %v is given more than once", application.Name)
+		}
+		names[application.Name] = true
+		manifest.Applications = append(manifest.Applications, *application)
+	}
+	return manifest, nil
+}
+
+func decodeApplication(appMap map[string]interface{}, baseDir string) (*Application, error) {
+	encoded, err := yaml.Marshal(appMap)
+	if err != nil {
+		return nil, errors.Annotate(types.InvalidManifestError, err.Error())
+	}
+	raw := rawApplication{}
+	if err := yaml.Unmarshal(encoded, &raw); err != nil {
+		return nil, invalidManifest("application %v: %v", appMap["name"], err)
+	}
+	if raw.Name == "" {
+		return nil, invalidManifest("application name is required")
+	}
+
+	app := &Application{
+		Name:                    raw.Name,
+		Instances:               raw.Instances,
+		Buildpacks:              raw.Buildpacks,
+		Command:                 raw.Command,
+		NoRoute:                 raw.NoRoute,
+		Services:                raw.Services,
+		HealthCheckType:         raw.HealthCheckType,
+		HealthCheckHTTPEndpoint: raw.HealthCheckHTTPEndpoint,
+		Timeout:                 raw.Timeout,
+		Path:                    baseDir,
+	}
+	if app.Memory, err = toMegabytes(raw.Memory); err != nil {
+		return nil, invalidManifest("application %v: invalid memory: %v", raw.Name, raw.Memory)
+	}
+	if app.DiskQuota, err = toMegabytes(raw.DiskQuota); err != nil {
+		return nil, invalidManifest("application %v: invalid disk_quota: %v", raw.Name, raw.DiskQuota)
+	}
+	if raw.Buildpack != "" {
+		if len(raw.Buildpacks) > 0 {
+			return nil, invalidManifest("application %v: buildpack and buildpacks cannot be given together", raw.Name)
+		}
+		app.Buildpacks = []string{raw.Buildpack}
+	}
+	if raw.Env != nil {
+		app.Env = normalizeValue(raw.Env).(map[string]interface{})
+	}
+	for _, route := range raw.Routes {
+		if route.Route == "" {
+			return nil, invalidManifest("application %v: route shall not be empty", raw.Name)
+		}
+		app.Routes = append(app.Routes, route.Route)
+	}
+	if app.NoRoute && len(app.Routes) > 0 {
+		return nil, invalidManifest("application %v: routes and no-route cannot be given together", raw.Name)
+	}
+	if raw.Path != "" {
+		app.Path = raw.Path
+		if !filepath.IsAbs(app.Path) {
+			app.Path = filepath.Join(baseDir, app.Path)
+		}
+	}
+	return app, nil
+}
+
+// toMegabytes converts size like "512M" or "1G" to MB. Size without unit is in MB, empty size is zero.
+func toMegabytes(size string) (int64, error) {
+	if size == "" {
+		return 0, nil
+	}
+	match := sizePattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(size)))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size: %v", size)
+	}
+	value, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.TrimSuffix(match[2], "B") {
+	case "G":
+		value *= 1024
+	case "T":
+		value *= 1024 * 1024
+	}
+	return value, nil
+}
+
+// normalizeValue converts maps decoded by yaml into map[string]interface{}, so they may be encoded as JSON
+func normalizeValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := map[string]interface{}{}
+		for key, nested := range typed {
+			normalized[fmt.Sprint(key)] = normalizeValue(nested)
+		}
+		return normalized
+	case map[string]interface{}:
+		normalized := map[string]interface{}{}
+		for key, nested := range typed {
+			normalized[key] = normalizeValue(nested)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(typed))
+		for i, nested := range typed {
+			normalized[i] = normalizeValue(nested)
+		}
+		return normalized
+	}
+	return value
+}
+
+func invalidManifest(format string, args ...interface{}) error {
+	return errors.Annotate(types.InvalidManifestError, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,213 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Manifest", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "manifest-")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	Describe("parse", func() {
+		It("should decode application properties", func() {
+			manifest, err := Parse([]byte(`
+applications:
+- name: app
+  memory: 1G
+  disk_quota: 512M
+  instances: 2
+  buildpack: java_buildpack
+  command: ./start.sh
+  path: target/app.jar
+  env:
+    MODE: production
+    LIMITS:
+      max: 10
+  routes:
+  - route: app.example.com
+  services:
+  - db
+  health-check-type: http
+  health-check-http-endpoint: /health
+  timeout: 120
+`), dir, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			instances := 2
+			Expect(manifest.Applications).To(Equal([]Application{{
+				Name:                    "app",
+				Memory:                  1024,
+				DiskQuota:               512,
+				Instances:               &instances,
+				Buildpacks:              []string{"java_buildpack"},
+				Command:                 "./start.sh",
+				Env:                     map[string]interface{}{"MODE": "production", "LIMITS": map[string]interface{}{"max": 10}},
+				Routes:                  []string{"app.example.com"},
+				Services:                []string{"db"},
+				HealthCheckType:         "http",
+				HealthCheckHTTPEndpoint: "/health",
+				Timeout:                 120,
+				Path:                    filepath.Join(dir, "target/app.jar"),
+			}}))
+		})
+
+		It("should apply top level properties to every application", func() {
+			manifest, err := Parse([]byte(`
+memory: 256M
+env:
+  SHARED: "yes"
+applications:
+- name: first
+- name: second
+  memory: 128
+  env:
+    OWN: "true"
+`), dir, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Applications).To(HaveLen(2))
+			Expect(manifest.Applications[0].Memory).To(Equal(int64(256)))
+			Expect(manifest.Applications[0].Path).To(Equal(dir))
+			Expect(manifest.Applications[1].Memory).To(Equal(int64(128)))
+			Expect(manifest.Applications[1].Env).To(Equal(map[string]interface{}{"SHARED": "yes", "OWN": "true"}))
+		})
+
+		It("should substitute variables", func() {
+			vars := map[string]interface{}{"instances": 3, "domain": "example.com", "env": "qa"}
+
+			manifest, err := Parse([]byte(`
+applications:
+- name: app-((env))
+  instances: ((instances))
+  routes:
+  - route: app-((env)).((domain))
+`), dir, vars)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Applications[0].Name).To(Equal("app-qa"))
+			Expect(*manifest.Applications[0].Instances).To(Equal(3))
+			Expect(manifest.Applications[0].Routes).To(Equal([]string{"app-qa.example.com"}))
+		})
+
+		It("should report all missing variables", func() {
+			_, err := Parse([]byte(`
+applications:
+- name: ((name))
+  memory: ((memory))
+`), dir, nil)
+
+			Expect(errors.Cause(err)).To(Equal(types.InvalidManifestError))
+			Expect(errors.Details(err)).To(ContainSubstring("missing variables: memory, name"))
+		})
+
+		It("should reject invalid manifests", func() {
+			invalid := []string{
+				`applications: []`,
+				`applications: [{memory: 1G}]`,
+				`applications: [{name: app, memory: lots}]`,
+				`applications: [{name: app}, {name: app}]`,
+				`applications: [{name: app, buildpack: go, buildpacks: [go]}]`,
+				`applications: [{name: app, no-route: true, routes: [{route: app.example.com}]}]`,
+				`applications: [`,
+			}
+			for _, content := range invalid {
+				_, err := Parse([]byte(content), dir, nil)
+				Expect(errors.Cause(err)).To(Equal(types.InvalidManifestError), content)
+			}
+		})
+	})
+
+	Describe("load", func() {
+		It("should merge manifest over the inherited one", func() {
+			writeFile("base.yml", `
+instances: 2
+env:
+  LOG_LEVEL: info
+applications:
+- name: app
+  memory: 512M
+  command: ./base
+- name: worker
+`)
+			path := writeFile("manifest.yml", `
+inherit: base.yml
+env:
+  LOG_LEVEL: debug
+applications:
+- name: app
+  command: ./start
+- name: ((extra))
+`)
+
+			manifest, err := Load(path, map[string]interface{}{"extra": "cron"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Applications).To(HaveLen(3))
+			app := manifest.Applications[0]
+			Expect(app.Name).To(Equal("app"))
+			Expect(app.Memory).To(Equal(int64(512)))
+			Expect(app.Command).To(Equal("./start"))
+			Expect(*app.Instances).To(Equal(2))
+			Expect(app.Env).To(Equal(map[string]interface{}{"LOG_LEVEL": "debug"}))
+			Expect(manifest.Applications[1].Name).To(Equal("worker"))
+			Expect(manifest.Applications[2].Name).To(Equal("cron"))
+		})
+
+		It("should fail on inheritance cycle", func() {
+			writeFile("a.yml", "inherit: b.yml\napplications: [{name: a}]\n")
+			writeFile("b.yml", "inherit: a.yml\n")
+
+			_, err := Load(filepath.Join(dir, "a.yml"), nil)
+
+			Expect(errors.Cause(err)).To(Equal(types.InvalidManifestError))
+			Expect(errors.Details(err)).To(ContainSubstring("inherits from itself"))
+		})
+
+		It("should read variables from file", func() {
+			path := writeFile("vars.yml", "memory: 2G\ninstances: 4\n")
+
+			vars, err := ReadVarsFile(path)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vars).To(Equal(map[string]interface{}{"memory": "2G", "instances": 4}))
+		})
+	})
+})
@@ -0,0 +1,78 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var variablePattern = regexp.MustCompile(`\(\(([-\w.]+)\)\)`)
+
+// interpolate substitutes ((name)) variables in values of the tree. Value consisting of a single
+// variable takes the variable value as is, variables inside longer strings are formatted as strings.
+// All variables missing in vars are reported in the error.
+func interpolate(tree interface{}, vars map[string]interface{}) (interface{}, error) {
+	missing := map[string]bool{}
+	interpolated := interpolateValue(tree, vars, missing)
+	if len(missing) > 0 {
+		names := []string{}
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, invalidManifest("missing variables: %v", strings.Join(names, ", "))
+	}
+	return interpolated, nil
+}
+
+func interpolateValue(value interface{}, vars map[string]interface{}, missing map[string]bool) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		interpolated := map[string]interface{}{}
+		for key, nested := range typed {
+			interpolated[key] = interpolateValue(nested, vars, missing)
+		}
+		return interpolated
+	case []interface{}:
+		interpolated := make([]interface{}, len(typed))
+		for i, nested := range typed {
+			interpolated[i] = interpolateValue(nested, vars, missing)
+		}
+		return interpolated
+	case string:
+		if match := variablePattern.FindStringSubmatch(typed); match != nil && match[0] == typed {
+			if variable, ok := vars[match[1]]; ok {
+				return variable
+			}
+			missing[match[1]] = true
+			return typed
+		}
+		return variablePattern.ReplaceAllStringFunc(typed, func(placeholder string) string {
+			name := variablePattern.FindStringSubmatch(placeholder)[1]
+			variable, ok := vars[name]
+			if !ok {
+				missing[name] = true
+				return placeholder
+			}
+			return fmt.Sprint(variable)
+		})
+	}
+	return value
+}
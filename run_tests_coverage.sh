@@ -34,3 +34,7 @@ function run_tests_in {
 }
 
 run_tests_in api
+run_tests_in cctest
+run_tests_in manifest
+run_tests_in logs
+run_tests_in usage
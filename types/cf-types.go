@@ -16,10 +16,16 @@
 
 package types
 
+import (
+	"fmt"
+	"time"
+)
+
 // cfAppsResponse describes the Cloud Controller API result for a list of apps
 type CfAppsResponse struct {
 	Count     int             `json:"total_results"`
 	Pages     int             `json:"total_pages"`
+	NextURL   string          `json:"next_url,omitempty"`
 	Resources []CfAppResource `json:"resources"`
 }
 
@@ -74,6 +80,11 @@ type CfDomainResponse struct {
 	Entity CfDomain `json:"entity"`
 }
 
+type CfDomainsResponse struct {
+	Count     int                `json:"total_results"`
+	Resources []CfDomainResponse `json:"resources"`
+}
+
 type CfSpaceResource struct {
 	Meta   CfMeta  `json:"metadata"`
 	Entity CfSpace `json:"entity"`
@@ -110,9 +121,32 @@ type CfMeta struct {
 }
 
 type CfJob struct {
-	GUID   string `json:"guid"`
-	Status string `json:"status"`
-	Error  string `json:"error"`
+	GUID         string          `json:"guid"`
+	Status       string          `json:"status"`
+	Error        string          `json:"error"`
+	ErrorDetails *CfErrorDetails `json:"error_details,omitempty"`
+}
+
+type CfErrorDetails struct {
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+	ErrorCode   string `json:"error_code"`
+}
+
+const (
+	JobQueued   = "queued"
+	JobRunning  = "running"
+	JobFinished = "finished"
+	JobFailed   = "failed"
+)
+
+// CfResource describes application file in resource matching and bits upload requests.
+// Mode is octal permission string, e.g. "644".
+type CfResource struct {
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"size"`
+	Path string `json:"fn,omitempty"`
+	Mode string `json:"mode,omitempty"`
 }
 
 type CfJobResponse struct {
@@ -152,6 +186,59 @@ type CfApp struct {
 	Memory        int64                  `json:"memory"`
 	Path          string                 `json:"path"`
 	Envs          map[string]interface{} `json:"environment_json"`
+	// HealthCheckType is "port", "process" or "http"
+	HealthCheckType         string `json:"health_check_type,omitempty"`
+	HealthCheckTimeout      int    `json:"health_check_timeout,omitempty"`
+	HealthCheckHTTPEndpoint string `json:"health_check_http_endpoint,omitempty"`
+	StackGUID               string `json:"stack_guid,omitempty"`
+	DockerImage             string `json:"docker_image,omitempty"`
+	// DockerCredentials are only sent, CC never returns them
+	DockerCredentials *CfDockerCredentials `json:"docker_credentials,omitempty"`
+	// Diego and EnableSSH are pointers, so values not known are not sent and not reset by CC
+	Diego     *bool `json:"diego,omitempty"`
+	EnableSSH *bool `json:"enable_ssh,omitempty"`
+	Ports     []int `json:"ports,omitempty"`
+	// Fields below are set by CloudController and never need to be sent
+	PackageState             string `json:"package_state,omitempty"`
+	StagingFailedReason      string `json:"staging_failed_reason,omitempty"`
+	StagingFailedDescription string `json:"staging_failed_description,omitempty"`
+	DetectedBuildpack        string `json:"detected_buildpack,omitempty"`
+	DetectedStartCommand     string `json:"detected_start_command,omitempty"`
+	Version                  string `json:"version,omitempty"`
+	PackageUpdatedAt         string `json:"package_updated_at,omitempty"`
+	StagingTaskID            string `json:"staging_task_id,omitempty"`
+}
+
+// IsDocker tells whether app runs Docker image instead of staged bits
+func (a *CfApp) IsDocker() bool {
+	return a.DockerImage != ""
+}
+
+// ClearReadOnlyFields removes fields set by CloudController, so the app may be sent back to it
+func (a *CfApp) ClearReadOnlyFields() {
+	a.PackageState = ""
+	a.StagingFailedReason = ""
+	a.StagingFailedDescription = ""
+	a.DetectedBuildpack = ""
+	a.DetectedStartCommand = ""
+	a.Version = ""
+	a.PackageUpdatedAt = ""
+	a.StagingTaskID = ""
+}
+
+// CfDockerCredentials are used by CC to pull image from private registry.
+// Password is redacted when credentials are formatted, e.g. in logs.
+type CfDockerCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (c CfDockerCredentials) String() string {
+	return fmt.Sprintf("{Username:%v Password:[REDACTED]}", c.Username)
+}
+
+func (c CfDockerCredentials) GoString() string {
+	return "types.CfDockerCredentials" + c.String()
 }
 
 type ServiceBindingResponse struct {
@@ -159,8 +246,39 @@ type ServiceBindingResponse struct {
 }
 
 type CfAppInstance struct {
-	State string  `json:"state"`
-	Since float64 `json:"since"`
+	State   string  `json:"state"`
+	Since   float64 `json:"since"`
+	Uptime  int64   `json:"uptime,omitempty"`
+	Details string  `json:"details,omitempty"`
+}
+
+// CfAppInstanceStats describes app instance as returned by /v2/apps/:guid/stats
+type CfAppInstanceStats struct {
+	State   string          `json:"state"`
+	Details string          `json:"details,omitempty"`
+	Stats   CfInstanceStats `json:"stats"`
+}
+
+// CfInstanceStats holds instance quotas and usage. Memory and disk are in bytes, uptime in seconds.
+type CfInstanceStats struct {
+	Name      string          `json:"name"`
+	URIs      []string        `json:"uris"`
+	Host      string          `json:"host"`
+	Port      int             `json:"port"`
+	Uptime    int64           `json:"uptime"`
+	MemQuota  int64           `json:"mem_quota"`
+	DiskQuota int64           `json:"disk_quota"`
+	FdsQuota  int64           `json:"fds_quota"`
+	Usage     CfInstanceUsage `json:"usage"`
+}
+
+// CfInstanceUsage holds usage sampled at Time, which CC formats as "2006-01-02 15:04:05 -0700".
+// CPU is a fraction of a single core.
+type CfInstanceUsage struct {
+	Time string  `json:"time"`
+	CPU  float64 `json:"cpu"`
+	Mem  int64   `json:"mem"`
+	Disk int64   `json:"disk"`
 }
 
 type CfCopyBitsRequest struct {
@@ -185,9 +303,48 @@ type CfDomain struct {
 }
 
 type CfSpace struct {
-	GUID    string `json:"guid"`
-	Name    string `json:"name"`
-	OrgGUID string `json:"organization_guid"`
+	GUID           string `json:"guid"`
+	Name           string `json:"name"`
+	OrgGUID        string `json:"organization_guid"`
+	SpaceQuotaGUID string `json:"space_quota_definition_guid,omitempty"`
+	AllowSSH       *bool  `json:"allow_ssh,omitempty"`
+}
+
+type CfSpaceSummary struct {
+	GUID string         `json:"guid"`
+	Name string         `json:"name"`
+	Apps []CfAppSummary `json:"apps"`
+}
+
+type CfOrgResource struct {
+	Meta   CfMeta `json:"metadata"`
+	Entity CfOrg  `json:"entity"`
+}
+
+type CfOrg struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	QuotaGUID string `json:"quota_definition_guid"`
+}
+
+type CfOrgMemoryUsage struct {
+	MemoryUsageInMB int64 `json:"memory_usage_in_mb"`
+}
+
+type CfQuotaDefinitionResource struct {
+	Meta   CfMeta            `json:"metadata"`
+	Entity CfQuotaDefinition `json:"entity"`
+}
+
+// CfQuotaDefinition describes both organization and space quota.
+// Limits set to QuotaUnlimited are not enforced.
+type CfQuotaDefinition struct {
+	Name                string `json:"name"`
+	MemoryLimit         int64  `json:"memory_limit"`
+	InstanceMemoryLimit int64  `json:"instance_memory_limit"`
+	AppInstanceLimit    int    `json:"app_instance_limit"`
+	TotalRoutes         int    `json:"total_routes"`
+	TotalServices       int    `json:"total_services"`
 }
 
 // CfServiceContext describes a CF Service Instance within the Cloud Controller
@@ -202,9 +359,10 @@ type CfServiceContext struct {
 
 // CFBindingResponse describes a CF Service Binding within the Cloud Controller
 type CfBinding struct {
-	GUID                string `json:"guid"`
-	AppGUID             string `json:"app_guid"`
-	ServiceInstanceGUID string `json:"service_instance_guid"`
+	GUID                string                 `json:"guid"`
+	AppGUID             string                 `json:"app_guid"`
+	ServiceInstanceGUID string                 `json:"service_instance_guid"`
+	Credentials         map[string]interface{} `json:"credentials,omitempty"`
 }
 
 type CfServiceInstanceCreateRequest struct {
@@ -215,6 +373,23 @@ type CfServiceInstanceCreateRequest struct {
 	Tags      []string               `json:"tags,omitempty"`
 }
 
+type CfServiceInstancesResponse struct {
+	Count     int                         `json:"total_results"`
+	Resources []CfServiceInstanceResource `json:"resources"`
+}
+
+type CfServiceInstanceResource struct {
+	Meta   CfMeta            `json:"metadata"`
+	Entity CfServiceInstance `json:"entity"`
+}
+
+// CfServiceInstance describes managed or user provided service instance
+type CfServiceInstance struct {
+	Name      string `json:"name"`
+	SpaceGUID string `json:"space_guid"`
+	Type      string `json:"type"`
+}
+
 type CfServiceInstanceCreateResponse struct {
 	Meta CfMeta `json:"metadata"`
 }
@@ -226,7 +401,17 @@ type CfServiceBindingCreateRequest struct {
 }
 
 type CfServiceBindingCreateResponse struct {
-	Meta CfMeta `json:"metadata"`
+	Meta   CfMeta `json:"metadata"`
+	Entity struct {
+		// LastOperation is in progress when broker binds asynchronously
+		LastOperation *CfLastOperation `json:"last_operation,omitempty"`
+	} `json:"entity"`
+}
+
+type CfLastOperation struct {
+	Type        string `json:"type"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
 }
 
 type CfBindingsResources struct {
@@ -235,8 +420,49 @@ type CfBindingsResources struct {
 }
 
 type CfVcapApplication struct {
-	Name string   `json:"name"`
-	Uris []string `json:"uris"`
+	Name            string   `json:"name"`
+	Uris            []string `json:"uris"`
+	ApplicationID   string   `json:"application_id,omitempty"`
+	ApplicationName string   `json:"application_name,omitempty"`
+	ApplicationURIs []string `json:"application_uris,omitempty"`
+	SpaceID         string   `json:"space_id,omitempty"`
+	SpaceName       string   `json:"space_name,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	CfAPI           string   `json:"cf_api,omitempty"`
+	Limits          struct {
+		Disk int64 `json:"disk"`
+		Fds  int64 `json:"fds"`
+		Mem  int64 `json:"mem"`
+	} `json:"limits"`
+}
+
+// CfAppEnv describes environment of the app as returned by /v2/apps/:guid/env
+type CfAppEnv struct {
+	// StagingEnv and RunningEnv come from environment variable groups
+	StagingEnv     map[string]interface{} `json:"staging_env_json"`
+	RunningEnv     map[string]interface{} `json:"running_env_json"`
+	Environment    map[string]interface{} `json:"environment_json"`
+	SystemEnv      CfAppSystemEnv         `json:"system_env_json"`
+	ApplicationEnv CfAppApplicationEnv    `json:"application_env_json"`
+}
+
+type CfAppSystemEnv struct {
+	VcapServices map[string][]CfVcapService `json:"VCAP_SERVICES"`
+}
+
+type CfAppApplicationEnv struct {
+	VcapApplication CfVcapApplication `json:"VCAP_APPLICATION"`
+}
+
+// CfVcapService describes service instance bound to the app, keyed in VCAP_SERVICES by service label
+type CfVcapService struct {
+	Name           string                 `json:"name"`
+	Label          string                 `json:"label"`
+	Plan           string                 `json:"plan"`
+	Provider       string                 `json:"provider,omitempty"`
+	Tags           []string               `json:"tags"`
+	Credentials    map[string]interface{} `json:"credentials"`
+	SyslogDrainURL string                 `json:"syslog_drain_url,omitempty"`
 }
 
 type CfServiceBrokerResources struct {
@@ -256,15 +482,248 @@ type CfServiceBroker struct {
 	Password string `json:"auth_password"`
 }
 
+// CfInfo describes the Cloud Controller API result for /v2/info
+type CfInfo struct {
+	Name                     string `json:"name"`
+	Build                    string `json:"build"`
+	Version                  int    `json:"version"`
+	Description              string `json:"description"`
+	AuthorizationEndpoint    string `json:"authorization_endpoint"`
+	TokenEndpoint            string `json:"token_endpoint"`
+	APIVersion               string `json:"api_version"`
+	AppSSHEndpoint           string `json:"app_ssh_endpoint"`
+	AppSSHHostKeyFingerprint string `json:"app_ssh_host_key_fingerprint"`
+	AppSSHOAuthClient        string `json:"app_ssh_oauth_client"`
+	DopplerLoggingEndpoint   string `json:"doppler_logging_endpoint"`
+	RoutingEndpoint          string `json:"routing_endpoint"`
+}
+
+type CfEventsResponse struct {
+	Count     int               `json:"total_results"`
+	Pages     int               `json:"total_pages"`
+	NextURL   string            `json:"next_url,omitempty"`
+	Resources []CfEventResource `json:"resources"`
+}
+
+// CfAppUsageEvent is entity of /v2/app_usage_events, emitted when app starts, stops or changes its footprint
+type CfAppUsageEvent struct {
+	State                         string `json:"state"`
+	PreviousState                 string `json:"previous_state,omitempty"`
+	AppGUID                       string `json:"app_guid"`
+	AppName                       string `json:"app_name"`
+	SpaceGUID                     string `json:"space_guid"`
+	SpaceName                     string `json:"space_name"`
+	OrgGUID                       string `json:"org_guid"`
+	MemoryInMBPerInstance         int    `json:"memory_in_mb_per_instance"`
+	PreviousMemoryInMBPerInstance int    `json:"previous_memory_in_mb_per_instance,omitempty"`
+	InstanceCount                 int    `json:"instance_count"`
+	PreviousInstanceCount         int    `json:"previous_instance_count,omitempty"`
+	ProcessType                   string `json:"process_type,omitempty"`
+}
+
+type CfAppUsageEventResource struct {
+	Meta   CfUsageEventMeta `json:"metadata"`
+	Entity CfAppUsageEvent  `json:"entity"`
+}
+
+type CfUsageEventMeta struct {
+	GUID      string    `json:"guid"`
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CfAppUsageEventsResponse struct {
+	Count     int                       `json:"total_results"`
+	Pages     int                       `json:"total_pages"`
+	NextURL   string                    `json:"next_url,omitempty"`
+	Resources []CfAppUsageEventResource `json:"resources"`
+}
+
+type CfEventResource struct {
+	Meta   CfMeta  `json:"metadata"`
+	Entity CfEvent `json:"entity"`
+}
+
+// CfEvent describes audit event, e.g. app.crash with index, exit_status
+// and exit_description in its metadata
+type CfEvent struct {
+	Type      string                 `json:"type"`
+	Actor     string                 `json:"actor"`
+	ActorType string                 `json:"actor_type"`
+	ActorName string                 `json:"actor_name"`
+	Actee     string                 `json:"actee"`
+	ActeeType string                 `json:"actee_type"`
+	ActeeName string                 `json:"actee_name"`
+	Timestamp time.Time              `json:"timestamp"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	SpaceGUID string                 `json:"space_guid"`
+	OrgGUID   string                 `json:"organization_guid"`
+}
+
+// CfRootLinks describes links returned by the Cloud Controller root endpoint
+type CfRootLinks struct {
+	Links map[string]CfRootLink `json:"links"`
+}
+
+type CfRootLink struct {
+	Href string `json:"href"`
+	Meta struct {
+		Version string `json:"version"`
+	} `json:"meta"`
+}
+
+// CfChecksum describes v3 package or droplet checksum, Type is "sha256" or "sha1"
+type CfChecksum struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type CfV3Droplet struct {
+	GUID     string              `json:"guid"`
+	State    string              `json:"state"`
+	Checksum *CfChecksum         `json:"checksum"`
+	Links    map[string]CfV3Link `json:"links,omitempty"`
+}
+
+type CfV3Package struct {
+	GUID  string `json:"guid"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+	Data  struct {
+		Checksum *CfChecksum `json:"checksum,omitempty"`
+	} `json:"data"`
+}
+
+type CfV3PackagesResponse struct {
+	Resources []CfV3Package `json:"resources"`
+}
+
+// CfToManyRelationship describes v3 API relationship to many resources
+type CfToManyRelationship struct {
+	Data []CfRelationship `json:"data"`
+}
+
+type CfRelationship struct {
+	GUID string `json:"guid"`
+}
+
+// CfFeatureFlag is platform wide switch of CC feature, e.g. "diego_docker"
+type CfFeatureFlag struct {
+	Name         string `json:"name"`
+	Enabled      bool   `json:"enabled"`
+	Overridden   bool   `json:"overridden,omitempty"`
+	DefaultValue bool   `json:"default_value,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+// CfV3Task is one-off process run with app droplet, e.g. database migration
+type CfV3Task struct {
+	GUID       string `json:"guid,omitempty"`
+	SequenceID int    `json:"sequence_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Command    string `json:"command,omitempty"`
+	State      string `json:"state"`
+	MemoryInMB int    `json:"memory_in_mb,omitempty"`
+	DiskInMB   int    `json:"disk_in_mb,omitempty"`
+	Result     struct {
+		FailureReason string `json:"failure_reason,omitempty"`
+	} `json:"result"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type CfV3TaskCreateRequest struct {
+	Name       string `json:"name,omitempty"`
+	Command    string `json:"command"`
+	MemoryInMB int    `json:"memory_in_mb,omitempty"`
+	DiskInMB   int    `json:"disk_in_mb,omitempty"`
+}
+
+type CfV3TasksResponse struct {
+	Pagination CfV3Pagination `json:"pagination"`
+	Resources  []CfV3Task     `json:"resources"`
+}
+
+// CfV3ProcessStats describes instance of app process as returned by /v3/processes/:guid/stats
+type CfV3ProcessStats struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	State   string `json:"state"`
+	Uptime  int64  `json:"uptime"`
+	Details string `json:"details,omitempty"`
+}
+
+type CfV3ProcessStatsResponse struct {
+	Resources []CfV3ProcessStats `json:"resources"`
+}
+
+type CfV3Pagination struct {
+	TotalResults int       `json:"total_results"`
+	Next         *CfV3Link `json:"next"`
+}
+
+type CfV3Link struct {
+	Href string `json:"href"`
+}
+
 const (
 	AppStarted = "STARTED"
 	AppStopped = "STOPPED"
 )
 
+const QuotaUnlimited = -1
+
+const (
+	EventAppCrash         = "app.crash"
+	EventAppCreate        = "audit.app.create"
+	EventAppUpdate        = "audit.app.update"
+	EventAppRestage       = "audit.app.restage"
+	EventAppStart         = "audit.app.start"
+	EventAppStop          = "audit.app.stop"
+	EventAppDeleteRequest = "audit.app.delete-request"
+	EventAppSSHAuthorized = "audit.app.ssh-authorized"
+)
+
+const (
+	InstanceRunning  = "RUNNING"
+	InstanceStarting = "STARTING"
+	InstanceCrashed  = "CRASHED"
+	InstanceFlapping = "FLAPPING"
+	InstanceDown     = "DOWN"
+)
+
+const (
+	UsageStarted      = "STARTED"
+	UsageStopped      = "STOPPED"
+	UsageBuildpackSet = "BUILDPACK_SET"
+)
+
+const (
+	TaskPending   = "PENDING"
+	TaskRunning   = "RUNNING"
+	TaskCanceling = "CANCELING"
+	TaskSucceeded = "SUCCEEDED"
+	TaskFailed    = "FAILED"
+)
+
+const (
+	LastOperationInProgress = "in progress"
+	LastOperationSucceeded  = "succeeded"
+	LastOperationFailed     = "failed"
+)
+
+const (
+	PackagePending = "PENDING"
+	PackageStaged  = "STAGED"
+	PackageFailed  = "FAILED"
+)
+
 func NewCfAppResource(summary CfAppSummary, newName string, spaceGUID string) *CfAppResource {
 	summary.CfApp.Name = newName
 	summary.CfApp.State = AppStopped
 	summary.CfApp.SpaceGUID = spaceGUID
+	summary.CfApp.ClearReadOnlyFields()
 	return &CfAppResource{Meta: CfMeta{GUID: summary.GUID}, Entity: summary.CfApp}
 }
 
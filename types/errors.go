@@ -16,7 +16,10 @@
 
 package types
 
-import "github.com/signalfx/golib/errors"
+import (
+	"fmt"
+	"github.com/signalfx/golib/errors"
+)
 
 var InvalidInputError error = errors.New("Invalid request body")
 var InstanceAlreadyExistsError = errors.New("Such an instance already exists")
@@ -25,10 +28,59 @@ var ServiceNotFoundError = errors.New("No such service exists")
 var InstanceNotFoundError = errors.New("No such instance exists")
 var EntityNotFoundError = errors.New("Entity does not exist")
 var InternalServerError = errors.New("Some internal error occurred")
+var InvalidConfigurationError = errors.New("Invalid client configuration")
 var CcJobFailedError = errors.New("Error occurred while copying bits")
+var CcTaskFailedError = errors.New("Task failed")
+var CcBindingFailedError = errors.New("Service binding failed")
+var CcUploadBitsFailedError = errors.New("Error occurred while uploading bits")
+var InvalidBitsSourceError = errors.New("Invalid application bits source")
+var InvalidManifestError = errors.New("Invalid manifest")
+var HealthProbeFailedError = errors.New("Health probe of the app failed")
+var SSHUnavailableError = errors.New("SSH access is not available")
+var SSHCodeFailedError = errors.New("Error occurred while getting SSH code")
+var UsageCursorError = errors.New("Error occurred while storing usage events cursor")
+var LogsUnavailableError = errors.New("Logs endpoint is not available")
+var LogsFetchFailedError = errors.New("Error occurred while fetching logs")
+var CcDownloadFailedError = errors.New("Error occurred while downloading")
+var DownloadVerificationFailedError = errors.New("Downloaded data does not match expected size or checksum")
 var CcCreateAppFailedError = errors.New("Error occurred while creating new app")
 var CcRestageFailedError = errors.New("Error occurred while restaging")
 var CcUpdateFailedError = errors.New("Error occurred while app updating")
+var CcStagingFailedError = errors.New("App staging failed")
+var AppCrashedError = errors.New("App instance crashed")
 var CcGetInstancesFailedError = errors.New("Error occurred while getting app instances")
+var InvalidScaleRequestError = errors.New("Invalid scale request")
+var QuotaExceededError = errors.New("Requested resources exceed quota")
+var CcRestartInstanceFailedError = errors.New("Error occurred while restarting app instance")
+var RollingUpdateAbortedError = errors.New("Rolling update aborted")
+var InvalidEnvPatchError = errors.New("Invalid environment patch")
+var CcUpdateConfigFailedError = errors.New("Error occurred while updating platform configuration")
 var TimeoutOccurredError = errors.New("Asynchronous call timeouted")
 var ExistingInstancesError = errors.New("Can't remove service with existing instances from catalog")
+
+// ErrMalformedResponse is returned when CloudController response can not be understood,
+// e.g. when router returned HTML page or required field is missing
+type ErrMalformedResponse struct {
+	StatusCode  int
+	ContentType string
+	Reason      string
+	BodySnippet string
+}
+
+func (e *ErrMalformedResponse) Error() string {
+	return fmt.Sprintf("Malformed response from CC (%d, %v): %v. Body: [%v]",
+		e.StatusCode, e.ContentType, e.Reason, e.BodySnippet)
+}
+
+// ErrUnsupportedByFoundation is returned when operation requires feature not available
+// in the Cloud Controller API version of the foundation
+type ErrUnsupportedByFoundation struct {
+	Feature         string
+	APIVersion      string
+	RequiredVersion string
+}
+
+func (e *ErrUnsupportedByFoundation) Error() string {
+	return fmt.Sprintf("%v is not supported by foundation with CC API %v (required: %v)",
+		e.Feature, e.APIVersion, e.RequiredVersion)
+}
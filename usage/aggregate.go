@@ -0,0 +1,178 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package usage
+
+import (
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"sort"
+	"time"
+)
+
+// Interval is time the app ran with constant memory and instance count
+type Interval struct {
+	OrgGUID     string    `json:"org_guid"`
+	SpaceGUID   string    `json:"space_guid"`
+	SpaceName   string    `json:"space_name"`
+	AppGUID     string    `json:"app_guid"`
+	AppName     string    `json:"app_name"`
+	ProcessType string    `json:"process_type,omitempty"`
+	MemoryInMB  int       `json:"memory_in_mb_per_instance"`
+	Instances   int       `json:"instance_count"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	// Open interval has not ended yet, End is the time it was reported until
+	Open bool `json:"open,omitempty"`
+}
+
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// MemoryGBHours is memory of all instances in GB multiplied by hours they ran
+func (i Interval) MemoryGBHours() float64 {
+	return float64(i.MemoryInMB*i.Instances) / 1024 * i.Duration().Hours()
+}
+
+// Total sums intervals of an app, or of a space when app fields are empty
+type Total struct {
+	OrgGUID       string
+	SpaceGUID     string
+	SpaceName     string
+	AppGUID       string
+	AppName       string
+	Duration      time.Duration
+	MemoryGBHours float64
+}
+
+// Aggregator turns STARTED and STOPPED app usage events into intervals. STARTED event of app
+// already running, emitted e.g. when it is scaled, ends the current interval and opens a new one.
+// Aggregator is JSON serializable, so intervals open at the end of one run may be closed by the next one.
+type Aggregator struct {
+	Closed []Interval           `json:"closed"`
+	Opened map[string]*Interval `json:"opened"`
+}
+
+func NewAggregator() *Aggregator {
+	return &Aggregator{Closed: []Interval{}, Opened: map[string]*Interval{}}
+}
+
+// Add accounts event. Events have to be added in order. Events other than STARTED and STOPPED are skipped.
+func (a *Aggregator) Add(event types.CfAppUsageEventResource) {
+	if a.Opened == nil {
+		a.Opened = map[string]*Interval{}
+	}
+	entity := event.Entity
+	key := entity.AppGUID + "/" + entity.ProcessType
+	switch entity.State {
+	case types.UsageStarted, types.UsageStopped:
+	default:
+		return
+	}
+
+	if open, ok := a.Opened[key]; ok {
+		open.End = event.Meta.CreatedAt
+		a.Closed = append(a.Closed, *open)
+		delete(a.Opened, key)
+	}
+	if entity.State == types.UsageStarted {
+		a.Opened[key] = &Interval{
+			OrgGUID:     entity.OrgGUID,
+			SpaceGUID:   entity.SpaceGUID,
+			SpaceName:   entity.SpaceName,
+			AppGUID:     entity.AppGUID,
+			AppName:     entity.AppName,
+			ProcessType: entity.ProcessType,
+			MemoryInMB:  entity.MemoryInMBPerInstance,
+			Instances:   entity.InstanceCount,
+			Start:       event.Meta.CreatedAt,
+		}
+	}
+}
+
+// Intervals returns closed intervals and the open ones cut at until, ordered by start
+func (a *Aggregator) Intervals(until time.Time) []Interval {
+	toReturn := append([]Interval{}, a.Closed...)
+	for _, open := range a.Opened {
+		interval := *open
+		interval.End = until
+		interval.Open = true
+		if interval.End.Before(interval.Start) {
+			interval.End = interval.Start
+		}
+		toReturn = append(toReturn, interval)
+	}
+	sort.Sort(byStart(toReturn))
+	return toReturn
+}
+
+// Summarize sums intervals per app and per space, ordered by space and app name
+func Summarize(intervals []Interval) (apps []Total, spaces []Total) {
+	appTotals := map[string]*Total{}
+	spaceTotals := map[string]*Total{}
+	for _, interval := range intervals {
+		app, ok := appTotals[interval.AppGUID]
+		if !ok {
+			app = &Total{OrgGUID: interval.OrgGUID, SpaceGUID: interval.SpaceGUID, SpaceName: interval.SpaceName,
+				AppGUID: interval.AppGUID, AppName: interval.AppName}
+			appTotals[interval.AppGUID] = app
+		}
+		space, ok := spaceTotals[interval.SpaceGUID]
+		if !ok {
+			space = &Total{OrgGUID: interval.OrgGUID, SpaceGUID: interval.SpaceGUID, SpaceName: interval.SpaceName}
+			spaceTotals[interval.SpaceGUID] = space
+		}
+		for _, total := range []*Total{app, space} {
+			total.Duration += interval.Duration()
+			total.MemoryGBHours += interval.MemoryGBHours()
+		}
+	}
+	return sortedTotals(appTotals), sortedTotals(spaceTotals)
+}
+
+func sortedTotals(totals map[string]*Total) []Total {
+	toReturn := []Total{}
+	for _, total := range totals {
+		toReturn = append(toReturn, *total)
+	}
+	sort.Sort(byName(toReturn))
+	return toReturn
+}
+
+type byStart []Interval
+
+func (s byStart) Len() int      { return len(s) }
+func (s byStart) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byStart) Less(i, j int) bool {
+	if !s[i].Start.Equal(s[j].Start) {
+		return s[i].Start.Before(s[j].Start)
+	}
+	return s[i].AppGUID+s[i].ProcessType < s[j].AppGUID+s[j].ProcessType
+}
+
+type byName []Total
+
+func (s byName) Len() int      { return len(s) }
+func (s byName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byName) Less(i, j int) bool {
+	if s[i].SpaceName != s[j].SpaceName {
+		return s[i].SpaceName < s[j].SpaceName
+	}
+	if s[i].AppName != s[j].AppName {
+		return s[i].AppName < s[j].AppName
+	}
+	return s[i].SpaceGUID+s[i].AppGUID < s[j].SpaceGUID+s[j].AppGUID
+}
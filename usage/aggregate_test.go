@@ -0,0 +1,115 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Aggregator", func() {
+
+	var (
+		sut  *Aggregator
+		base time.Time
+	)
+
+	event := func(state, appGUID, spaceGUID string, memory, instances int, at time.Duration) types.CfAppUsageEventResource {
+		return types.CfAppUsageEventResource{
+			Meta: types.CfUsageEventMeta{GUID: appGUID + at.String(), CreatedAt: base.Add(at)},
+			Entity: types.CfAppUsageEvent{State: state, AppGUID: appGUID, AppName: appGUID + "-name",
+				SpaceGUID: spaceGUID, SpaceName: spaceGUID + "-name", OrgGUID: "org",
+				MemoryInMBPerInstance: memory, InstanceCount: instances},
+		}
+	}
+
+	BeforeEach(func() {
+		sut = NewAggregator()
+		base = time.Date(2016, 9, 1, 0, 0, 0, 0, time.UTC)
+		sut.Add(event(types.UsageStarted, "web", "prod", 1024, 2, 0))
+		sut.Add(event(types.UsageBuildpackSet, "web", "prod", 1024, 2, time.Minute))
+		// scaling emits STARTED of running app
+		sut.Add(event(types.UsageStarted, "web", "prod", 512, 4, time.Hour))
+		sut.Add(event(types.UsageStopped, "web", "prod", 512, 4, 3*time.Hour))
+		sut.Add(event(types.UsageStarted, "worker", "prod", 2048, 1, 2*time.Hour))
+		sut.Add(event(types.UsageStarted, "api", "dev", 256, 1, 0))
+		sut.Add(event(types.UsageStopped, "api", "dev", 256, 1, 30*time.Minute))
+	})
+
+	It("should build intervals with open ones cut at report time", func() {
+		intervals := sut.Intervals(base.Add(4 * time.Hour))
+
+		Expect(intervals).To(HaveLen(4))
+		Expect(intervals[1].AppGUID).To(Equal("web"))
+		Expect(intervals[1].Duration()).To(Equal(time.Hour))
+		Expect(intervals[1].MemoryGBHours()).To(Equal(2.0))
+		Expect(intervals[2].MemoryGBHours()).To(Equal(4.0))
+		Expect(intervals[3].AppGUID).To(Equal("worker"))
+		Expect(intervals[3].Open).To(BeTrue())
+		Expect(intervals[3].Duration()).To(Equal(2 * time.Hour))
+	})
+
+	It("should sum memory hours per app and per space", func() {
+		apps, spaces := Summarize(sut.Intervals(base.Add(4 * time.Hour)))
+
+		Expect(apps).To(HaveLen(3))
+		Expect(apps[0].AppGUID).To(Equal("api"))
+		Expect(apps[0].MemoryGBHours).To(Equal(0.125))
+		Expect(apps[1].AppGUID).To(Equal("web"))
+		Expect(apps[1].MemoryGBHours).To(Equal(6.0))
+		Expect(apps[1].Duration).To(Equal(3 * time.Hour))
+		Expect(spaces).To(HaveLen(2))
+		Expect(spaces[1].SpaceGUID).To(Equal("prod"))
+		Expect(spaces[1].AppGUID).To(BeEmpty())
+		Expect(spaces[1].MemoryGBHours).To(Equal(10.0))
+	})
+
+	It("should close interval opened before serialization", func() {
+		raw, err := json.Marshal(sut)
+		Expect(err).NotTo(HaveOccurred())
+		restored := &Aggregator{}
+		Expect(json.Unmarshal(raw, restored)).To(Succeed())
+
+		restored.Add(event(types.UsageStopped, "worker", "prod", 2048, 1, 5*time.Hour))
+
+		intervals := restored.Intervals(base.Add(6 * time.Hour))
+		Expect(intervals).To(HaveLen(4))
+		Expect(intervals[3].Open).To(BeFalse())
+		Expect(intervals[3].Duration()).To(Equal(3 * time.Hour))
+	})
+
+	It("should export intervals and totals to CSV", func() {
+		intervals := sut.Intervals(base.Add(4 * time.Hour))
+		apps, _ := Summarize(intervals)
+		buffer := &bytes.Buffer{}
+
+		Expect(WriteIntervalsCSV(buffer, intervals)).To(Succeed())
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		Expect(lines).To(HaveLen(5))
+		Expect(lines[0]).To(HavePrefix("org_guid,space_guid,space_name,app_guid,app_name"))
+		Expect(lines[4]).To(Equal("org,prod,prod-name,worker,worker-name,,2048,1,2016-09-01T02:00:00Z,2016-09-01T04:00:00Z,true,2.0000,4.0000"))
+
+		buffer.Reset()
+		Expect(WriteTotalsCSV(buffer, apps)).To(Succeed())
+		Expect(strings.Split(strings.TrimSpace(buffer.String()), "\n")[2]).To(Equal("org,prod,prod-name,web,web-name,3.0000,6.0000"))
+	})
+})
@@ -0,0 +1,88 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package usage
+
+import (
+	log "github.com/cihub/seelog"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/types"
+)
+
+// Consumer reads /v2/app_usage_events from where it stopped last time
+type Consumer struct {
+	API   *api.CfAPI
+	Store CursorStore
+	// PageSize above api.MaxUsageEventsPageSize is lowered to it, as CC returns no more
+	PageSize int
+}
+
+// NewConsumer creates consumer keeping its cursor in store. Nil store means DefaultCursorFile.
+func NewConsumer(c *api.CfAPI, store CursorStore) *Consumer {
+	if store == nil {
+		store = NewFileCursorStore(DefaultCursorFile)
+	}
+	return &Consumer{API: c, Store: store, PageSize: api.DefaultUsageEventsPageSize}
+}
+
+// Consume passes events following the saved cursor to handler, in order, until no more events
+// are available, and returns number of events handled. Cursor is saved after every page, and
+// on handler error it points to the last event handled successfully, so no event is lost or repeated.
+func (c *Consumer) Consume(handler func(types.CfAppUsageEventResource) error) (int, error) {
+	cursor, err := c.Store.Load()
+	if err != nil {
+		return 0, err
+	}
+	pageSize := c.PageSize
+	if pageSize <= 0 {
+		pageSize = api.DefaultUsageEventsPageSize
+	} else if pageSize > api.MaxUsageEventsPageSize {
+		pageSize = api.MaxUsageEventsPageSize
+	}
+
+	consumed := 0
+	for {
+		events, err := c.API.GetAppUsageEvents(cursor, pageSize)
+		if err != nil {
+			return consumed, err
+		}
+		for _, event := range events {
+			if err := handler(event); err != nil {
+				log.Errorf("Handling app usage event %v failed: %v", event.Meta.GUID, err)
+				if saveErr := c.save(cursor, consumed); saveErr != nil {
+					log.Errorf("Could not save usage events cursor: %v", saveErr)
+				}
+				return consumed, err
+			}
+			cursor = event.Meta.GUID
+			consumed++
+		}
+		if err := c.save(cursor, consumed); err != nil {
+			return consumed, err
+		}
+		if len(events) < pageSize {
+			log.Infof("Consumed %d app usage event(s), cursor: [%v]", consumed, cursor)
+			return consumed, nil
+		}
+	}
+}
+
+func (c *Consumer) save(cursor string, consumed int) error {
+	if consumed == 0 {
+		return nil
+	}
+	return c.Store.Save(cursor)
+}
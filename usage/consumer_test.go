@@ -0,0 +1,124 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package usage
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/api"
+	"github.com/trustedanalytics/go-cf-lib/cctest"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Consumer", func() {
+
+	var (
+		fake   *cctest.FakeCC
+		dir    string
+		store  *FileCursorStore
+		sut    *Consumer
+		guids  []string
+		base   time.Time
+		events []string
+	)
+
+	collect := func(event types.CfAppUsageEventResource) error {
+		events = append(events, event.Meta.GUID)
+		return nil
+	}
+
+	BeforeEach(func() {
+		fake = cctest.NewFakeCC()
+		var err error
+		dir, err = ioutil.TempDir("", "usage-")
+		Expect(err).NotTo(HaveOccurred())
+		store = NewFileCursorStore(filepath.Join(dir, "cursor"))
+		sut = NewConsumer(&api.CfAPI{BaseAddress: fake.URL, Client: http.DefaultClient}, store)
+		sut.PageSize = 2
+		base = time.Date(2016, 9, 1, 0, 0, 0, 0, time.UTC)
+		guids = []string{}
+		for i := 0; i < 5; i++ {
+			guids = append(guids, fake.AddAppUsageEvent(types.CfAppUsageEvent{State: types.UsageStarted, AppGUID: "app"},
+				base.Add(time.Duration(i)*time.Hour)))
+		}
+		events = []string{}
+	})
+
+	AfterEach(func() {
+		fake.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("should page with after_guid and continue from saved cursor", func() {
+		Expect(sut.Consume(collect)).To(Equal(5))
+		Expect(events).To(Equal(guids))
+		Expect(store.Load()).To(Equal(guids[4]))
+		Expect(fake.Requests()).To(ContainElement("GET /v2/app_usage_events?after_guid=" + guids[1] +
+			"&order-direction=asc&results-per-page=2"))
+
+		next := fake.AddAppUsageEvent(types.CfAppUsageEvent{State: types.UsageStopped, AppGUID: "app"}, base.Add(6*time.Hour))
+		events = []string{}
+		restarted := NewConsumer(sut.API, NewFileCursorStore(store.Path))
+
+		Expect(restarted.Consume(collect)).To(Equal(1))
+		Expect(events).To(Equal([]string{next}))
+	})
+
+	It("should not stop early when page size exceeds what CC returns", func() {
+		for i := 5; i < 120; i++ {
+			guids = append(guids, fake.AddAppUsageEvent(types.CfAppUsageEvent{State: types.UsageStarted, AppGUID: "app"},
+				base.Add(time.Duration(i)*time.Hour)))
+		}
+		sut.PageSize = 500
+
+		Expect(sut.Consume(collect)).To(Equal(120))
+		Expect(events).To(Equal(guids))
+	})
+
+	It("should keep cursor at last handled event when handler fails", func() {
+		failure := errors.New("database down")
+		count, err := sut.Consume(func(event types.CfAppUsageEventResource) error {
+			if event.Meta.GUID == guids[3] {
+				return failure
+			}
+			return nil
+		})
+
+		Expect(err).To(Equal(failure))
+		Expect(count).To(Equal(3))
+		Expect(store.Load()).To(Equal(guids[2]))
+	})
+
+	It("should return CC error for unknown cursor", func() {
+		Expect(store.Save("purged-event")).To(Succeed())
+
+		_, err := sut.Consume(collect)
+
+		Expect(err).To(HaveOccurred())
+		Expect(events).To(BeEmpty())
+	})
+
+	It("should use file store by default", func() {
+		Expect(NewConsumer(sut.API, nil).Store).To(Equal(NewFileCursorStore(DefaultCursorFile)))
+	})
+})
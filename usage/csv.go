@@ -0,0 +1,54 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package usage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteIntervalsCSV writes intervals with header row. Times are RFC3339, durations in hours.
+func WriteIntervalsCSV(w io.Writer, intervals []Interval) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"org_guid", "space_guid", "space_name", "app_guid", "app_name", "process_type",
+		"memory_in_mb_per_instance", "instance_count", "start", "end", "open", "hours", "memory_gb_hours"})
+	for _, i := range intervals {
+		writer.Write([]string{i.OrgGUID, i.SpaceGUID, i.SpaceName, i.AppGUID, i.AppName, i.ProcessType,
+			fmt.Sprint(i.MemoryInMB), fmt.Sprint(i.Instances), i.Start.UTC().Format(time.RFC3339),
+			i.End.UTC().Format(time.RFC3339), fmt.Sprint(i.Open), decimal(i.Duration().Hours()), decimal(i.MemoryGBHours())})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteTotalsCSV writes per-app or per-space totals with header row
+func WriteTotalsCSV(w io.Writer, totals []Total) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"org_guid", "space_guid", "space_name", "app_guid", "app_name", "hours", "memory_gb_hours"})
+	for _, t := range totals {
+		writer.Write([]string{t.OrgGUID, t.SpaceGUID, t.SpaceName, t.AppGUID, t.AppName,
+			decimal(t.Duration.Hours()), decimal(t.MemoryGBHours)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func decimal(v float64) string {
+	return fmt.Sprintf("%.4f", v)
+}
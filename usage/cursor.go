@@ -0,0 +1,95 @@
+/**
+ * Copyright (c) 2016 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package usage
+
+import (
+	"github.com/signalfx/golib/errors"
+	"github.com/trustedanalytics/go-cf-lib/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultCursorFile is the file keeping cursor of consumer created without store
+const DefaultCursorFile = "app-usage-events.cursor"
+
+// CursorStore keeps GUID of the last consumed event, so consumption continues after restart
+type CursorStore interface {
+	// Load returns saved GUID, or empty string when nothing was consumed yet
+	Load() (string, error)
+	Save(guid string) error
+}
+
+// FileCursorStore keeps cursor in a file. The file is replaced atomically on save.
+type FileCursorStore struct {
+	Path string
+}
+
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{Path: path}
+}
+
+func (s *FileCursorStore) Load() (string, error) {
+	content, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(types.UsageCursorError, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (s *FileCursorStore) Save(guid string) error {
+	temp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".")
+	if err != nil {
+		return errors.Wrap(types.UsageCursorError, err)
+	}
+	_, err = temp.WriteString(guid + "\n")
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), s.Path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return errors.Wrap(types.UsageCursorError, err)
+	}
+	return nil
+}
+
+// MemoryCursorStore keeps cursor in memory, e.g. for tests or one-off reports
+type MemoryCursorStore struct {
+	mutex sync.Mutex
+	guid  string
+}
+
+func (s *MemoryCursorStore) Load() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.guid, nil
+}
+
+func (s *MemoryCursorStore) Save(guid string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.guid = guid
+	return nil
+}